@@ -32,12 +32,14 @@ type CheckRequest struct {
 	ObjectId string `protobuf:"bytes,2,opt,name=object_id,json=objectId,proto3" json:"object_id,omitempty"`
 	// The relation/permission being checked
 	Relation string `protobuf:"bytes,3,opt,name=relation,proto3" json:"relation,omitempty"`
-	// The user ID to check permissions for
+	// The user ID to check permissions for, which must not be a wildcard
 	UserId string `protobuf:"bytes,4,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
 	// Optional consistency token for causally consistent reads
 	ConsistencyToken string `protobuf:"bytes,5,opt,name=consistency_token,json=consistencyToken,proto3" json:"consistency_token,omitempty"`
 	// Optional context for conditional permissions
-	Context       map[string]string `protobuf:"bytes,6,rep,name=context,proto3" json:"context,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Context map[string]string `protobuf:"bytes,6,rep,name=context,proto3" json:"context,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Whether to include debug information in the response
+	Debug         bool `protobuf:"varint,7,opt,name=debug,proto3" json:"debug,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -114,6 +116,13 @@ func (x *CheckRequest) GetContext() map[string]string {
 	return nil
 }
 
+func (x *CheckRequest) GetDebug() bool {
+	if x != nil {
+		return x.Debug
+	}
+	return false
+}
+
 // CheckResponse contains the result of an authorization check
 type CheckResponse struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
@@ -124,9 +133,14 @@ type CheckResponse struct {
 	// When this check was performed
 	CheckedAt *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=checked_at,json=checkedAt,proto3" json:"checked_at,omitempty"`
 	// Optional debug information (only included in debug mode)
-	DebugInfo     *DebugInfo `protobuf:"bytes,4,opt,name=debug_info,json=debugInfo,proto3" json:"debug_info,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	DebugInfo *DebugInfo `protobuf:"bytes,4,opt,name=debug_info,json=debugInfo,proto3" json:"debug_info,omitempty"`
+	// Whether the result depends on context the request did not provide
+	// When set, allowed is false and missing_context lists the keys needed
+	Conditional bool `protobuf:"varint,5,opt,name=conditional,proto3" json:"conditional,omitempty"`
+	// Context keys required to evaluate the conditions involved in the check
+	MissingContext []string `protobuf:"bytes,6,rep,name=missing_context,json=missingContext,proto3" json:"missing_context,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
 }
 
 func (x *CheckResponse) Reset() {
@@ -187,6 +201,20 @@ func (x *CheckResponse) GetDebugInfo() *DebugInfo {
 	return nil
 }
 
+func (x *CheckResponse) GetConditional() bool {
+	if x != nil {
+		return x.Conditional
+	}
+	return false
+}
+
+func (x *CheckResponse) GetMissingContext() []string {
+	if x != nil {
+		return x.MissingContext
+	}
+	return nil
+}
+
 // ExpandRequest asks for expansion of a userset
 type ExpandRequest struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
@@ -336,7 +364,7 @@ func (x *ExpandResponse) GetExpandedAt() *timestamppb.Timestamp {
 // ListPermissionsRequest asks for all permissions of a user
 type ListPermissionsRequest struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
-	// The user ID to list permissions for
+	// The user ID to list permissions for, which must not be a wildcard
 	UserId string `protobuf:"bytes,1,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
 	// Optional namespace filter
 	Namespace string `protobuf:"bytes,2,opt,name=namespace,proto3" json:"namespace,omitempty"`
@@ -675,24 +703,27 @@ var File_authorization_proto protoreflect.FileDescriptor
 
 const file_authorization_proto_rawDesc = "" +
 	"\n" +
-	"\x13authorization.proto\x12\bgoacl.v1\x1a\x1cgoogle/api/annotations.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\vtypes.proto\"\xa6\x02\n" +
+	"\x13authorization.proto\x12\bgoacl.v1\x1a\x1cgoogle/api/annotations.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\vtypes.proto\"\xbc\x02\n" +
 	"\fCheckRequest\x12\x1c\n" +
 	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x12\x1b\n" +
 	"\tobject_id\x18\x02 \x01(\tR\bobjectId\x12\x1a\n" +
 	"\brelation\x18\x03 \x01(\tR\brelation\x12\x17\n" +
 	"\auser_id\x18\x04 \x01(\tR\x06userId\x12+\n" +
 	"\x11consistency_token\x18\x05 \x01(\tR\x10consistencyToken\x12=\n" +
-	"\acontext\x18\x06 \x03(\v2#.goacl.v1.CheckRequest.ContextEntryR\acontext\x1a:\n" +
+	"\acontext\x18\x06 \x03(\v2#.goacl.v1.CheckRequest.ContextEntryR\acontext\x12\x14\n" +
+	"\x05debug\x18\a \x01(\bR\x05debug\x1a:\n" +
 	"\fContextEntry\x12\x10\n" +
 	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
-	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xc5\x01\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\x90\x02\n" +
 	"\rCheckResponse\x12\x18\n" +
 	"\aallowed\x18\x01 \x01(\bR\aallowed\x12+\n" +
 	"\x11consistency_token\x18\x02 \x01(\tR\x10consistencyToken\x129\n" +
 	"\n" +
 	"checked_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tcheckedAt\x122\n" +
 	"\n" +
-	"debug_info\x18\x04 \x01(\v2\x13.goacl.v1.DebugInfoR\tdebugInfo\"\xb0\x01\n" +
+	"debug_info\x18\x04 \x01(\v2\x13.goacl.v1.DebugInfoR\tdebugInfo\x12 \n" +
+	"\vconditional\x18\x05 \x01(\bR\vconditional\x12'\n" +
+	"\x0fmissing_context\x18\x06 \x03(\tR\x0emissingContext\"\xb0\x01\n" +
 	"\rExpandRequest\x12\x1c\n" +
 	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x12\x1b\n" +
 	"\tobject_id\x18\x02 \x01(\tR\bobjectId\x12\x1a\n" +
@@ -23,6 +23,59 @@ const (
 	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
 )
 
+// ChangeType is how the relation changed
+type RelationDiff_ChangeType int32
+
+const (
+	RelationDiff_CHANGE_TYPE_UNSPECIFIED RelationDiff_ChangeType = 0
+	RelationDiff_CHANGE_TYPE_ADDED       RelationDiff_ChangeType = 1
+	RelationDiff_CHANGE_TYPE_REMOVED     RelationDiff_ChangeType = 2
+	RelationDiff_CHANGE_TYPE_MODIFIED    RelationDiff_ChangeType = 3
+)
+
+// Enum value maps for RelationDiff_ChangeType.
+var (
+	RelationDiff_ChangeType_name = map[int32]string{
+		0: "CHANGE_TYPE_UNSPECIFIED",
+		1: "CHANGE_TYPE_ADDED",
+		2: "CHANGE_TYPE_REMOVED",
+		3: "CHANGE_TYPE_MODIFIED",
+	}
+	RelationDiff_ChangeType_value = map[string]int32{
+		"CHANGE_TYPE_UNSPECIFIED": 0,
+		"CHANGE_TYPE_ADDED":       1,
+		"CHANGE_TYPE_REMOVED":     2,
+		"CHANGE_TYPE_MODIFIED":    3,
+	}
+)
+
+func (x RelationDiff_ChangeType) Enum() *RelationDiff_ChangeType {
+	p := new(RelationDiff_ChangeType)
+	*p = x
+	return p
+}
+
+func (x RelationDiff_ChangeType) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (RelationDiff_ChangeType) Descriptor() protoreflect.EnumDescriptor {
+	return file_configuration_proto_enumTypes[0].Descriptor()
+}
+
+func (RelationDiff_ChangeType) Type() protoreflect.EnumType {
+	return &file_configuration_proto_enumTypes[0]
+}
+
+func (x RelationDiff_ChangeType) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use RelationDiff_ChangeType.Descriptor instead.
+func (RelationDiff_ChangeType) EnumDescriptor() ([]byte, []int) {
+	return file_configuration_proto_rawDescGZIP(), []int{19, 0}
+}
+
 // WriteNamespaceRequest contains the namespace configuration to write
 type WriteNamespaceRequest struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
@@ -31,9 +84,23 @@ type WriteNamespaceRequest struct {
 	// Optional consistency token
 	ConsistencyToken string `protobuf:"bytes,2,opt,name=consistency_token,json=consistencyToken,proto3" json:"consistency_token,omitempty"`
 	// Whether to allow updates to existing namespaces
-	AllowUpdate   bool `protobuf:"varint,3,opt,name=allow_update,json=allowUpdate,proto3" json:"allow_update,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	AllowUpdate bool `protobuf:"varint,3,opt,name=allow_update,json=allowUpdate,proto3" json:"allow_update,omitempty"`
+	// The version of the stored configuration an update replaces, or 0 when
+	// the namespace must not exist yet
+	// Updates fail with ABORTED when the stored version differs, so concurrent
+	// edits cannot overwrite each other
+	ExpectedVersion int64 `protobuf:"varint,4,opt,name=expected_version,json=expectedVersion,proto3" json:"expected_version,omitempty"`
+	// The configuration written in the schema language instead of config,
+	// defining exactly one namespace
+	Schema string `protobuf:"bytes,5,opt,name=schema,proto3" json:"schema,omitempty"`
+	// Who makes the change, recorded in the revision the write creates
+	Author string `protobuf:"bytes,6,opt,name=author,proto3" json:"author,omitempty"`
+	// Apply an update even when it removes relations that still have tuples
+	// or changes rewrite rules in ways that can take access away
+	// Without it such updates fail with FAILED_PRECONDITION
+	AllowBreakingChanges bool `protobuf:"varint,7,opt,name=allow_breaking_changes,json=allowBreakingChanges,proto3" json:"allow_breaking_changes,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
 }
 
 func (x *WriteNamespaceRequest) Reset() {
@@ -87,6 +154,34 @@ func (x *WriteNamespaceRequest) GetAllowUpdate() bool {
 	return false
 }
 
+func (x *WriteNamespaceRequest) GetExpectedVersion() int64 {
+	if x != nil {
+		return x.ExpectedVersion
+	}
+	return 0
+}
+
+func (x *WriteNamespaceRequest) GetSchema() string {
+	if x != nil {
+		return x.Schema
+	}
+	return ""
+}
+
+func (x *WriteNamespaceRequest) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *WriteNamespaceRequest) GetAllowBreakingChanges() bool {
+	if x != nil {
+		return x.AllowBreakingChanges
+	}
+	return false
+}
+
 // WriteNamespaceResponse confirms the write operation
 type WriteNamespaceResponse struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
@@ -397,9 +492,12 @@ type DeleteNamespaceRequest struct {
 	// Optional consistency token
 	ConsistencyToken string `protobuf:"bytes,2,opt,name=consistency_token,json=consistencyToken,proto3" json:"consistency_token,omitempty"`
 	// Whether to force delete even if tuples exist
-	Force         bool `protobuf:"varint,3,opt,name=force,proto3" json:"force,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	// The tuples of the namespace are deleted along with it
+	Force bool `protobuf:"varint,3,opt,name=force,proto3" json:"force,omitempty"`
+	// Optional version the stored configuration must be at
+	ExpectedVersion int64 `protobuf:"varint,4,opt,name=expected_version,json=expectedVersion,proto3" json:"expected_version,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
 }
 
 func (x *DeleteNamespaceRequest) Reset() {
@@ -453,13 +551,22 @@ func (x *DeleteNamespaceRequest) GetForce() bool {
 	return false
 }
 
+func (x *DeleteNamespaceRequest) GetExpectedVersion() int64 {
+	if x != nil {
+		return x.ExpectedVersion
+	}
+	return 0
+}
+
 // DeleteNamespaceResponse confirms the delete operation
 type DeleteNamespaceResponse struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// Consistency token for subsequent operations
 	ConsistencyToken string `protobuf:"bytes,1,opt,name=consistency_token,json=consistencyToken,proto3" json:"consistency_token,omitempty"`
 	// When the namespace was deleted
-	DeletedAt     *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=deleted_at,json=deletedAt,proto3" json:"deleted_at,omitempty"`
+	DeletedAt *timestamppb.Timestamp `protobuf:"bytes,2,opt,name=deleted_at,json=deletedAt,proto3" json:"deleted_at,omitempty"`
+	// Number of relation tuples deleted with the namespace
+	TuplesDeleted int64 `protobuf:"varint,3,opt,name=tuples_deleted,json=tuplesDeleted,proto3" json:"tuples_deleted,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -508,15 +615,28 @@ func (x *DeleteNamespaceResponse) GetDeletedAt() *timestamppb.Timestamp {
 	return nil
 }
 
+func (x *DeleteNamespaceResponse) GetTuplesDeleted() int64 {
+	if x != nil {
+		return x.TuplesDeleted
+	}
+	return 0
+}
+
 // ValidateNamespaceRequest contains a namespace configuration to validate
 type ValidateNamespaceRequest struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
 	// The namespace name being validated
 	Namespace string `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
 	// The namespace configuration to validate
-	Config        *NamespaceConfig `protobuf:"bytes,2,opt,name=config,proto3" json:"config,omitempty"`
-	unknownFields protoimpl.UnknownFields
-	sizeCache     protoimpl.SizeCache
+	Config *NamespaceConfig `protobuf:"bytes,2,opt,name=config,proto3" json:"config,omitempty"`
+	// The configuration written in the schema language instead of config,
+	// defining exactly one namespace
+	Schema string `protobuf:"bytes,3,opt,name=schema,proto3" json:"schema,omitempty"`
+	// Report breaking changes against the stored configuration as warnings
+	// instead of errors
+	AllowBreakingChanges bool `protobuf:"varint,4,opt,name=allow_breaking_changes,json=allowBreakingChanges,proto3" json:"allow_breaking_changes,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
 }
 
 func (x *ValidateNamespaceRequest) Reset() {
@@ -563,6 +683,20 @@ func (x *ValidateNamespaceRequest) GetConfig() *NamespaceConfig {
 	return nil
 }
 
+func (x *ValidateNamespaceRequest) GetSchema() string {
+	if x != nil {
+		return x.Schema
+	}
+	return ""
+}
+
+func (x *ValidateNamespaceRequest) GetAllowBreakingChanges() bool {
+	if x != nil {
+		return x.AllowBreakingChanges
+	}
+	return false
+}
+
 // ValidateNamespaceResponse contains validation results
 type ValidateNamespaceResponse struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
@@ -635,7 +769,14 @@ type ValidationError struct {
 	// The error message
 	Message string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
 	// The error code
-	Code          string `protobuf:"bytes,3,opt,name=code,proto3" json:"code,omitempty"`
+	Code string `protobuf:"bytes,3,opt,name=code,proto3" json:"code,omitempty"`
+	// JSONPath of the offending node within the rewrite rules named by
+	// field, such as $.union.child[1]
+	Path string `protobuf:"bytes,4,opt,name=path,proto3" json:"path,omitempty"`
+	// Line and column in the schema text, when the configuration was
+	// written in the schema language
+	Line          int32 `protobuf:"varint,5,opt,name=line,proto3" json:"line,omitempty"`
+	Column        int32 `protobuf:"varint,6,opt,name=column,proto3" json:"column,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -691,6 +832,27 @@ func (x *ValidationError) GetCode() string {
 	return ""
 }
 
+func (x *ValidationError) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
+
+func (x *ValidationError) GetLine() int32 {
+	if x != nil {
+		return x.Line
+	}
+	return 0
+}
+
+func (x *ValidationError) GetColumn() int32 {
+	if x != nil {
+		return x.Column
+	}
+	return 0
+}
+
 // ValidationWarning represents a configuration validation warning
 type ValidationWarning struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
@@ -699,7 +861,14 @@ type ValidationWarning struct {
 	// The warning message
 	Message string `protobuf:"bytes,2,opt,name=message,proto3" json:"message,omitempty"`
 	// The warning code
-	Code          string `protobuf:"bytes,3,opt,name=code,proto3" json:"code,omitempty"`
+	Code string `protobuf:"bytes,3,opt,name=code,proto3" json:"code,omitempty"`
+	// JSONPath of the offending node within the rewrite rules named by
+	// field, such as $.union.child[1]
+	Path string `protobuf:"bytes,4,opt,name=path,proto3" json:"path,omitempty"`
+	// Line and column in the schema text, when the configuration was
+	// written in the schema language
+	Line          int32 `protobuf:"varint,5,opt,name=line,proto3" json:"line,omitempty"`
+	Column        int32 `protobuf:"varint,6,opt,name=column,proto3" json:"column,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -755,64 +924,796 @@ func (x *ValidationWarning) GetCode() string {
 	return ""
 }
 
-var File_configuration_proto protoreflect.FileDescriptor
+func (x *ValidationWarning) GetPath() string {
+	if x != nil {
+		return x.Path
+	}
+	return ""
+}
 
-const file_configuration_proto_rawDesc = "" +
-	"\n" +
-	"\x13configuration.proto\x12\bgoacl.v1\x1a\x1cgoogle/api/annotations.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\vtypes.proto\"\x9a\x01\n" +
-	"\x15WriteNamespaceRequest\x121\n" +
-	"\x06config\x18\x01 \x01(\v2\x19.goacl.v1.NamespaceConfigR\x06config\x12+\n" +
-	"\x11consistency_token\x18\x02 \x01(\tR\x10consistencyToken\x12!\n" +
-	"\fallow_update\x18\x03 \x01(\bR\vallowUpdate\"\xb3\x01\n" +
-	"\x16WriteNamespaceResponse\x12+\n" +
-	"\x11consistency_token\x18\x01 \x01(\tR\x10consistencyToken\x129\n" +
-	"\n" +
-	"written_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\twrittenAt\x121\n" +
-	"\x06config\x18\x03 \x01(\v2\x19.goacl.v1.NamespaceConfigR\x06config\"a\n" +
-	"\x14ReadNamespaceRequest\x12\x1c\n" +
-	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x12+\n" +
-	"\x11consistency_token\x18\x02 \x01(\tR\x10consistencyToken\"w\n" +
-	"\x15ReadNamespaceResponse\x121\n" +
-	"\x06config\x18\x01 \x01(\v2\x19.goacl.v1.NamespaceConfigR\x06config\x12+\n" +
-	"\x11consistency_token\x18\x02 \x01(\tR\x10consistencyToken\"\x80\x01\n" +
-	"\x15ListNamespacesRequest\x12+\n" +
-	"\x11consistency_token\x18\x01 \x01(\tR\x10consistencyToken\x12\x1d\n" +
-	"\n" +
-	"page_token\x18\x02 \x01(\tR\tpageToken\x12\x1b\n" +
-	"\tpage_size\x18\x03 \x01(\x05R\bpageSize\"\xa2\x01\n" +
-	"\x16ListNamespacesResponse\x123\n" +
-	"\aconfigs\x18\x01 \x03(\v2\x19.goacl.v1.NamespaceConfigR\aconfigs\x12&\n" +
-	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\x12+\n" +
-	"\x11consistency_token\x18\x03 \x01(\tR\x10consistencyToken\"y\n" +
-	"\x16DeleteNamespaceRequest\x12\x1c\n" +
-	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x12+\n" +
-	"\x11consistency_token\x18\x02 \x01(\tR\x10consistencyToken\x12\x14\n" +
-	"\x05force\x18\x03 \x01(\bR\x05force\"\x81\x01\n" +
-	"\x17DeleteNamespaceResponse\x12+\n" +
-	"\x11consistency_token\x18\x01 \x01(\tR\x10consistencyToken\x129\n" +
-	"\n" +
-	"deleted_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\tdeletedAt\"k\n" +
-	"\x18ValidateNamespaceRequest\x12\x1c\n" +
-	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x121\n" +
-	"\x06config\x18\x02 \x01(\v2\x19.goacl.v1.NamespaceConfigR\x06config\"\x9d\x01\n" +
-	"\x19ValidateNamespaceResponse\x12\x14\n" +
-	"\x05valid\x18\x01 \x01(\bR\x05valid\x121\n" +
-	"\x06errors\x18\x02 \x03(\v2\x19.goacl.v1.ValidationErrorR\x06errors\x127\n" +
-	"\bwarnings\x18\x03 \x03(\v2\x1b.goacl.v1.ValidationWarningR\bwarnings\"U\n" +
-	"\x0fValidationError\x12\x14\n" +
-	"\x05field\x18\x01 \x01(\tR\x05field\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage\x12\x12\n" +
-	"\x04code\x18\x03 \x01(\tR\x04code\"W\n" +
-	"\x11ValidationWarning\x12\x14\n" +
-	"\x05field\x18\x01 \x01(\tR\x05field\x12\x18\n" +
-	"\amessage\x18\x02 \x01(\tR\amessage\x12\x12\n" +
-	"\x04code\x18\x03 \x01(\tR\x04code2\xf4\x04\n" +
+func (x *ValidationWarning) GetLine() int32 {
+	if x != nil {
+		return x.Line
+	}
+	return 0
+}
+
+func (x *ValidationWarning) GetColumn() int32 {
+	if x != nil {
+		return x.Column
+	}
+	return 0
+}
+
+// NamespaceRevision is an immutable record of one write of a namespace
+// configuration
+type NamespaceRevision struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The namespace name
+	Namespace string `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	// The version of the configuration the write produced
+	Version int64 `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	// Who made the change, as given by the write
+	Author string `protobuf:"bytes,3,opt,name=author,proto3" json:"author,omitempty"`
+	// When the revision was written
+	CreatedAt *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	// The namespace configuration as written
+	Config        *NamespaceConfig `protobuf:"bytes,5,opt,name=config,proto3" json:"config,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *NamespaceRevision) Reset() {
+	*x = NamespaceRevision{}
+	mi := &file_configuration_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *NamespaceRevision) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*NamespaceRevision) ProtoMessage() {}
+
+func (x *NamespaceRevision) ProtoReflect() protoreflect.Message {
+	mi := &file_configuration_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use NamespaceRevision.ProtoReflect.Descriptor instead.
+func (*NamespaceRevision) Descriptor() ([]byte, []int) {
+	return file_configuration_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *NamespaceRevision) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *NamespaceRevision) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *NamespaceRevision) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *NamespaceRevision) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *NamespaceRevision) GetConfig() *NamespaceConfig {
+	if x != nil {
+		return x.Config
+	}
+	return nil
+}
+
+// ListNamespaceRevisionsRequest specifies which revisions to list
+type ListNamespaceRevisionsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The namespace name
+	Namespace string `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	// Pagination token
+	PageToken string `protobuf:"bytes,2,opt,name=page_token,json=pageToken,proto3" json:"page_token,omitempty"`
+	// Maximum number of revisions to return
+	PageSize      int32 `protobuf:"varint,3,opt,name=page_size,json=pageSize,proto3" json:"page_size,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListNamespaceRevisionsRequest) Reset() {
+	*x = ListNamespaceRevisionsRequest{}
+	mi := &file_configuration_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListNamespaceRevisionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListNamespaceRevisionsRequest) ProtoMessage() {}
+
+func (x *ListNamespaceRevisionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_configuration_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListNamespaceRevisionsRequest.ProtoReflect.Descriptor instead.
+func (*ListNamespaceRevisionsRequest) Descriptor() ([]byte, []int) {
+	return file_configuration_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ListNamespaceRevisionsRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *ListNamespaceRevisionsRequest) GetPageToken() string {
+	if x != nil {
+		return x.PageToken
+	}
+	return ""
+}
+
+func (x *ListNamespaceRevisionsRequest) GetPageSize() int32 {
+	if x != nil {
+		return x.PageSize
+	}
+	return 0
+}
+
+// ListNamespaceRevisionsResponse contains the revisions, newest first
+type ListNamespaceRevisionsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The revisions
+	Revisions []*NamespaceRevision `protobuf:"bytes,1,rep,name=revisions,proto3" json:"revisions,omitempty"`
+	// Token for next page of results
+	NextPageToken string `protobuf:"bytes,2,opt,name=next_page_token,json=nextPageToken,proto3" json:"next_page_token,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListNamespaceRevisionsResponse) Reset() {
+	*x = ListNamespaceRevisionsResponse{}
+	mi := &file_configuration_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListNamespaceRevisionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListNamespaceRevisionsResponse) ProtoMessage() {}
+
+func (x *ListNamespaceRevisionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_configuration_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListNamespaceRevisionsResponse.ProtoReflect.Descriptor instead.
+func (*ListNamespaceRevisionsResponse) Descriptor() ([]byte, []int) {
+	return file_configuration_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ListNamespaceRevisionsResponse) GetRevisions() []*NamespaceRevision {
+	if x != nil {
+		return x.Revisions
+	}
+	return nil
+}
+
+func (x *ListNamespaceRevisionsResponse) GetNextPageToken() string {
+	if x != nil {
+		return x.NextPageToken
+	}
+	return ""
+}
+
+// ReadNamespaceRevisionRequest specifies which revision to read
+type ReadNamespaceRevisionRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The namespace name
+	Namespace string `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	// The version of the revision
+	Version       int64 `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadNamespaceRevisionRequest) Reset() {
+	*x = ReadNamespaceRevisionRequest{}
+	mi := &file_configuration_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadNamespaceRevisionRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadNamespaceRevisionRequest) ProtoMessage() {}
+
+func (x *ReadNamespaceRevisionRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_configuration_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadNamespaceRevisionRequest.ProtoReflect.Descriptor instead.
+func (*ReadNamespaceRevisionRequest) Descriptor() ([]byte, []int) {
+	return file_configuration_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ReadNamespaceRevisionRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *ReadNamespaceRevisionRequest) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+// ReadNamespaceRevisionResponse contains the revision
+type ReadNamespaceRevisionResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The revision
+	Revision      *NamespaceRevision `protobuf:"bytes,1,opt,name=revision,proto3" json:"revision,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ReadNamespaceRevisionResponse) Reset() {
+	*x = ReadNamespaceRevisionResponse{}
+	mi := &file_configuration_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ReadNamespaceRevisionResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ReadNamespaceRevisionResponse) ProtoMessage() {}
+
+func (x *ReadNamespaceRevisionResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_configuration_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ReadNamespaceRevisionResponse.ProtoReflect.Descriptor instead.
+func (*ReadNamespaceRevisionResponse) Descriptor() ([]byte, []int) {
+	return file_configuration_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ReadNamespaceRevisionResponse) GetRevision() *NamespaceRevision {
+	if x != nil {
+		return x.Revision
+	}
+	return nil
+}
+
+// DiffNamespaceRevisionsRequest specifies which revisions to compare
+type DiffNamespaceRevisionsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The namespace name
+	Namespace string `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	// The version of the older revision
+	FromVersion int64 `protobuf:"varint,2,opt,name=from_version,json=fromVersion,proto3" json:"from_version,omitempty"`
+	// The version of the newer revision, or 0 for the current configuration
+	ToVersion     int64 `protobuf:"varint,3,opt,name=to_version,json=toVersion,proto3" json:"to_version,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DiffNamespaceRevisionsRequest) Reset() {
+	*x = DiffNamespaceRevisionsRequest{}
+	mi := &file_configuration_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DiffNamespaceRevisionsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DiffNamespaceRevisionsRequest) ProtoMessage() {}
+
+func (x *DiffNamespaceRevisionsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_configuration_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DiffNamespaceRevisionsRequest.ProtoReflect.Descriptor instead.
+func (*DiffNamespaceRevisionsRequest) Descriptor() ([]byte, []int) {
+	return file_configuration_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *DiffNamespaceRevisionsRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *DiffNamespaceRevisionsRequest) GetFromVersion() int64 {
+	if x != nil {
+		return x.FromVersion
+	}
+	return 0
+}
+
+func (x *DiffNamespaceRevisionsRequest) GetToVersion() int64 {
+	if x != nil {
+		return x.ToVersion
+	}
+	return 0
+}
+
+// DiffNamespaceRevisionsResponse lists the relations that differ
+type DiffNamespaceRevisionsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The version of the older revision
+	FromVersion int64 `protobuf:"varint,1,opt,name=from_version,json=fromVersion,proto3" json:"from_version,omitempty"`
+	// The version of the newer revision
+	ToVersion int64 `protobuf:"varint,2,opt,name=to_version,json=toVersion,proto3" json:"to_version,omitempty"`
+	// One entry per added, removed or modified relation
+	Changes       []*RelationDiff `protobuf:"bytes,3,rep,name=changes,proto3" json:"changes,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DiffNamespaceRevisionsResponse) Reset() {
+	*x = DiffNamespaceRevisionsResponse{}
+	mi := &file_configuration_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DiffNamespaceRevisionsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DiffNamespaceRevisionsResponse) ProtoMessage() {}
+
+func (x *DiffNamespaceRevisionsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_configuration_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DiffNamespaceRevisionsResponse.ProtoReflect.Descriptor instead.
+func (*DiffNamespaceRevisionsResponse) Descriptor() ([]byte, []int) {
+	return file_configuration_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *DiffNamespaceRevisionsResponse) GetFromVersion() int64 {
+	if x != nil {
+		return x.FromVersion
+	}
+	return 0
+}
+
+func (x *DiffNamespaceRevisionsResponse) GetToVersion() int64 {
+	if x != nil {
+		return x.ToVersion
+	}
+	return 0
+}
+
+func (x *DiffNamespaceRevisionsResponse) GetChanges() []*RelationDiff {
+	if x != nil {
+		return x.Changes
+	}
+	return nil
+}
+
+// RelationDiff describes how a relation differs between two revisions
+type RelationDiff struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The relation name
+	Relation string `protobuf:"bytes,1,opt,name=relation,proto3" json:"relation,omitempty"`
+	// How the relation changed
+	Type RelationDiff_ChangeType `protobuf:"varint,2,opt,name=type,proto3,enum=goacl.v1.RelationDiff_ChangeType" json:"type,omitempty"`
+	// The relation in the older revision, unset when it was added
+	From *RelationConfig `protobuf:"bytes,3,opt,name=from,proto3" json:"from,omitempty"`
+	// The relation in the newer revision, unset when it was removed
+	To            *RelationConfig `protobuf:"bytes,4,opt,name=to,proto3" json:"to,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RelationDiff) Reset() {
+	*x = RelationDiff{}
+	mi := &file_configuration_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RelationDiff) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RelationDiff) ProtoMessage() {}
+
+func (x *RelationDiff) ProtoReflect() protoreflect.Message {
+	mi := &file_configuration_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RelationDiff.ProtoReflect.Descriptor instead.
+func (*RelationDiff) Descriptor() ([]byte, []int) {
+	return file_configuration_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *RelationDiff) GetRelation() string {
+	if x != nil {
+		return x.Relation
+	}
+	return ""
+}
+
+func (x *RelationDiff) GetType() RelationDiff_ChangeType {
+	if x != nil {
+		return x.Type
+	}
+	return RelationDiff_CHANGE_TYPE_UNSPECIFIED
+}
+
+func (x *RelationDiff) GetFrom() *RelationConfig {
+	if x != nil {
+		return x.From
+	}
+	return nil
+}
+
+func (x *RelationDiff) GetTo() *RelationConfig {
+	if x != nil {
+		return x.To
+	}
+	return nil
+}
+
+// RollbackNamespaceRequest specifies the revision to roll back to
+type RollbackNamespaceRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The namespace name
+	Namespace string `protobuf:"bytes,1,opt,name=namespace,proto3" json:"namespace,omitempty"`
+	// The version of the revision whose configuration is restored
+	Version int64 `protobuf:"varint,2,opt,name=version,proto3" json:"version,omitempty"`
+	// Optional version the stored configuration must be at
+	ExpectedVersion int64 `protobuf:"varint,3,opt,name=expected_version,json=expectedVersion,proto3" json:"expected_version,omitempty"`
+	// Who makes the change, recorded in the revision the rollback creates
+	Author string `protobuf:"bytes,4,opt,name=author,proto3" json:"author,omitempty"`
+	// Roll back even when the restored configuration removes relations that
+	// still have tuples or can take access away
+	// Without it such rollbacks fail with FAILED_PRECONDITION
+	AllowBreakingChanges bool `protobuf:"varint,5,opt,name=allow_breaking_changes,json=allowBreakingChanges,proto3" json:"allow_breaking_changes,omitempty"`
+	unknownFields        protoimpl.UnknownFields
+	sizeCache            protoimpl.SizeCache
+}
+
+func (x *RollbackNamespaceRequest) Reset() {
+	*x = RollbackNamespaceRequest{}
+	mi := &file_configuration_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RollbackNamespaceRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RollbackNamespaceRequest) ProtoMessage() {}
+
+func (x *RollbackNamespaceRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_configuration_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RollbackNamespaceRequest.ProtoReflect.Descriptor instead.
+func (*RollbackNamespaceRequest) Descriptor() ([]byte, []int) {
+	return file_configuration_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *RollbackNamespaceRequest) GetNamespace() string {
+	if x != nil {
+		return x.Namespace
+	}
+	return ""
+}
+
+func (x *RollbackNamespaceRequest) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *RollbackNamespaceRequest) GetExpectedVersion() int64 {
+	if x != nil {
+		return x.ExpectedVersion
+	}
+	return 0
+}
+
+func (x *RollbackNamespaceRequest) GetAuthor() string {
+	if x != nil {
+		return x.Author
+	}
+	return ""
+}
+
+func (x *RollbackNamespaceRequest) GetAllowBreakingChanges() bool {
+	if x != nil {
+		return x.AllowBreakingChanges
+	}
+	return false
+}
+
+// RollbackNamespaceResponse confirms the rollback
+type RollbackNamespaceResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Consistency token for subsequent operations
+	ConsistencyToken string `protobuf:"bytes,1,opt,name=consistency_token,json=consistencyToken,proto3" json:"consistency_token,omitempty"`
+	// The restored configuration, at the version of the new revision
+	Config        *NamespaceConfig `protobuf:"bytes,2,opt,name=config,proto3" json:"config,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RollbackNamespaceResponse) Reset() {
+	*x = RollbackNamespaceResponse{}
+	mi := &file_configuration_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RollbackNamespaceResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RollbackNamespaceResponse) ProtoMessage() {}
+
+func (x *RollbackNamespaceResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_configuration_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RollbackNamespaceResponse.ProtoReflect.Descriptor instead.
+func (*RollbackNamespaceResponse) Descriptor() ([]byte, []int) {
+	return file_configuration_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *RollbackNamespaceResponse) GetConsistencyToken() string {
+	if x != nil {
+		return x.ConsistencyToken
+	}
+	return ""
+}
+
+func (x *RollbackNamespaceResponse) GetConfig() *NamespaceConfig {
+	if x != nil {
+		return x.Config
+	}
+	return nil
+}
+
+var File_configuration_proto protoreflect.FileDescriptor
+
+const file_configuration_proto_rawDesc = "" +
+	"\n" +
+	"\x13configuration.proto\x12\bgoacl.v1\x1a\x1cgoogle/api/annotations.proto\x1a\x1fgoogle/protobuf/timestamp.proto\x1a\vtypes.proto\"\xab\x02\n" +
+	"\x15WriteNamespaceRequest\x121\n" +
+	"\x06config\x18\x01 \x01(\v2\x19.goacl.v1.NamespaceConfigR\x06config\x12+\n" +
+	"\x11consistency_token\x18\x02 \x01(\tR\x10consistencyToken\x12!\n" +
+	"\fallow_update\x18\x03 \x01(\bR\vallowUpdate\x12)\n" +
+	"\x10expected_version\x18\x04 \x01(\x03R\x0fexpectedVersion\x12\x16\n" +
+	"\x06schema\x18\x05 \x01(\tR\x06schema\x12\x16\n" +
+	"\x06author\x18\x06 \x01(\tR\x06author\x124\n" +
+	"\x16allow_breaking_changes\x18\a \x01(\bR\x14allowBreakingChanges\"\xb3\x01\n" +
+	"\x16WriteNamespaceResponse\x12+\n" +
+	"\x11consistency_token\x18\x01 \x01(\tR\x10consistencyToken\x129\n" +
+	"\n" +
+	"written_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\twrittenAt\x121\n" +
+	"\x06config\x18\x03 \x01(\v2\x19.goacl.v1.NamespaceConfigR\x06config\"a\n" +
+	"\x14ReadNamespaceRequest\x12\x1c\n" +
+	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x12+\n" +
+	"\x11consistency_token\x18\x02 \x01(\tR\x10consistencyToken\"w\n" +
+	"\x15ReadNamespaceResponse\x121\n" +
+	"\x06config\x18\x01 \x01(\v2\x19.goacl.v1.NamespaceConfigR\x06config\x12+\n" +
+	"\x11consistency_token\x18\x02 \x01(\tR\x10consistencyToken\"\x80\x01\n" +
+	"\x15ListNamespacesRequest\x12+\n" +
+	"\x11consistency_token\x18\x01 \x01(\tR\x10consistencyToken\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x02 \x01(\tR\tpageToken\x12\x1b\n" +
+	"\tpage_size\x18\x03 \x01(\x05R\bpageSize\"\xa2\x01\n" +
+	"\x16ListNamespacesResponse\x123\n" +
+	"\aconfigs\x18\x01 \x03(\v2\x19.goacl.v1.NamespaceConfigR\aconfigs\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\x12+\n" +
+	"\x11consistency_token\x18\x03 \x01(\tR\x10consistencyToken\"\xa4\x01\n" +
+	"\x16DeleteNamespaceRequest\x12\x1c\n" +
+	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x12+\n" +
+	"\x11consistency_token\x18\x02 \x01(\tR\x10consistencyToken\x12\x14\n" +
+	"\x05force\x18\x03 \x01(\bR\x05force\x12)\n" +
+	"\x10expected_version\x18\x04 \x01(\x03R\x0fexpectedVersion\"\xa8\x01\n" +
+	"\x17DeleteNamespaceResponse\x12+\n" +
+	"\x11consistency_token\x18\x01 \x01(\tR\x10consistencyToken\x129\n" +
+	"\n" +
+	"deleted_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\tdeletedAt\x12%\n" +
+	"\x0etuples_deleted\x18\x03 \x01(\x03R\rtuplesDeleted\"\xb9\x01\n" +
+	"\x18ValidateNamespaceRequest\x12\x1c\n" +
+	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x121\n" +
+	"\x06config\x18\x02 \x01(\v2\x19.goacl.v1.NamespaceConfigR\x06config\x12\x16\n" +
+	"\x06schema\x18\x03 \x01(\tR\x06schema\x124\n" +
+	"\x16allow_breaking_changes\x18\x04 \x01(\bR\x14allowBreakingChanges\"\x9d\x01\n" +
+	"\x19ValidateNamespaceResponse\x12\x14\n" +
+	"\x05valid\x18\x01 \x01(\bR\x05valid\x121\n" +
+	"\x06errors\x18\x02 \x03(\v2\x19.goacl.v1.ValidationErrorR\x06errors\x127\n" +
+	"\bwarnings\x18\x03 \x03(\v2\x1b.goacl.v1.ValidationWarningR\bwarnings\"\x95\x01\n" +
+	"\x0fValidationError\x12\x14\n" +
+	"\x05field\x18\x01 \x01(\tR\x05field\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x12\n" +
+	"\x04code\x18\x03 \x01(\tR\x04code\x12\x12\n" +
+	"\x04path\x18\x04 \x01(\tR\x04path\x12\x12\n" +
+	"\x04line\x18\x05 \x01(\x05R\x04line\x12\x16\n" +
+	"\x06column\x18\x06 \x01(\x05R\x06column\"\x97\x01\n" +
+	"\x11ValidationWarning\x12\x14\n" +
+	"\x05field\x18\x01 \x01(\tR\x05field\x12\x18\n" +
+	"\amessage\x18\x02 \x01(\tR\amessage\x12\x12\n" +
+	"\x04code\x18\x03 \x01(\tR\x04code\x12\x12\n" +
+	"\x04path\x18\x04 \x01(\tR\x04path\x12\x12\n" +
+	"\x04line\x18\x05 \x01(\x05R\x04line\x12\x16\n" +
+	"\x06column\x18\x06 \x01(\x05R\x06column\"\xd1\x01\n" +
+	"\x11NamespaceRevision\x12\x1c\n" +
+	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\x12\x16\n" +
+	"\x06author\x18\x03 \x01(\tR\x06author\x129\n" +
+	"\n" +
+	"created_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x121\n" +
+	"\x06config\x18\x05 \x01(\v2\x19.goacl.v1.NamespaceConfigR\x06config\"y\n" +
+	"\x1dListNamespaceRevisionsRequest\x12\x1c\n" +
+	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x12\x1d\n" +
+	"\n" +
+	"page_token\x18\x02 \x01(\tR\tpageToken\x12\x1b\n" +
+	"\tpage_size\x18\x03 \x01(\x05R\bpageSize\"\x83\x01\n" +
+	"\x1eListNamespaceRevisionsResponse\x129\n" +
+	"\trevisions\x18\x01 \x03(\v2\x1b.goacl.v1.NamespaceRevisionR\trevisions\x12&\n" +
+	"\x0fnext_page_token\x18\x02 \x01(\tR\rnextPageToken\"V\n" +
+	"\x1cReadNamespaceRevisionRequest\x12\x1c\n" +
+	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\"X\n" +
+	"\x1dReadNamespaceRevisionResponse\x127\n" +
+	"\brevision\x18\x01 \x01(\v2\x1b.goacl.v1.NamespaceRevisionR\brevision\"\x7f\n" +
+	"\x1dDiffNamespaceRevisionsRequest\x12\x1c\n" +
+	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x12!\n" +
+	"\ffrom_version\x18\x02 \x01(\x03R\vfromVersion\x12\x1d\n" +
+	"\n" +
+	"to_version\x18\x03 \x01(\x03R\ttoVersion\"\x94\x01\n" +
+	"\x1eDiffNamespaceRevisionsResponse\x12!\n" +
+	"\ffrom_version\x18\x01 \x01(\x03R\vfromVersion\x12\x1d\n" +
+	"\n" +
+	"to_version\x18\x02 \x01(\x03R\ttoVersion\x120\n" +
+	"\achanges\x18\x03 \x03(\v2\x16.goacl.v1.RelationDiffR\achanges\"\xae\x02\n" +
+	"\fRelationDiff\x12\x1a\n" +
+	"\brelation\x18\x01 \x01(\tR\brelation\x125\n" +
+	"\x04type\x18\x02 \x01(\x0e2!.goacl.v1.RelationDiff.ChangeTypeR\x04type\x12,\n" +
+	"\x04from\x18\x03 \x01(\v2\x18.goacl.v1.RelationConfigR\x04from\x12(\n" +
+	"\x02to\x18\x04 \x01(\v2\x18.goacl.v1.RelationConfigR\x02to\"s\n" +
+	"\n" +
+	"ChangeType\x12\x1b\n" +
+	"\x17CHANGE_TYPE_UNSPECIFIED\x10\x00\x12\x15\n" +
+	"\x11CHANGE_TYPE_ADDED\x10\x01\x12\x17\n" +
+	"\x13CHANGE_TYPE_REMOVED\x10\x02\x12\x18\n" +
+	"\x14CHANGE_TYPE_MODIFIED\x10\x03\"\xcb\x01\n" +
+	"\x18RollbackNamespaceRequest\x12\x1c\n" +
+	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x12\x18\n" +
+	"\aversion\x18\x02 \x01(\x03R\aversion\x12)\n" +
+	"\x10expected_version\x18\x03 \x01(\x03R\x0fexpectedVersion\x12\x16\n" +
+	"\x06author\x18\x04 \x01(\tR\x06author\x124\n" +
+	"\x16allow_breaking_changes\x18\x05 \x01(\bR\x14allowBreakingChanges\"{\n" +
+	"\x19RollbackNamespaceResponse\x12+\n" +
+	"\x11consistency_token\x18\x01 \x01(\tR\x10consistencyToken\x121\n" +
+	"\x06config\x18\x02 \x01(\v2\x19.goacl.v1.NamespaceConfigR\x06config2\xf2\t\n" +
 	"\x14ConfigurationService\x12n\n" +
 	"\x0eWriteNamespace\x12\x1f.goacl.v1.WriteNamespaceRequest\x1a .goacl.v1.WriteNamespaceResponse\"\x19\x82\xd3\xe4\x93\x02\x13:\x01*\"\x0e/v1/namespaces\x12t\n" +
 	"\rReadNamespace\x12\x1e.goacl.v1.ReadNamespaceRequest\x1a\x1f.goacl.v1.ReadNamespaceResponse\"\"\x82\xd3\xe4\x93\x02\x1c\x12\x1a/v1/namespaces/{namespace}\x12k\n" +
 	"\x0eListNamespaces\x12\x1f.goacl.v1.ListNamespacesRequest\x1a .goacl.v1.ListNamespacesResponse\"\x16\x82\xd3\xe4\x93\x02\x10\x12\x0e/v1/namespaces\x12z\n" +
 	"\x0fDeleteNamespace\x12 .goacl.v1.DeleteNamespaceRequest\x1a!.goacl.v1.DeleteNamespaceResponse\"\"\x82\xd3\xe4\x93\x02\x1c*\x1a/v1/namespaces/{namespace}\x12\x8c\x01\n" +
-	"\x11ValidateNamespace\x12\".goacl.v1.ValidateNamespaceRequest\x1a#.goacl.v1.ValidateNamespaceResponse\".\x82\xd3\xe4\x93\x02(:\x01*\"#/v1/namespaces/{namespace}/validateB\x84\x01\n" +
+	"\x11ValidateNamespace\x12\".goacl.v1.ValidateNamespaceRequest\x1a#.goacl.v1.ValidateNamespaceResponse\".\x82\xd3\xe4\x93\x02(:\x01*\"#/v1/namespaces/{namespace}/validate\x12\x99\x01\n" +
+	"\x16ListNamespaceRevisions\x12'.goacl.v1.ListNamespaceRevisionsRequest\x1a(.goacl.v1.ListNamespaceRevisionsResponse\",\x82\xd3\xe4\x93\x02&\x12$/v1/namespaces/{namespace}/revisions\x12\xa0\x01\n" +
+	"\x15ReadNamespaceRevision\x12&.goacl.v1.ReadNamespaceRevisionRequest\x1a'.goacl.v1.ReadNamespaceRevisionResponse\"6\x82\xd3\xe4\x93\x020\x12./v1/namespaces/{namespace}/revisions/{version}\x12\xad\x01\n" +
+	"\x16DiffNamespaceRevisions\x12'.goacl.v1.DiffNamespaceRevisionsRequest\x1a(.goacl.v1.DiffNamespaceRevisionsResponse\"@\x82\xd3\xe4\x93\x02:\x128/v1/namespaces/{namespace}/revisions/{from_version}/diff\x12\x8c\x01\n" +
+	"\x11RollbackNamespace\x12\".goacl.v1.RollbackNamespaceRequest\x1a#.goacl.v1.RollbackNamespaceResponse\".\x82\xd3\xe4\x93\x02(:\x01*\"#/v1/namespaces/{namespace}/rollbackB\x84\x01\n" +
 	"\fcom.goacl.v1B\x12ConfigurationProtoP\x01Z\x1fgithub.com/DangVTNhan/goacl/api\xa2\x02\x03GXX\xaa\x02\bGoacl.V1\xca\x02\bGoacl\\V1\xe2\x02\x14Goacl\\V1\\GPBMetadata\xea\x02\tGoacl::V1b\x06proto3"
 
 var (
@@ -827,48 +1728,78 @@ func file_configuration_proto_rawDescGZIP() []byte {
 	return file_configuration_proto_rawDescData
 }
 
-var file_configuration_proto_msgTypes = make([]protoimpl.MessageInfo, 12)
+var file_configuration_proto_enumTypes = make([]protoimpl.EnumInfo, 1)
+var file_configuration_proto_msgTypes = make([]protoimpl.MessageInfo, 22)
 var file_configuration_proto_goTypes = []any{
-	(*WriteNamespaceRequest)(nil),     // 0: goacl.v1.WriteNamespaceRequest
-	(*WriteNamespaceResponse)(nil),    // 1: goacl.v1.WriteNamespaceResponse
-	(*ReadNamespaceRequest)(nil),      // 2: goacl.v1.ReadNamespaceRequest
-	(*ReadNamespaceResponse)(nil),     // 3: goacl.v1.ReadNamespaceResponse
-	(*ListNamespacesRequest)(nil),     // 4: goacl.v1.ListNamespacesRequest
-	(*ListNamespacesResponse)(nil),    // 5: goacl.v1.ListNamespacesResponse
-	(*DeleteNamespaceRequest)(nil),    // 6: goacl.v1.DeleteNamespaceRequest
-	(*DeleteNamespaceResponse)(nil),   // 7: goacl.v1.DeleteNamespaceResponse
-	(*ValidateNamespaceRequest)(nil),  // 8: goacl.v1.ValidateNamespaceRequest
-	(*ValidateNamespaceResponse)(nil), // 9: goacl.v1.ValidateNamespaceResponse
-	(*ValidationError)(nil),           // 10: goacl.v1.ValidationError
-	(*ValidationWarning)(nil),         // 11: goacl.v1.ValidationWarning
-	(*NamespaceConfig)(nil),           // 12: goacl.v1.NamespaceConfig
-	(*timestamppb.Timestamp)(nil),     // 13: google.protobuf.Timestamp
+	(RelationDiff_ChangeType)(0),           // 0: goacl.v1.RelationDiff.ChangeType
+	(*WriteNamespaceRequest)(nil),          // 1: goacl.v1.WriteNamespaceRequest
+	(*WriteNamespaceResponse)(nil),         // 2: goacl.v1.WriteNamespaceResponse
+	(*ReadNamespaceRequest)(nil),           // 3: goacl.v1.ReadNamespaceRequest
+	(*ReadNamespaceResponse)(nil),          // 4: goacl.v1.ReadNamespaceResponse
+	(*ListNamespacesRequest)(nil),          // 5: goacl.v1.ListNamespacesRequest
+	(*ListNamespacesResponse)(nil),         // 6: goacl.v1.ListNamespacesResponse
+	(*DeleteNamespaceRequest)(nil),         // 7: goacl.v1.DeleteNamespaceRequest
+	(*DeleteNamespaceResponse)(nil),        // 8: goacl.v1.DeleteNamespaceResponse
+	(*ValidateNamespaceRequest)(nil),       // 9: goacl.v1.ValidateNamespaceRequest
+	(*ValidateNamespaceResponse)(nil),      // 10: goacl.v1.ValidateNamespaceResponse
+	(*ValidationError)(nil),                // 11: goacl.v1.ValidationError
+	(*ValidationWarning)(nil),              // 12: goacl.v1.ValidationWarning
+	(*NamespaceRevision)(nil),              // 13: goacl.v1.NamespaceRevision
+	(*ListNamespaceRevisionsRequest)(nil),  // 14: goacl.v1.ListNamespaceRevisionsRequest
+	(*ListNamespaceRevisionsResponse)(nil), // 15: goacl.v1.ListNamespaceRevisionsResponse
+	(*ReadNamespaceRevisionRequest)(nil),   // 16: goacl.v1.ReadNamespaceRevisionRequest
+	(*ReadNamespaceRevisionResponse)(nil),  // 17: goacl.v1.ReadNamespaceRevisionResponse
+	(*DiffNamespaceRevisionsRequest)(nil),  // 18: goacl.v1.DiffNamespaceRevisionsRequest
+	(*DiffNamespaceRevisionsResponse)(nil), // 19: goacl.v1.DiffNamespaceRevisionsResponse
+	(*RelationDiff)(nil),                   // 20: goacl.v1.RelationDiff
+	(*RollbackNamespaceRequest)(nil),       // 21: goacl.v1.RollbackNamespaceRequest
+	(*RollbackNamespaceResponse)(nil),      // 22: goacl.v1.RollbackNamespaceResponse
+	(*NamespaceConfig)(nil),                // 23: goacl.v1.NamespaceConfig
+	(*timestamppb.Timestamp)(nil),          // 24: google.protobuf.Timestamp
+	(*RelationConfig)(nil),                 // 25: goacl.v1.RelationConfig
 }
 var file_configuration_proto_depIdxs = []int32{
-	12, // 0: goacl.v1.WriteNamespaceRequest.config:type_name -> goacl.v1.NamespaceConfig
-	13, // 1: goacl.v1.WriteNamespaceResponse.written_at:type_name -> google.protobuf.Timestamp
-	12, // 2: goacl.v1.WriteNamespaceResponse.config:type_name -> goacl.v1.NamespaceConfig
-	12, // 3: goacl.v1.ReadNamespaceResponse.config:type_name -> goacl.v1.NamespaceConfig
-	12, // 4: goacl.v1.ListNamespacesResponse.configs:type_name -> goacl.v1.NamespaceConfig
-	13, // 5: goacl.v1.DeleteNamespaceResponse.deleted_at:type_name -> google.protobuf.Timestamp
-	12, // 6: goacl.v1.ValidateNamespaceRequest.config:type_name -> goacl.v1.NamespaceConfig
-	10, // 7: goacl.v1.ValidateNamespaceResponse.errors:type_name -> goacl.v1.ValidationError
-	11, // 8: goacl.v1.ValidateNamespaceResponse.warnings:type_name -> goacl.v1.ValidationWarning
-	0,  // 9: goacl.v1.ConfigurationService.WriteNamespace:input_type -> goacl.v1.WriteNamespaceRequest
-	2,  // 10: goacl.v1.ConfigurationService.ReadNamespace:input_type -> goacl.v1.ReadNamespaceRequest
-	4,  // 11: goacl.v1.ConfigurationService.ListNamespaces:input_type -> goacl.v1.ListNamespacesRequest
-	6,  // 12: goacl.v1.ConfigurationService.DeleteNamespace:input_type -> goacl.v1.DeleteNamespaceRequest
-	8,  // 13: goacl.v1.ConfigurationService.ValidateNamespace:input_type -> goacl.v1.ValidateNamespaceRequest
-	1,  // 14: goacl.v1.ConfigurationService.WriteNamespace:output_type -> goacl.v1.WriteNamespaceResponse
-	3,  // 15: goacl.v1.ConfigurationService.ReadNamespace:output_type -> goacl.v1.ReadNamespaceResponse
-	5,  // 16: goacl.v1.ConfigurationService.ListNamespaces:output_type -> goacl.v1.ListNamespacesResponse
-	7,  // 17: goacl.v1.ConfigurationService.DeleteNamespace:output_type -> goacl.v1.DeleteNamespaceResponse
-	9,  // 18: goacl.v1.ConfigurationService.ValidateNamespace:output_type -> goacl.v1.ValidateNamespaceResponse
-	14, // [14:19] is the sub-list for method output_type
-	9,  // [9:14] is the sub-list for method input_type
-	9,  // [9:9] is the sub-list for extension type_name
-	9,  // [9:9] is the sub-list for extension extendee
-	0,  // [0:9] is the sub-list for field type_name
+	23, // 0: goacl.v1.WriteNamespaceRequest.config:type_name -> goacl.v1.NamespaceConfig
+	24, // 1: goacl.v1.WriteNamespaceResponse.written_at:type_name -> google.protobuf.Timestamp
+	23, // 2: goacl.v1.WriteNamespaceResponse.config:type_name -> goacl.v1.NamespaceConfig
+	23, // 3: goacl.v1.ReadNamespaceResponse.config:type_name -> goacl.v1.NamespaceConfig
+	23, // 4: goacl.v1.ListNamespacesResponse.configs:type_name -> goacl.v1.NamespaceConfig
+	24, // 5: goacl.v1.DeleteNamespaceResponse.deleted_at:type_name -> google.protobuf.Timestamp
+	23, // 6: goacl.v1.ValidateNamespaceRequest.config:type_name -> goacl.v1.NamespaceConfig
+	11, // 7: goacl.v1.ValidateNamespaceResponse.errors:type_name -> goacl.v1.ValidationError
+	12, // 8: goacl.v1.ValidateNamespaceResponse.warnings:type_name -> goacl.v1.ValidationWarning
+	24, // 9: goacl.v1.NamespaceRevision.created_at:type_name -> google.protobuf.Timestamp
+	23, // 10: goacl.v1.NamespaceRevision.config:type_name -> goacl.v1.NamespaceConfig
+	13, // 11: goacl.v1.ListNamespaceRevisionsResponse.revisions:type_name -> goacl.v1.NamespaceRevision
+	13, // 12: goacl.v1.ReadNamespaceRevisionResponse.revision:type_name -> goacl.v1.NamespaceRevision
+	20, // 13: goacl.v1.DiffNamespaceRevisionsResponse.changes:type_name -> goacl.v1.RelationDiff
+	0,  // 14: goacl.v1.RelationDiff.type:type_name -> goacl.v1.RelationDiff.ChangeType
+	25, // 15: goacl.v1.RelationDiff.from:type_name -> goacl.v1.RelationConfig
+	25, // 16: goacl.v1.RelationDiff.to:type_name -> goacl.v1.RelationConfig
+	23, // 17: goacl.v1.RollbackNamespaceResponse.config:type_name -> goacl.v1.NamespaceConfig
+	1,  // 18: goacl.v1.ConfigurationService.WriteNamespace:input_type -> goacl.v1.WriteNamespaceRequest
+	3,  // 19: goacl.v1.ConfigurationService.ReadNamespace:input_type -> goacl.v1.ReadNamespaceRequest
+	5,  // 20: goacl.v1.ConfigurationService.ListNamespaces:input_type -> goacl.v1.ListNamespacesRequest
+	7,  // 21: goacl.v1.ConfigurationService.DeleteNamespace:input_type -> goacl.v1.DeleteNamespaceRequest
+	9,  // 22: goacl.v1.ConfigurationService.ValidateNamespace:input_type -> goacl.v1.ValidateNamespaceRequest
+	14, // 23: goacl.v1.ConfigurationService.ListNamespaceRevisions:input_type -> goacl.v1.ListNamespaceRevisionsRequest
+	16, // 24: goacl.v1.ConfigurationService.ReadNamespaceRevision:input_type -> goacl.v1.ReadNamespaceRevisionRequest
+	18, // 25: goacl.v1.ConfigurationService.DiffNamespaceRevisions:input_type -> goacl.v1.DiffNamespaceRevisionsRequest
+	21, // 26: goacl.v1.ConfigurationService.RollbackNamespace:input_type -> goacl.v1.RollbackNamespaceRequest
+	2,  // 27: goacl.v1.ConfigurationService.WriteNamespace:output_type -> goacl.v1.WriteNamespaceResponse
+	4,  // 28: goacl.v1.ConfigurationService.ReadNamespace:output_type -> goacl.v1.ReadNamespaceResponse
+	6,  // 29: goacl.v1.ConfigurationService.ListNamespaces:output_type -> goacl.v1.ListNamespacesResponse
+	8,  // 30: goacl.v1.ConfigurationService.DeleteNamespace:output_type -> goacl.v1.DeleteNamespaceResponse
+	10, // 31: goacl.v1.ConfigurationService.ValidateNamespace:output_type -> goacl.v1.ValidateNamespaceResponse
+	15, // 32: goacl.v1.ConfigurationService.ListNamespaceRevisions:output_type -> goacl.v1.ListNamespaceRevisionsResponse
+	17, // 33: goacl.v1.ConfigurationService.ReadNamespaceRevision:output_type -> goacl.v1.ReadNamespaceRevisionResponse
+	19, // 34: goacl.v1.ConfigurationService.DiffNamespaceRevisions:output_type -> goacl.v1.DiffNamespaceRevisionsResponse
+	22, // 35: goacl.v1.ConfigurationService.RollbackNamespace:output_type -> goacl.v1.RollbackNamespaceResponse
+	27, // [27:36] is the sub-list for method output_type
+	18, // [18:27] is the sub-list for method input_type
+	18, // [18:18] is the sub-list for extension type_name
+	18, // [18:18] is the sub-list for extension extendee
+	0,  // [0:18] is the sub-list for field type_name
 }
 
 func init() { file_configuration_proto_init() }
@@ -882,13 +1813,14 @@ func file_configuration_proto_init() {
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_configuration_proto_rawDesc), len(file_configuration_proto_rawDesc)),
-			NumEnums:      0,
-			NumMessages:   12,
+			NumEnums:      1,
+			NumMessages:   22,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
 		GoTypes:           file_configuration_proto_goTypes,
 		DependencyIndexes: file_configuration_proto_depIdxs,
+		EnumInfos:         file_configuration_proto_enumTypes,
 		MessageInfos:      file_configuration_proto_msgTypes,
 	}.Build()
 	File_configuration_proto = out.File
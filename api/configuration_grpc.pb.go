@@ -19,11 +19,15 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	ConfigurationService_WriteNamespace_FullMethodName    = "/goacl.v1.ConfigurationService/WriteNamespace"
-	ConfigurationService_ReadNamespace_FullMethodName     = "/goacl.v1.ConfigurationService/ReadNamespace"
-	ConfigurationService_ListNamespaces_FullMethodName    = "/goacl.v1.ConfigurationService/ListNamespaces"
-	ConfigurationService_DeleteNamespace_FullMethodName   = "/goacl.v1.ConfigurationService/DeleteNamespace"
-	ConfigurationService_ValidateNamespace_FullMethodName = "/goacl.v1.ConfigurationService/ValidateNamespace"
+	ConfigurationService_WriteNamespace_FullMethodName         = "/goacl.v1.ConfigurationService/WriteNamespace"
+	ConfigurationService_ReadNamespace_FullMethodName          = "/goacl.v1.ConfigurationService/ReadNamespace"
+	ConfigurationService_ListNamespaces_FullMethodName         = "/goacl.v1.ConfigurationService/ListNamespaces"
+	ConfigurationService_DeleteNamespace_FullMethodName        = "/goacl.v1.ConfigurationService/DeleteNamespace"
+	ConfigurationService_ValidateNamespace_FullMethodName      = "/goacl.v1.ConfigurationService/ValidateNamespace"
+	ConfigurationService_ListNamespaceRevisions_FullMethodName = "/goacl.v1.ConfigurationService/ListNamespaceRevisions"
+	ConfigurationService_ReadNamespaceRevision_FullMethodName  = "/goacl.v1.ConfigurationService/ReadNamespaceRevision"
+	ConfigurationService_DiffNamespaceRevisions_FullMethodName = "/goacl.v1.ConfigurationService/DiffNamespaceRevisions"
+	ConfigurationService_RollbackNamespace_FullMethodName      = "/goacl.v1.ConfigurationService/RollbackNamespace"
 )
 
 // ConfigurationServiceClient is the client API for ConfigurationService service.
@@ -42,6 +46,14 @@ type ConfigurationServiceClient interface {
 	DeleteNamespace(ctx context.Context, in *DeleteNamespaceRequest, opts ...grpc.CallOption) (*DeleteNamespaceResponse, error)
 	// Validate a namespace configuration
 	ValidateNamespace(ctx context.Context, in *ValidateNamespaceRequest, opts ...grpc.CallOption) (*ValidateNamespaceResponse, error)
+	// List the revisions of a namespace configuration, newest first
+	ListNamespaceRevisions(ctx context.Context, in *ListNamespaceRevisionsRequest, opts ...grpc.CallOption) (*ListNamespaceRevisionsResponse, error)
+	// Get a namespace configuration as it was at a revision
+	ReadNamespaceRevision(ctx context.Context, in *ReadNamespaceRevisionRequest, opts ...grpc.CallOption) (*ReadNamespaceRevisionResponse, error)
+	// Compare two revisions of a namespace configuration relation by relation
+	DiffNamespaceRevisions(ctx context.Context, in *DiffNamespaceRevisionsRequest, opts ...grpc.CallOption) (*DiffNamespaceRevisionsResponse, error)
+	// Restore the configuration of an earlier revision as a new revision
+	RollbackNamespace(ctx context.Context, in *RollbackNamespaceRequest, opts ...grpc.CallOption) (*RollbackNamespaceResponse, error)
 }
 
 type configurationServiceClient struct {
@@ -102,6 +114,46 @@ func (c *configurationServiceClient) ValidateNamespace(ctx context.Context, in *
 	return out, nil
 }
 
+func (c *configurationServiceClient) ListNamespaceRevisions(ctx context.Context, in *ListNamespaceRevisionsRequest, opts ...grpc.CallOption) (*ListNamespaceRevisionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListNamespaceRevisionsResponse)
+	err := c.cc.Invoke(ctx, ConfigurationService_ListNamespaceRevisions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *configurationServiceClient) ReadNamespaceRevision(ctx context.Context, in *ReadNamespaceRevisionRequest, opts ...grpc.CallOption) (*ReadNamespaceRevisionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ReadNamespaceRevisionResponse)
+	err := c.cc.Invoke(ctx, ConfigurationService_ReadNamespaceRevision_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *configurationServiceClient) DiffNamespaceRevisions(ctx context.Context, in *DiffNamespaceRevisionsRequest, opts ...grpc.CallOption) (*DiffNamespaceRevisionsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DiffNamespaceRevisionsResponse)
+	err := c.cc.Invoke(ctx, ConfigurationService_DiffNamespaceRevisions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *configurationServiceClient) RollbackNamespace(ctx context.Context, in *RollbackNamespaceRequest, opts ...grpc.CallOption) (*RollbackNamespaceResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RollbackNamespaceResponse)
+	err := c.cc.Invoke(ctx, ConfigurationService_RollbackNamespace_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ConfigurationServiceServer is the server API for ConfigurationService service.
 // All implementations must embed UnimplementedConfigurationServiceServer
 // for forward compatibility.
@@ -118,6 +170,14 @@ type ConfigurationServiceServer interface {
 	DeleteNamespace(context.Context, *DeleteNamespaceRequest) (*DeleteNamespaceResponse, error)
 	// Validate a namespace configuration
 	ValidateNamespace(context.Context, *ValidateNamespaceRequest) (*ValidateNamespaceResponse, error)
+	// List the revisions of a namespace configuration, newest first
+	ListNamespaceRevisions(context.Context, *ListNamespaceRevisionsRequest) (*ListNamespaceRevisionsResponse, error)
+	// Get a namespace configuration as it was at a revision
+	ReadNamespaceRevision(context.Context, *ReadNamespaceRevisionRequest) (*ReadNamespaceRevisionResponse, error)
+	// Compare two revisions of a namespace configuration relation by relation
+	DiffNamespaceRevisions(context.Context, *DiffNamespaceRevisionsRequest) (*DiffNamespaceRevisionsResponse, error)
+	// Restore the configuration of an earlier revision as a new revision
+	RollbackNamespace(context.Context, *RollbackNamespaceRequest) (*RollbackNamespaceResponse, error)
 	mustEmbedUnimplementedConfigurationServiceServer()
 }
 
@@ -143,6 +203,18 @@ func (UnimplementedConfigurationServiceServer) DeleteNamespace(context.Context,
 func (UnimplementedConfigurationServiceServer) ValidateNamespace(context.Context, *ValidateNamespaceRequest) (*ValidateNamespaceResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method ValidateNamespace not implemented")
 }
+func (UnimplementedConfigurationServiceServer) ListNamespaceRevisions(context.Context, *ListNamespaceRevisionsRequest) (*ListNamespaceRevisionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListNamespaceRevisions not implemented")
+}
+func (UnimplementedConfigurationServiceServer) ReadNamespaceRevision(context.Context, *ReadNamespaceRevisionRequest) (*ReadNamespaceRevisionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ReadNamespaceRevision not implemented")
+}
+func (UnimplementedConfigurationServiceServer) DiffNamespaceRevisions(context.Context, *DiffNamespaceRevisionsRequest) (*DiffNamespaceRevisionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DiffNamespaceRevisions not implemented")
+}
+func (UnimplementedConfigurationServiceServer) RollbackNamespace(context.Context, *RollbackNamespaceRequest) (*RollbackNamespaceResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RollbackNamespace not implemented")
+}
 func (UnimplementedConfigurationServiceServer) mustEmbedUnimplementedConfigurationServiceServer() {}
 func (UnimplementedConfigurationServiceServer) testEmbeddedByValue()                              {}
 
@@ -254,6 +326,78 @@ func _ConfigurationService_ValidateNamespace_Handler(srv interface{}, ctx contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ConfigurationService_ListNamespaceRevisions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListNamespaceRevisionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConfigurationServiceServer).ListNamespaceRevisions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConfigurationService_ListNamespaceRevisions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConfigurationServiceServer).ListNamespaceRevisions(ctx, req.(*ListNamespaceRevisionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConfigurationService_ReadNamespaceRevision_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ReadNamespaceRevisionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConfigurationServiceServer).ReadNamespaceRevision(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConfigurationService_ReadNamespaceRevision_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConfigurationServiceServer).ReadNamespaceRevision(ctx, req.(*ReadNamespaceRevisionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConfigurationService_DiffNamespaceRevisions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DiffNamespaceRevisionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConfigurationServiceServer).DiffNamespaceRevisions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConfigurationService_DiffNamespaceRevisions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConfigurationServiceServer).DiffNamespaceRevisions(ctx, req.(*DiffNamespaceRevisionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ConfigurationService_RollbackNamespace_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RollbackNamespaceRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConfigurationServiceServer).RollbackNamespace(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConfigurationService_RollbackNamespace_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConfigurationServiceServer).RollbackNamespace(ctx, req.(*RollbackNamespaceRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ConfigurationService_ServiceDesc is the grpc.ServiceDesc for ConfigurationService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -281,6 +425,22 @@ var ConfigurationService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "ValidateNamespace",
 			Handler:    _ConfigurationService_ValidateNamespace_Handler,
 		},
+		{
+			MethodName: "ListNamespaceRevisions",
+			Handler:    _ConfigurationService_ListNamespaceRevisions_Handler,
+		},
+		{
+			MethodName: "ReadNamespaceRevision",
+			Handler:    _ConfigurationService_ReadNamespaceRevision_Handler,
+		},
+		{
+			MethodName: "DiffNamespaceRevisions",
+			Handler:    _ConfigurationService_DiffNamespaceRevisions_Handler,
+		},
+		{
+			MethodName: "RollbackNamespace",
+			Handler:    _ConfigurationService_RollbackNamespace_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "configuration.proto",
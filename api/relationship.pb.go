@@ -73,6 +73,59 @@ func (PreconditionType) EnumDescriptor() ([]byte, []int) {
 	return file_relationship_proto_rawDescGZIP(), []int{0}
 }
 
+// ImportMode specifies how an import handles failing rows
+type ImportMode int32
+
+const (
+	// Treated as IMPORT_MODE_ABORT_ON_ERROR
+	ImportMode_IMPORT_MODE_UNSPECIFIED ImportMode = 0
+	// Stop at the first failing row, keeping the rows imported before it
+	ImportMode_IMPORT_MODE_ABORT_ON_ERROR ImportMode = 1
+	// Skip failing rows and import everything else
+	ImportMode_IMPORT_MODE_BEST_EFFORT ImportMode = 2
+)
+
+// Enum value maps for ImportMode.
+var (
+	ImportMode_name = map[int32]string{
+		0: "IMPORT_MODE_UNSPECIFIED",
+		1: "IMPORT_MODE_ABORT_ON_ERROR",
+		2: "IMPORT_MODE_BEST_EFFORT",
+	}
+	ImportMode_value = map[string]int32{
+		"IMPORT_MODE_UNSPECIFIED":    0,
+		"IMPORT_MODE_ABORT_ON_ERROR": 1,
+		"IMPORT_MODE_BEST_EFFORT":    2,
+	}
+)
+
+func (x ImportMode) Enum() *ImportMode {
+	p := new(ImportMode)
+	*p = x
+	return p
+}
+
+func (x ImportMode) String() string {
+	return protoimpl.X.EnumStringOf(x.Descriptor(), protoreflect.EnumNumber(x))
+}
+
+func (ImportMode) Descriptor() protoreflect.EnumDescriptor {
+	return file_relationship_proto_enumTypes[1].Descriptor()
+}
+
+func (ImportMode) Type() protoreflect.EnumType {
+	return &file_relationship_proto_enumTypes[1]
+}
+
+func (x ImportMode) Number() protoreflect.EnumNumber {
+	return protoreflect.EnumNumber(x)
+}
+
+// Deprecated: Use ImportMode.Descriptor instead.
+func (ImportMode) EnumDescriptor() ([]byte, []int) {
+	return file_relationship_proto_rawDescGZIP(), []int{1}
+}
+
 // ChangeType specifies the type of change in a watch response
 type ChangeType int32
 
@@ -110,11 +163,11 @@ func (x ChangeType) String() string {
 }
 
 func (ChangeType) Descriptor() protoreflect.EnumDescriptor {
-	return file_relationship_proto_enumTypes[1].Descriptor()
+	return file_relationship_proto_enumTypes[2].Descriptor()
 }
 
 func (ChangeType) Type() protoreflect.EnumType {
-	return &file_relationship_proto_enumTypes[1]
+	return &file_relationship_proto_enumTypes[2]
 }
 
 func (x ChangeType) Number() protoreflect.EnumNumber {
@@ -123,7 +176,7 @@ func (x ChangeType) Number() protoreflect.EnumNumber {
 
 // Deprecated: Use ChangeType.Descriptor instead.
 func (ChangeType) EnumDescriptor() ([]byte, []int) {
-	return file_relationship_proto_rawDescGZIP(), []int{1}
+	return file_relationship_proto_rawDescGZIP(), []int{2}
 }
 
 // WriteRelationRequest contains tuples to write
@@ -924,6 +977,369 @@ func (x *WriteOperationResult) GetTuplesAffected() int32 {
 	return 0
 }
 
+// ImportRelationsRequest carries a chunk of the tuples to import
+type ImportRelationsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// The relation tuples to import
+	Tuples []*RelationTuple `protobuf:"bytes,1,rep,name=tuples,proto3" json:"tuples,omitempty"`
+	// How failing rows are handled; read from the first message only
+	Mode          ImportMode `protobuf:"varint,2,opt,name=mode,proto3,enum=goacl.v1.ImportMode" json:"mode,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImportRelationsRequest) Reset() {
+	*x = ImportRelationsRequest{}
+	mi := &file_relationship_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportRelationsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportRelationsRequest) ProtoMessage() {}
+
+func (x *ImportRelationsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_relationship_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportRelationsRequest.ProtoReflect.Descriptor instead.
+func (*ImportRelationsRequest) Descriptor() ([]byte, []int) {
+	return file_relationship_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ImportRelationsRequest) GetTuples() []*RelationTuple {
+	if x != nil {
+		return x.Tuples
+	}
+	return nil
+}
+
+func (x *ImportRelationsRequest) GetMode() ImportMode {
+	if x != nil {
+		return x.Mode
+	}
+	return ImportMode_IMPORT_MODE_UNSPECIFIED
+}
+
+// ImportRelationsResponse reports the progress of an import, or its outcome
+// once completed
+type ImportRelationsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Number of tuples received
+	TuplesReceived int64 `protobuf:"varint,1,opt,name=tuples_received,json=tuplesReceived,proto3" json:"tuples_received,omitempty"`
+	// Number of tuples stored
+	TuplesImported int64 `protobuf:"varint,2,opt,name=tuples_imported,json=tuplesImported,proto3" json:"tuples_imported,omitempty"`
+	// Number of tuples skipped because they already existed
+	TuplesSkipped int64 `protobuf:"varint,3,opt,name=tuples_skipped,json=tuplesSkipped,proto3" json:"tuples_skipped,omitempty"`
+	// Number of tuples that failed
+	TuplesFailed int64 `protobuf:"varint,4,opt,name=tuples_failed,json=tuplesFailed,proto3" json:"tuples_failed,omitempty"`
+	// Errors of the failing rows, capped at the first 1000; only sent once the
+	// import completed
+	Errors []*ImportError `protobuf:"bytes,5,rep,name=errors,proto3" json:"errors,omitempty"`
+	// Whether the import stopped at the first failing row
+	Aborted bool `protobuf:"varint,6,opt,name=aborted,proto3" json:"aborted,omitempty"`
+	// Consistency token covering every tuple imported so far
+	ConsistencyToken string `protobuf:"bytes,7,opt,name=consistency_token,json=consistencyToken,proto3" json:"consistency_token,omitempty"`
+	// When the import completed; unset while the import is in progress
+	CompletedAt   *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=completed_at,json=completedAt,proto3" json:"completed_at,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImportRelationsResponse) Reset() {
+	*x = ImportRelationsResponse{}
+	mi := &file_relationship_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportRelationsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportRelationsResponse) ProtoMessage() {}
+
+func (x *ImportRelationsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_relationship_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportRelationsResponse.ProtoReflect.Descriptor instead.
+func (*ImportRelationsResponse) Descriptor() ([]byte, []int) {
+	return file_relationship_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ImportRelationsResponse) GetTuplesReceived() int64 {
+	if x != nil {
+		return x.TuplesReceived
+	}
+	return 0
+}
+
+func (x *ImportRelationsResponse) GetTuplesImported() int64 {
+	if x != nil {
+		return x.TuplesImported
+	}
+	return 0
+}
+
+func (x *ImportRelationsResponse) GetTuplesSkipped() int64 {
+	if x != nil {
+		return x.TuplesSkipped
+	}
+	return 0
+}
+
+func (x *ImportRelationsResponse) GetTuplesFailed() int64 {
+	if x != nil {
+		return x.TuplesFailed
+	}
+	return 0
+}
+
+func (x *ImportRelationsResponse) GetErrors() []*ImportError {
+	if x != nil {
+		return x.Errors
+	}
+	return nil
+}
+
+func (x *ImportRelationsResponse) GetAborted() bool {
+	if x != nil {
+		return x.Aborted
+	}
+	return false
+}
+
+func (x *ImportRelationsResponse) GetConsistencyToken() string {
+	if x != nil {
+		return x.ConsistencyToken
+	}
+	return ""
+}
+
+func (x *ImportRelationsResponse) GetCompletedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CompletedAt
+	}
+	return nil
+}
+
+// ImportError describes a row that could not be imported
+type ImportError struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Zero-based position of the row in the import stream
+	Row int64 `protobuf:"varint,1,opt,name=row,proto3" json:"row,omitempty"`
+	// The tuple as received
+	Tuple *RelationTuple `protobuf:"bytes,2,opt,name=tuple,proto3" json:"tuple,omitempty"`
+	// Why the row failed
+	Error         string `protobuf:"bytes,3,opt,name=error,proto3" json:"error,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ImportError) Reset() {
+	*x = ImportError{}
+	mi := &file_relationship_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ImportError) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ImportError) ProtoMessage() {}
+
+func (x *ImportError) ProtoReflect() protoreflect.Message {
+	mi := &file_relationship_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ImportError.ProtoReflect.Descriptor instead.
+func (*ImportError) Descriptor() ([]byte, []int) {
+	return file_relationship_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *ImportError) GetRow() int64 {
+	if x != nil {
+		return x.Row
+	}
+	return 0
+}
+
+func (x *ImportError) GetTuple() *RelationTuple {
+	if x != nil {
+		return x.Tuple
+	}
+	return nil
+}
+
+func (x *ImportError) GetError() string {
+	if x != nil {
+		return x.Error
+	}
+	return ""
+}
+
+// ExportRelationsRequest specifies the snapshot to export
+type ExportRelationsRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Optional consistency token of the snapshot to export; the latest data
+	// is exported when empty
+	ConsistencyToken string `protobuf:"bytes,1,opt,name=consistency_token,json=consistencyToken,proto3" json:"consistency_token,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *ExportRelationsRequest) Reset() {
+	*x = ExportRelationsRequest{}
+	mi := &file_relationship_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportRelationsRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportRelationsRequest) ProtoMessage() {}
+
+func (x *ExportRelationsRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_relationship_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportRelationsRequest.ProtoReflect.Descriptor instead.
+func (*ExportRelationsRequest) Descriptor() ([]byte, []int) {
+	return file_relationship_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *ExportRelationsRequest) GetConsistencyToken() string {
+	if x != nil {
+		return x.ConsistencyToken
+	}
+	return ""
+}
+
+// ExportRelationsResponse carries one exported record
+// Namespace configurations are sent before relation tuples
+type ExportRelationsResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Record:
+	//
+	//	*ExportRelationsResponse_Namespace
+	//	*ExportRelationsResponse_Tuple
+	Record        isExportRelationsResponse_Record `protobuf_oneof:"record"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ExportRelationsResponse) Reset() {
+	*x = ExportRelationsResponse{}
+	mi := &file_relationship_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ExportRelationsResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ExportRelationsResponse) ProtoMessage() {}
+
+func (x *ExportRelationsResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_relationship_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ExportRelationsResponse.ProtoReflect.Descriptor instead.
+func (*ExportRelationsResponse) Descriptor() ([]byte, []int) {
+	return file_relationship_proto_rawDescGZIP(), []int{16}
+}
+
+func (x *ExportRelationsResponse) GetRecord() isExportRelationsResponse_Record {
+	if x != nil {
+		return x.Record
+	}
+	return nil
+}
+
+func (x *ExportRelationsResponse) GetNamespace() *NamespaceConfig {
+	if x != nil {
+		if x, ok := x.Record.(*ExportRelationsResponse_Namespace); ok {
+			return x.Namespace
+		}
+	}
+	return nil
+}
+
+func (x *ExportRelationsResponse) GetTuple() *RelationTuple {
+	if x != nil {
+		if x, ok := x.Record.(*ExportRelationsResponse_Tuple); ok {
+			return x.Tuple
+		}
+	}
+	return nil
+}
+
+type isExportRelationsResponse_Record interface {
+	isExportRelationsResponse_Record()
+}
+
+type ExportRelationsResponse_Namespace struct {
+	// An exported namespace configuration
+	Namespace *NamespaceConfig `protobuf:"bytes,1,opt,name=namespace,proto3,oneof"`
+}
+
+type ExportRelationsResponse_Tuple struct {
+	// An exported relation tuple
+	Tuple *RelationTuple `protobuf:"bytes,2,opt,name=tuple,proto3,oneof"`
+}
+
+func (*ExportRelationsResponse_Namespace) isExportRelationsResponse_Record() {}
+
+func (*ExportRelationsResponse_Tuple) isExportRelationsResponse_Record() {}
+
 // RelationFilter specifies criteria for filtering relation tuples
 type RelationFilter struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
@@ -943,7 +1359,7 @@ type RelationFilter struct {
 
 func (x *RelationFilter) Reset() {
 	*x = RelationFilter{}
-	mi := &file_relationship_proto_msgTypes[12]
+	mi := &file_relationship_proto_msgTypes[17]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -955,7 +1371,7 @@ func (x *RelationFilter) String() string {
 func (*RelationFilter) ProtoMessage() {}
 
 func (x *RelationFilter) ProtoReflect() protoreflect.Message {
-	mi := &file_relationship_proto_msgTypes[12]
+	mi := &file_relationship_proto_msgTypes[17]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -968,7 +1384,7 @@ func (x *RelationFilter) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use RelationFilter.ProtoReflect.Descriptor instead.
 func (*RelationFilter) Descriptor() ([]byte, []int) {
-	return file_relationship_proto_rawDescGZIP(), []int{12}
+	return file_relationship_proto_rawDescGZIP(), []int{17}
 }
 
 func (x *RelationFilter) GetNamespace() string {
@@ -1019,7 +1435,7 @@ type Precondition struct {
 
 func (x *Precondition) Reset() {
 	*x = Precondition{}
-	mi := &file_relationship_proto_msgTypes[13]
+	mi := &file_relationship_proto_msgTypes[18]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -1031,7 +1447,7 @@ func (x *Precondition) String() string {
 func (*Precondition) ProtoMessage() {}
 
 func (x *Precondition) ProtoReflect() protoreflect.Message {
-	mi := &file_relationship_proto_msgTypes[13]
+	mi := &file_relationship_proto_msgTypes[18]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -1044,7 +1460,7 @@ func (x *Precondition) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Precondition.ProtoReflect.Descriptor instead.
 func (*Precondition) Descriptor() ([]byte, []int) {
-	return file_relationship_proto_rawDescGZIP(), []int{13}
+	return file_relationship_proto_rawDescGZIP(), []int{18}
 }
 
 func (x *Precondition) GetType() PreconditionType {
@@ -1122,7 +1538,29 @@ const file_relationship_proto_rawDesc = "" +
 	"\x14WriteOperationResult\x12\x18\n" +
 	"\asuccess\x18\x01 \x01(\bR\asuccess\x12\x14\n" +
 	"\x05error\x18\x02 \x01(\tR\x05error\x12'\n" +
-	"\x0ftuples_affected\x18\x03 \x01(\x05R\x0etuplesAffected\"\x9a\x01\n" +
+	"\x0ftuples_affected\x18\x03 \x01(\x05R\x0etuplesAffected\"s\n" +
+	"\x16ImportRelationsRequest\x12/\n" +
+	"\x06tuples\x18\x01 \x03(\v2\x17.goacl.v1.RelationTupleR\x06tuples\x12(\n" +
+	"\x04mode\x18\x02 \x01(\x0e2\x14.goacl.v1.ImportModeR\x04mode\"\xec\x02\n" +
+	"\x17ImportRelationsResponse\x12'\n" +
+	"\x0ftuples_received\x18\x01 \x01(\x03R\x0etuplesReceived\x12'\n" +
+	"\x0ftuples_imported\x18\x02 \x01(\x03R\x0etuplesImported\x12%\n" +
+	"\x0etuples_skipped\x18\x03 \x01(\x03R\rtuplesSkipped\x12#\n" +
+	"\rtuples_failed\x18\x04 \x01(\x03R\ftuplesFailed\x12-\n" +
+	"\x06errors\x18\x05 \x03(\v2\x15.goacl.v1.ImportErrorR\x06errors\x12\x18\n" +
+	"\aaborted\x18\x06 \x01(\bR\aaborted\x12+\n" +
+	"\x11consistency_token\x18\a \x01(\tR\x10consistencyToken\x12=\n" +
+	"\fcompleted_at\x18\b \x01(\v2\x1a.google.protobuf.TimestampR\vcompletedAt\"d\n" +
+	"\vImportError\x12\x10\n" +
+	"\x03row\x18\x01 \x01(\x03R\x03row\x12-\n" +
+	"\x05tuple\x18\x02 \x01(\v2\x17.goacl.v1.RelationTupleR\x05tuple\x12\x14\n" +
+	"\x05error\x18\x03 \x01(\tR\x05error\"E\n" +
+	"\x16ExportRelationsRequest\x12+\n" +
+	"\x11consistency_token\x18\x01 \x01(\tR\x10consistencyToken\"\x8f\x01\n" +
+	"\x17ExportRelationsResponse\x129\n" +
+	"\tnamespace\x18\x01 \x01(\v2\x19.goacl.v1.NamespaceConfigH\x00R\tnamespace\x12/\n" +
+	"\x05tuple\x18\x02 \x01(\v2\x17.goacl.v1.RelationTupleH\x00R\x05tupleB\b\n" +
+	"\x06record\"\x9a\x01\n" +
 	"\x0eRelationFilter\x12\x1c\n" +
 	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x12\x1b\n" +
 	"\tobject_id\x18\x02 \x01(\tR\bobjectId\x12\x1a\n" +
@@ -1135,20 +1573,27 @@ const file_relationship_proto_rawDesc = "" +
 	"\x10PreconditionType\x12!\n" +
 	"\x1dPRECONDITION_TYPE_UNSPECIFIED\x10\x00\x12 \n" +
 	"\x1cPRECONDITION_TYPE_MUST_EXIST\x10\x01\x12$\n" +
-	" PRECONDITION_TYPE_MUST_NOT_EXIST\x10\x02*t\n" +
+	" PRECONDITION_TYPE_MUST_NOT_EXIST\x10\x02*f\n" +
+	"\n" +
+	"ImportMode\x12\x1b\n" +
+	"\x17IMPORT_MODE_UNSPECIFIED\x10\x00\x12\x1e\n" +
+	"\x1aIMPORT_MODE_ABORT_ON_ERROR\x10\x01\x12\x1b\n" +
+	"\x17IMPORT_MODE_BEST_EFFORT\x10\x02*t\n" +
 	"\n" +
 	"ChangeType\x12\x1b\n" +
 	"\x17CHANGE_TYPE_UNSPECIFIED\x10\x00\x12\x17\n" +
 	"\x13CHANGE_TYPE_CREATED\x10\x01\x12\x17\n" +
 	"\x13CHANGE_TYPE_DELETED\x10\x02\x12\x17\n" +
-	"\x13CHANGE_TYPE_UPDATED\x10\x032\xa0\x04\n" +
+	"\x13CHANGE_TYPE_UPDATED\x10\x032\xd6\x05\n" +
 	"\x13RelationshipService\x12j\n" +
 	"\rWriteRelation\x12\x1e.goacl.v1.WriteRelationRequest\x1a\x1f.goacl.v1.WriteRelationResponse\"\x18\x82\xd3\xe4\x93\x02\x12:\x01*\"\r/v1/relations\x12t\n" +
 	"\x0eDeleteRelation\x12\x1f.goacl.v1.DeleteRelationRequest\x1a .goacl.v1.DeleteRelationResponse\"\x1f\x82\xd3\xe4\x93\x02\x19:\x01*\"\x14/v1/relations/delete\x12g\n" +
 	"\rReadRelations\x12\x1e.goacl.v1.ReadRelationsRequest\x1a\x1f.goacl.v1.ReadRelationsResponse\"\x15\x82\xd3\xe4\x93\x02\x0f\x12\r/v1/relations\x12U\n" +
 	"\x0eWatchRelations\x12\x1f.goacl.v1.WatchRelationsRequest\x1a .goacl.v1.WatchRelationsResponse0\x01\x12g\n" +
 	"\n" +
-	"BatchWrite\x12\x1b.goacl.v1.BatchWriteRequest\x1a\x1c.goacl.v1.BatchWriteResponse\"\x1e\x82\xd3\xe4\x93\x02\x18:\x01*\"\x13/v1/relations/batchB\x83\x01\n" +
+	"BatchWrite\x12\x1b.goacl.v1.BatchWriteRequest\x1a\x1c.goacl.v1.BatchWriteResponse\"\x1e\x82\xd3\xe4\x93\x02\x18:\x01*\"\x13/v1/relations/batch\x12Z\n" +
+	"\x0fImportRelations\x12 .goacl.v1.ImportRelationsRequest\x1a!.goacl.v1.ImportRelationsResponse(\x010\x01\x12X\n" +
+	"\x0fExportRelations\x12 .goacl.v1.ExportRelationsRequest\x1a!.goacl.v1.ExportRelationsResponse0\x01B\x83\x01\n" +
 	"\fcom.goacl.v1B\x11RelationshipProtoP\x01Z\x1fgithub.com/DangVTNhan/goacl/api\xa2\x02\x03GXX\xaa\x02\bGoacl.V1\xca\x02\bGoacl\\V1\xe2\x02\x14Goacl\\V1\\GPBMetadata\xea\x02\tGoacl::V1b\x06proto3"
 
 var (
@@ -1163,63 +1608,81 @@ func file_relationship_proto_rawDescGZIP() []byte {
 	return file_relationship_proto_rawDescData
 }
 
-var file_relationship_proto_enumTypes = make([]protoimpl.EnumInfo, 2)
-var file_relationship_proto_msgTypes = make([]protoimpl.MessageInfo, 14)
+var file_relationship_proto_enumTypes = make([]protoimpl.EnumInfo, 3)
+var file_relationship_proto_msgTypes = make([]protoimpl.MessageInfo, 19)
 var file_relationship_proto_goTypes = []any{
-	(PreconditionType)(0),          // 0: goacl.v1.PreconditionType
-	(ChangeType)(0),                // 1: goacl.v1.ChangeType
-	(*WriteRelationRequest)(nil),   // 2: goacl.v1.WriteRelationRequest
-	(*WriteRelationResponse)(nil),  // 3: goacl.v1.WriteRelationResponse
-	(*DeleteRelationRequest)(nil),  // 4: goacl.v1.DeleteRelationRequest
-	(*DeleteRelationResponse)(nil), // 5: goacl.v1.DeleteRelationResponse
-	(*ReadRelationsRequest)(nil),   // 6: goacl.v1.ReadRelationsRequest
-	(*ReadRelationsResponse)(nil),  // 7: goacl.v1.ReadRelationsResponse
-	(*WatchRelationsRequest)(nil),  // 8: goacl.v1.WatchRelationsRequest
-	(*WatchRelationsResponse)(nil), // 9: goacl.v1.WatchRelationsResponse
-	(*BatchWriteRequest)(nil),      // 10: goacl.v1.BatchWriteRequest
-	(*BatchWriteResponse)(nil),     // 11: goacl.v1.BatchWriteResponse
-	(*WriteOperation)(nil),         // 12: goacl.v1.WriteOperation
-	(*WriteOperationResult)(nil),   // 13: goacl.v1.WriteOperationResult
-	(*RelationFilter)(nil),         // 14: goacl.v1.RelationFilter
-	(*Precondition)(nil),           // 15: goacl.v1.Precondition
-	(*RelationTuple)(nil),          // 16: goacl.v1.RelationTuple
-	(*timestamppb.Timestamp)(nil),  // 17: google.protobuf.Timestamp
+	(PreconditionType)(0),           // 0: goacl.v1.PreconditionType
+	(ImportMode)(0),                 // 1: goacl.v1.ImportMode
+	(ChangeType)(0),                 // 2: goacl.v1.ChangeType
+	(*WriteRelationRequest)(nil),    // 3: goacl.v1.WriteRelationRequest
+	(*WriteRelationResponse)(nil),   // 4: goacl.v1.WriteRelationResponse
+	(*DeleteRelationRequest)(nil),   // 5: goacl.v1.DeleteRelationRequest
+	(*DeleteRelationResponse)(nil),  // 6: goacl.v1.DeleteRelationResponse
+	(*ReadRelationsRequest)(nil),    // 7: goacl.v1.ReadRelationsRequest
+	(*ReadRelationsResponse)(nil),   // 8: goacl.v1.ReadRelationsResponse
+	(*WatchRelationsRequest)(nil),   // 9: goacl.v1.WatchRelationsRequest
+	(*WatchRelationsResponse)(nil),  // 10: goacl.v1.WatchRelationsResponse
+	(*BatchWriteRequest)(nil),       // 11: goacl.v1.BatchWriteRequest
+	(*BatchWriteResponse)(nil),      // 12: goacl.v1.BatchWriteResponse
+	(*WriteOperation)(nil),          // 13: goacl.v1.WriteOperation
+	(*WriteOperationResult)(nil),    // 14: goacl.v1.WriteOperationResult
+	(*ImportRelationsRequest)(nil),  // 15: goacl.v1.ImportRelationsRequest
+	(*ImportRelationsResponse)(nil), // 16: goacl.v1.ImportRelationsResponse
+	(*ImportError)(nil),             // 17: goacl.v1.ImportError
+	(*ExportRelationsRequest)(nil),  // 18: goacl.v1.ExportRelationsRequest
+	(*ExportRelationsResponse)(nil), // 19: goacl.v1.ExportRelationsResponse
+	(*RelationFilter)(nil),          // 20: goacl.v1.RelationFilter
+	(*Precondition)(nil),            // 21: goacl.v1.Precondition
+	(*RelationTuple)(nil),           // 22: goacl.v1.RelationTuple
+	(*timestamppb.Timestamp)(nil),   // 23: google.protobuf.Timestamp
+	(*NamespaceConfig)(nil),         // 24: goacl.v1.NamespaceConfig
 }
 var file_relationship_proto_depIdxs = []int32{
-	16, // 0: goacl.v1.WriteRelationRequest.tuples:type_name -> goacl.v1.RelationTuple
-	15, // 1: goacl.v1.WriteRelationRequest.preconditions:type_name -> goacl.v1.Precondition
-	17, // 2: goacl.v1.WriteRelationResponse.written_at:type_name -> google.protobuf.Timestamp
-	14, // 3: goacl.v1.DeleteRelationRequest.filter:type_name -> goacl.v1.RelationFilter
-	15, // 4: goacl.v1.DeleteRelationRequest.preconditions:type_name -> goacl.v1.Precondition
-	17, // 5: goacl.v1.DeleteRelationResponse.deleted_at:type_name -> google.protobuf.Timestamp
-	14, // 6: goacl.v1.ReadRelationsRequest.filter:type_name -> goacl.v1.RelationFilter
-	16, // 7: goacl.v1.ReadRelationsResponse.tuples:type_name -> goacl.v1.RelationTuple
-	14, // 8: goacl.v1.WatchRelationsRequest.filter:type_name -> goacl.v1.RelationFilter
-	1,  // 9: goacl.v1.WatchRelationsResponse.change_type:type_name -> goacl.v1.ChangeType
-	16, // 10: goacl.v1.WatchRelationsResponse.tuple:type_name -> goacl.v1.RelationTuple
-	17, // 11: goacl.v1.WatchRelationsResponse.changed_at:type_name -> google.protobuf.Timestamp
-	12, // 12: goacl.v1.BatchWriteRequest.operations:type_name -> goacl.v1.WriteOperation
-	17, // 13: goacl.v1.BatchWriteResponse.written_at:type_name -> google.protobuf.Timestamp
-	13, // 14: goacl.v1.BatchWriteResponse.results:type_name -> goacl.v1.WriteOperationResult
-	2,  // 15: goacl.v1.WriteOperation.write:type_name -> goacl.v1.WriteRelationRequest
-	4,  // 16: goacl.v1.WriteOperation.delete:type_name -> goacl.v1.DeleteRelationRequest
-	0,  // 17: goacl.v1.Precondition.type:type_name -> goacl.v1.PreconditionType
-	16, // 18: goacl.v1.Precondition.tuple:type_name -> goacl.v1.RelationTuple
-	2,  // 19: goacl.v1.RelationshipService.WriteRelation:input_type -> goacl.v1.WriteRelationRequest
-	4,  // 20: goacl.v1.RelationshipService.DeleteRelation:input_type -> goacl.v1.DeleteRelationRequest
-	6,  // 21: goacl.v1.RelationshipService.ReadRelations:input_type -> goacl.v1.ReadRelationsRequest
-	8,  // 22: goacl.v1.RelationshipService.WatchRelations:input_type -> goacl.v1.WatchRelationsRequest
-	10, // 23: goacl.v1.RelationshipService.BatchWrite:input_type -> goacl.v1.BatchWriteRequest
-	3,  // 24: goacl.v1.RelationshipService.WriteRelation:output_type -> goacl.v1.WriteRelationResponse
-	5,  // 25: goacl.v1.RelationshipService.DeleteRelation:output_type -> goacl.v1.DeleteRelationResponse
-	7,  // 26: goacl.v1.RelationshipService.ReadRelations:output_type -> goacl.v1.ReadRelationsResponse
-	9,  // 27: goacl.v1.RelationshipService.WatchRelations:output_type -> goacl.v1.WatchRelationsResponse
-	11, // 28: goacl.v1.RelationshipService.BatchWrite:output_type -> goacl.v1.BatchWriteResponse
-	24, // [24:29] is the sub-list for method output_type
-	19, // [19:24] is the sub-list for method input_type
-	19, // [19:19] is the sub-list for extension type_name
-	19, // [19:19] is the sub-list for extension extendee
-	0,  // [0:19] is the sub-list for field type_name
+	22, // 0: goacl.v1.WriteRelationRequest.tuples:type_name -> goacl.v1.RelationTuple
+	21, // 1: goacl.v1.WriteRelationRequest.preconditions:type_name -> goacl.v1.Precondition
+	23, // 2: goacl.v1.WriteRelationResponse.written_at:type_name -> google.protobuf.Timestamp
+	20, // 3: goacl.v1.DeleteRelationRequest.filter:type_name -> goacl.v1.RelationFilter
+	21, // 4: goacl.v1.DeleteRelationRequest.preconditions:type_name -> goacl.v1.Precondition
+	23, // 5: goacl.v1.DeleteRelationResponse.deleted_at:type_name -> google.protobuf.Timestamp
+	20, // 6: goacl.v1.ReadRelationsRequest.filter:type_name -> goacl.v1.RelationFilter
+	22, // 7: goacl.v1.ReadRelationsResponse.tuples:type_name -> goacl.v1.RelationTuple
+	20, // 8: goacl.v1.WatchRelationsRequest.filter:type_name -> goacl.v1.RelationFilter
+	2,  // 9: goacl.v1.WatchRelationsResponse.change_type:type_name -> goacl.v1.ChangeType
+	22, // 10: goacl.v1.WatchRelationsResponse.tuple:type_name -> goacl.v1.RelationTuple
+	23, // 11: goacl.v1.WatchRelationsResponse.changed_at:type_name -> google.protobuf.Timestamp
+	13, // 12: goacl.v1.BatchWriteRequest.operations:type_name -> goacl.v1.WriteOperation
+	23, // 13: goacl.v1.BatchWriteResponse.written_at:type_name -> google.protobuf.Timestamp
+	14, // 14: goacl.v1.BatchWriteResponse.results:type_name -> goacl.v1.WriteOperationResult
+	3,  // 15: goacl.v1.WriteOperation.write:type_name -> goacl.v1.WriteRelationRequest
+	5,  // 16: goacl.v1.WriteOperation.delete:type_name -> goacl.v1.DeleteRelationRequest
+	22, // 17: goacl.v1.ImportRelationsRequest.tuples:type_name -> goacl.v1.RelationTuple
+	1,  // 18: goacl.v1.ImportRelationsRequest.mode:type_name -> goacl.v1.ImportMode
+	17, // 19: goacl.v1.ImportRelationsResponse.errors:type_name -> goacl.v1.ImportError
+	23, // 20: goacl.v1.ImportRelationsResponse.completed_at:type_name -> google.protobuf.Timestamp
+	22, // 21: goacl.v1.ImportError.tuple:type_name -> goacl.v1.RelationTuple
+	24, // 22: goacl.v1.ExportRelationsResponse.namespace:type_name -> goacl.v1.NamespaceConfig
+	22, // 23: goacl.v1.ExportRelationsResponse.tuple:type_name -> goacl.v1.RelationTuple
+	0,  // 24: goacl.v1.Precondition.type:type_name -> goacl.v1.PreconditionType
+	22, // 25: goacl.v1.Precondition.tuple:type_name -> goacl.v1.RelationTuple
+	3,  // 26: goacl.v1.RelationshipService.WriteRelation:input_type -> goacl.v1.WriteRelationRequest
+	5,  // 27: goacl.v1.RelationshipService.DeleteRelation:input_type -> goacl.v1.DeleteRelationRequest
+	7,  // 28: goacl.v1.RelationshipService.ReadRelations:input_type -> goacl.v1.ReadRelationsRequest
+	9,  // 29: goacl.v1.RelationshipService.WatchRelations:input_type -> goacl.v1.WatchRelationsRequest
+	11, // 30: goacl.v1.RelationshipService.BatchWrite:input_type -> goacl.v1.BatchWriteRequest
+	15, // 31: goacl.v1.RelationshipService.ImportRelations:input_type -> goacl.v1.ImportRelationsRequest
+	18, // 32: goacl.v1.RelationshipService.ExportRelations:input_type -> goacl.v1.ExportRelationsRequest
+	4,  // 33: goacl.v1.RelationshipService.WriteRelation:output_type -> goacl.v1.WriteRelationResponse
+	6,  // 34: goacl.v1.RelationshipService.DeleteRelation:output_type -> goacl.v1.DeleteRelationResponse
+	8,  // 35: goacl.v1.RelationshipService.ReadRelations:output_type -> goacl.v1.ReadRelationsResponse
+	10, // 36: goacl.v1.RelationshipService.WatchRelations:output_type -> goacl.v1.WatchRelationsResponse
+	12, // 37: goacl.v1.RelationshipService.BatchWrite:output_type -> goacl.v1.BatchWriteResponse
+	16, // 38: goacl.v1.RelationshipService.ImportRelations:output_type -> goacl.v1.ImportRelationsResponse
+	19, // 39: goacl.v1.RelationshipService.ExportRelations:output_type -> goacl.v1.ExportRelationsResponse
+	33, // [33:40] is the sub-list for method output_type
+	26, // [26:33] is the sub-list for method input_type
+	26, // [26:26] is the sub-list for extension type_name
+	26, // [26:26] is the sub-list for extension extendee
+	0,  // [0:26] is the sub-list for field type_name
 }
 
 func init() { file_relationship_proto_init() }
@@ -1232,13 +1695,17 @@ func file_relationship_proto_init() {
 		(*WriteOperation_Write)(nil),
 		(*WriteOperation_Delete)(nil),
 	}
+	file_relationship_proto_msgTypes[16].OneofWrappers = []any{
+		(*ExportRelationsResponse_Namespace)(nil),
+		(*ExportRelationsResponse_Tuple)(nil),
+	}
 	type x struct{}
 	out := protoimpl.TypeBuilder{
 		File: protoimpl.DescBuilder{
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_relationship_proto_rawDesc), len(file_relationship_proto_rawDesc)),
-			NumEnums:      2,
-			NumMessages:   14,
+			NumEnums:      3,
+			NumMessages:   19,
 			NumExtensions: 0,
 			NumServices:   1,
 		},
@@ -19,11 +19,13 @@ import (
 const _ = grpc.SupportPackageIsVersion9
 
 const (
-	RelationshipService_WriteRelation_FullMethodName  = "/goacl.v1.RelationshipService/WriteRelation"
-	RelationshipService_DeleteRelation_FullMethodName = "/goacl.v1.RelationshipService/DeleteRelation"
-	RelationshipService_ReadRelations_FullMethodName  = "/goacl.v1.RelationshipService/ReadRelations"
-	RelationshipService_WatchRelations_FullMethodName = "/goacl.v1.RelationshipService/WatchRelations"
-	RelationshipService_BatchWrite_FullMethodName     = "/goacl.v1.RelationshipService/BatchWrite"
+	RelationshipService_WriteRelation_FullMethodName   = "/goacl.v1.RelationshipService/WriteRelation"
+	RelationshipService_DeleteRelation_FullMethodName  = "/goacl.v1.RelationshipService/DeleteRelation"
+	RelationshipService_ReadRelations_FullMethodName   = "/goacl.v1.RelationshipService/ReadRelations"
+	RelationshipService_WatchRelations_FullMethodName  = "/goacl.v1.RelationshipService/WatchRelations"
+	RelationshipService_BatchWrite_FullMethodName      = "/goacl.v1.RelationshipService/BatchWrite"
+	RelationshipService_ImportRelations_FullMethodName = "/goacl.v1.RelationshipService/ImportRelations"
+	RelationshipService_ExportRelations_FullMethodName = "/goacl.v1.RelationshipService/ExportRelations"
 )
 
 // RelationshipServiceClient is the client API for RelationshipService service.
@@ -42,6 +44,13 @@ type RelationshipServiceClient interface {
 	WatchRelations(ctx context.Context, in *WatchRelationsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[WatchRelationsResponse], error)
 	// Batch write multiple relation operations atomically
 	BatchWrite(ctx context.Context, in *BatchWriteRequest, opts ...grpc.CallOption) (*BatchWriteResponse, error)
+	// Bulk load relation tuples streamed by the client, reporting progress as
+	// each batch is stored; the last response, with completed_at set, reports
+	// the outcome of the import
+	ImportRelations(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ImportRelationsRequest, ImportRelationsResponse], error)
+	// Export every namespace configuration and relation tuple read at a single
+	// snapshot, whose consistency token is sent in the "consistency-token" trailer
+	ExportRelations(ctx context.Context, in *ExportRelationsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ExportRelationsResponse], error)
 }
 
 type relationshipServiceClient struct {
@@ -111,6 +120,38 @@ func (c *relationshipServiceClient) BatchWrite(ctx context.Context, in *BatchWri
 	return out, nil
 }
 
+func (c *relationshipServiceClient) ImportRelations(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[ImportRelationsRequest, ImportRelationsResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &RelationshipService_ServiceDesc.Streams[1], RelationshipService_ImportRelations_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ImportRelationsRequest, ImportRelationsResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RelationshipService_ImportRelationsClient = grpc.BidiStreamingClient[ImportRelationsRequest, ImportRelationsResponse]
+
+func (c *relationshipServiceClient) ExportRelations(ctx context.Context, in *ExportRelationsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[ExportRelationsResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &RelationshipService_ServiceDesc.Streams[2], RelationshipService_ExportRelations_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[ExportRelationsRequest, ExportRelationsResponse]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RelationshipService_ExportRelationsClient = grpc.ServerStreamingClient[ExportRelationsResponse]
+
 // RelationshipServiceServer is the server API for RelationshipService service.
 // All implementations must embed UnimplementedRelationshipServiceServer
 // for forward compatibility.
@@ -127,6 +168,13 @@ type RelationshipServiceServer interface {
 	WatchRelations(*WatchRelationsRequest, grpc.ServerStreamingServer[WatchRelationsResponse]) error
 	// Batch write multiple relation operations atomically
 	BatchWrite(context.Context, *BatchWriteRequest) (*BatchWriteResponse, error)
+	// Bulk load relation tuples streamed by the client, reporting progress as
+	// each batch is stored; the last response, with completed_at set, reports
+	// the outcome of the import
+	ImportRelations(grpc.BidiStreamingServer[ImportRelationsRequest, ImportRelationsResponse]) error
+	// Export every namespace configuration and relation tuple read at a single
+	// snapshot, whose consistency token is sent in the "consistency-token" trailer
+	ExportRelations(*ExportRelationsRequest, grpc.ServerStreamingServer[ExportRelationsResponse]) error
 	mustEmbedUnimplementedRelationshipServiceServer()
 }
 
@@ -152,6 +200,12 @@ func (UnimplementedRelationshipServiceServer) WatchRelations(*WatchRelationsRequ
 func (UnimplementedRelationshipServiceServer) BatchWrite(context.Context, *BatchWriteRequest) (*BatchWriteResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method BatchWrite not implemented")
 }
+func (UnimplementedRelationshipServiceServer) ImportRelations(grpc.BidiStreamingServer[ImportRelationsRequest, ImportRelationsResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method ImportRelations not implemented")
+}
+func (UnimplementedRelationshipServiceServer) ExportRelations(*ExportRelationsRequest, grpc.ServerStreamingServer[ExportRelationsResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method ExportRelations not implemented")
+}
 func (UnimplementedRelationshipServiceServer) mustEmbedUnimplementedRelationshipServiceServer() {}
 func (UnimplementedRelationshipServiceServer) testEmbeddedByValue()                             {}
 
@@ -256,6 +310,24 @@ func _RelationshipService_BatchWrite_Handler(srv interface{}, ctx context.Contex
 	return interceptor(ctx, in, info, handler)
 }
 
+func _RelationshipService_ImportRelations_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(RelationshipServiceServer).ImportRelations(&grpc.GenericServerStream[ImportRelationsRequest, ImportRelationsResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RelationshipService_ImportRelationsServer = grpc.BidiStreamingServer[ImportRelationsRequest, ImportRelationsResponse]
+
+func _RelationshipService_ExportRelations_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ExportRelationsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(RelationshipServiceServer).ExportRelations(m, &grpc.GenericServerStream[ExportRelationsRequest, ExportRelationsResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type RelationshipService_ExportRelationsServer = grpc.ServerStreamingServer[ExportRelationsResponse]
+
 // RelationshipService_ServiceDesc is the grpc.ServiceDesc for RelationshipService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -286,6 +358,17 @@ var RelationshipService_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _RelationshipService_WatchRelations_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "ImportRelations",
+			Handler:       _RelationshipService_ImportRelations_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+		{
+			StreamName:    "ExportRelations",
+			Handler:       _RelationshipService_ExportRelations_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "relationship.proto",
 }
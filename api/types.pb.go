@@ -33,13 +33,20 @@ type RelationTuple struct {
 	// The relation name (e.g., "viewer", "editor", "owner")
 	Relation string `protobuf:"bytes,3,opt,name=relation,proto3" json:"relation,omitempty"`
 	// The user ID in the relationship
+	// May be the wildcard "*" or "type:*" to match every user (of a type)
+	// on relations that allow wildcards
 	UserId string `protobuf:"bytes,4,opt,name=user_id,json=userId,proto3" json:"user_id,omitempty"`
 	// Optional userset for indirect relationships (e.g., "group:eng#member")
 	Userset string `protobuf:"bytes,5,opt,name=userset,proto3" json:"userset,omitempty"`
 	// When this tuple was created
 	CreatedAt *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
 	// When this tuple was last updated
-	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	UpdatedAt *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	// Optional condition that must hold for the tuple to apply, evaluated
+	// against the check request context (e.g., "request.ip in 10.0.0.0/8")
+	Condition string `protobuf:"bytes,8,opt,name=condition,proto3" json:"condition,omitempty"`
+	// Optional time after which the tuple no longer applies and is deleted
+	ExpiresAt     *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=expires_at,json=expiresAt,proto3" json:"expires_at,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -123,6 +130,20 @@ func (x *RelationTuple) GetUpdatedAt() *timestamppb.Timestamp {
 	return nil
 }
 
+func (x *RelationTuple) GetCondition() string {
+	if x != nil {
+		return x.Condition
+	}
+	return ""
+}
+
+func (x *RelationTuple) GetExpiresAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.ExpiresAt
+	}
+	return nil
+}
+
 // NamespaceConfig defines the schema and rules for a namespace
 type NamespaceConfig struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
@@ -133,7 +154,10 @@ type NamespaceConfig struct {
 	// When this namespace was created
 	CreatedAt *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
 	// When this namespace was last updated
-	UpdatedAt     *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	UpdatedAt *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	// Version of the configuration, incremented by every write
+	// Pass it as expected_version to update or delete this configuration
+	Version       int64 `protobuf:"varint,5,opt,name=version,proto3" json:"version,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -196,6 +220,13 @@ func (x *NamespaceConfig) GetUpdatedAt() *timestamppb.Timestamp {
 	return nil
 }
 
+func (x *NamespaceConfig) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
 // RelationConfig defines a single relation within a namespace
 type RelationConfig struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
@@ -203,9 +234,15 @@ type RelationConfig struct {
 	Name string `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
 	// Userset rewrite rules in JSON format
 	// Defines how this relation can be computed from other relations
+	// Responses always set both this and rewrite; requests set either one
 	RewriteRules string `protobuf:"bytes,2,opt,name=rewrite_rules,json=rewriteRules,proto3" json:"rewrite_rules,omitempty"`
 	// Optional description of this relation
-	Description   string `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	Description string `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	// Whether tuples may grant this relation to a wildcard subject, either
+	// "*" for every user or "type:*" for every user of a type
+	AllowWildcard bool `protobuf:"varint,4,opt,name=allow_wildcard,json=allowWildcard,proto3" json:"allow_wildcard,omitempty"`
+	// Userset rewrite rules as a typed tree, equivalent to rewrite_rules
+	Rewrite       *Rewrite `protobuf:"bytes,5,opt,name=rewrite,proto3" json:"rewrite,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
@@ -261,6 +298,454 @@ func (x *RelationConfig) GetDescription() string {
 	return ""
 }
 
+func (x *RelationConfig) GetAllowWildcard() bool {
+	if x != nil {
+		return x.AllowWildcard
+	}
+	return false
+}
+
+func (x *RelationConfig) GetRewrite() *Rewrite {
+	if x != nil {
+		return x.Rewrite
+	}
+	return nil
+}
+
+// Rewrite is a node of a userset rewrite tree, the typed form of the JSON
+// rewrite rules
+type Rewrite struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Rewrite:
+	//
+	//	*Rewrite_This
+	//	*Rewrite_ComputedUserset
+	//	*Rewrite_TupleToUserset
+	//	*Rewrite_Union
+	//	*Rewrite_Intersection
+	//	*Rewrite_Exclusion
+	Rewrite       isRewrite_Rewrite `protobuf_oneof:"rewrite"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Rewrite) Reset() {
+	*x = Rewrite{}
+	mi := &file_types_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Rewrite) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Rewrite) ProtoMessage() {}
+
+func (x *Rewrite) ProtoReflect() protoreflect.Message {
+	mi := &file_types_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Rewrite.ProtoReflect.Descriptor instead.
+func (*Rewrite) Descriptor() ([]byte, []int) {
+	return file_types_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *Rewrite) GetRewrite() isRewrite_Rewrite {
+	if x != nil {
+		return x.Rewrite
+	}
+	return nil
+}
+
+func (x *Rewrite) GetThis() *RewriteThis {
+	if x != nil {
+		if x, ok := x.Rewrite.(*Rewrite_This); ok {
+			return x.This
+		}
+	}
+	return nil
+}
+
+func (x *Rewrite) GetComputedUserset() *RewriteComputedUserset {
+	if x != nil {
+		if x, ok := x.Rewrite.(*Rewrite_ComputedUserset); ok {
+			return x.ComputedUserset
+		}
+	}
+	return nil
+}
+
+func (x *Rewrite) GetTupleToUserset() *RewriteTupleToUserset {
+	if x != nil {
+		if x, ok := x.Rewrite.(*Rewrite_TupleToUserset); ok {
+			return x.TupleToUserset
+		}
+	}
+	return nil
+}
+
+func (x *Rewrite) GetUnion() *RewriteSetOperation {
+	if x != nil {
+		if x, ok := x.Rewrite.(*Rewrite_Union); ok {
+			return x.Union
+		}
+	}
+	return nil
+}
+
+func (x *Rewrite) GetIntersection() *RewriteSetOperation {
+	if x != nil {
+		if x, ok := x.Rewrite.(*Rewrite_Intersection); ok {
+			return x.Intersection
+		}
+	}
+	return nil
+}
+
+func (x *Rewrite) GetExclusion() *RewriteExclusion {
+	if x != nil {
+		if x, ok := x.Rewrite.(*Rewrite_Exclusion); ok {
+			return x.Exclusion
+		}
+	}
+	return nil
+}
+
+type isRewrite_Rewrite interface {
+	isRewrite_Rewrite()
+}
+
+type Rewrite_This struct {
+	// The tuples stored directly for the relation
+	This *RewriteThis `protobuf:"bytes,1,opt,name=this,proto3,oneof"`
+}
+
+type Rewrite_ComputedUserset struct {
+	// Another relation on the same object
+	ComputedUserset *RewriteComputedUserset `protobuf:"bytes,2,opt,name=computed_userset,json=computedUserset,proto3,oneof"`
+}
+
+type Rewrite_TupleToUserset struct {
+	// A relation on the objects referenced by a tupleset relation
+	TupleToUserset *RewriteTupleToUserset `protobuf:"bytes,3,opt,name=tuple_to_userset,json=tupleToUserset,proto3,oneof"`
+}
+
+type Rewrite_Union struct {
+	// Users of any child
+	Union *RewriteSetOperation `protobuf:"bytes,4,opt,name=union,proto3,oneof"`
+}
+
+type Rewrite_Intersection struct {
+	// Users of every child
+	Intersection *RewriteSetOperation `protobuf:"bytes,5,opt,name=intersection,proto3,oneof"`
+}
+
+type Rewrite_Exclusion struct {
+	// Users of the base but not of the exclude
+	Exclusion *RewriteExclusion `protobuf:"bytes,6,opt,name=exclusion,proto3,oneof"`
+}
+
+func (*Rewrite_This) isRewrite_Rewrite() {}
+
+func (*Rewrite_ComputedUserset) isRewrite_Rewrite() {}
+
+func (*Rewrite_TupleToUserset) isRewrite_Rewrite() {}
+
+func (*Rewrite_Union) isRewrite_Rewrite() {}
+
+func (*Rewrite_Intersection) isRewrite_Rewrite() {}
+
+func (*Rewrite_Exclusion) isRewrite_Rewrite() {}
+
+// RewriteThis refers to the tuples stored directly for the relation
+type RewriteThis struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RewriteThis) Reset() {
+	*x = RewriteThis{}
+	mi := &file_types_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RewriteThis) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RewriteThis) ProtoMessage() {}
+
+func (x *RewriteThis) ProtoReflect() protoreflect.Message {
+	mi := &file_types_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RewriteThis.ProtoReflect.Descriptor instead.
+func (*RewriteThis) Descriptor() ([]byte, []int) {
+	return file_types_proto_rawDescGZIP(), []int{4}
+}
+
+// RewriteComputedUserset refers to another relation on the same object
+type RewriteComputedUserset struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Relation      string                 `protobuf:"bytes,1,opt,name=relation,proto3" json:"relation,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RewriteComputedUserset) Reset() {
+	*x = RewriteComputedUserset{}
+	mi := &file_types_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RewriteComputedUserset) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RewriteComputedUserset) ProtoMessage() {}
+
+func (x *RewriteComputedUserset) ProtoReflect() protoreflect.Message {
+	mi := &file_types_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RewriteComputedUserset.ProtoReflect.Descriptor instead.
+func (*RewriteComputedUserset) Descriptor() ([]byte, []int) {
+	return file_types_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *RewriteComputedUserset) GetRelation() string {
+	if x != nil {
+		return x.Relation
+	}
+	return ""
+}
+
+// RewriteTupleset selects the tuples whose subjects RewriteTupleToUserset
+// follows
+type RewriteTupleset struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Relation      string                 `protobuf:"bytes,1,opt,name=relation,proto3" json:"relation,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RewriteTupleset) Reset() {
+	*x = RewriteTupleset{}
+	mi := &file_types_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RewriteTupleset) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RewriteTupleset) ProtoMessage() {}
+
+func (x *RewriteTupleset) ProtoReflect() protoreflect.Message {
+	mi := &file_types_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RewriteTupleset.ProtoReflect.Descriptor instead.
+func (*RewriteTupleset) Descriptor() ([]byte, []int) {
+	return file_types_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *RewriteTupleset) GetRelation() string {
+	if x != nil {
+		return x.Relation
+	}
+	return ""
+}
+
+// RewriteTupleToUserset evaluates computed_userset on every object the
+// tuples of the tupleset relation point to
+type RewriteTupleToUserset struct {
+	state           protoimpl.MessageState  `protogen:"open.v1"`
+	Tupleset        *RewriteTupleset        `protobuf:"bytes,1,opt,name=tupleset,proto3" json:"tupleset,omitempty"`
+	ComputedUserset *RewriteComputedUserset `protobuf:"bytes,2,opt,name=computed_userset,json=computedUserset,proto3" json:"computed_userset,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *RewriteTupleToUserset) Reset() {
+	*x = RewriteTupleToUserset{}
+	mi := &file_types_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RewriteTupleToUserset) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RewriteTupleToUserset) ProtoMessage() {}
+
+func (x *RewriteTupleToUserset) ProtoReflect() protoreflect.Message {
+	mi := &file_types_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RewriteTupleToUserset.ProtoReflect.Descriptor instead.
+func (*RewriteTupleToUserset) Descriptor() ([]byte, []int) {
+	return file_types_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *RewriteTupleToUserset) GetTupleset() *RewriteTupleset {
+	if x != nil {
+		return x.Tupleset
+	}
+	return nil
+}
+
+func (x *RewriteTupleToUserset) GetComputedUserset() *RewriteComputedUserset {
+	if x != nil {
+		return x.ComputedUserset
+	}
+	return nil
+}
+
+// RewriteSetOperation combines the usersets of its children
+type RewriteSetOperation struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Child         []*Rewrite             `protobuf:"bytes,1,rep,name=child,proto3" json:"child,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RewriteSetOperation) Reset() {
+	*x = RewriteSetOperation{}
+	mi := &file_types_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RewriteSetOperation) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RewriteSetOperation) ProtoMessage() {}
+
+func (x *RewriteSetOperation) ProtoReflect() protoreflect.Message {
+	mi := &file_types_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RewriteSetOperation.ProtoReflect.Descriptor instead.
+func (*RewriteSetOperation) Descriptor() ([]byte, []int) {
+	return file_types_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *RewriteSetOperation) GetChild() []*Rewrite {
+	if x != nil {
+		return x.Child
+	}
+	return nil
+}
+
+// RewriteExclusion removes the users of exclude from the users of base
+type RewriteExclusion struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Base          *Rewrite               `protobuf:"bytes,1,opt,name=base,proto3" json:"base,omitempty"`
+	Exclude       *Rewrite               `protobuf:"bytes,2,opt,name=exclude,proto3" json:"exclude,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *RewriteExclusion) Reset() {
+	*x = RewriteExclusion{}
+	mi := &file_types_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *RewriteExclusion) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*RewriteExclusion) ProtoMessage() {}
+
+func (x *RewriteExclusion) ProtoReflect() protoreflect.Message {
+	mi := &file_types_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use RewriteExclusion.ProtoReflect.Descriptor instead.
+func (*RewriteExclusion) Descriptor() ([]byte, []int) {
+	return file_types_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *RewriteExclusion) GetBase() *Rewrite {
+	if x != nil {
+		return x.Base
+	}
+	return nil
+}
+
+func (x *RewriteExclusion) GetExclude() *Rewrite {
+	if x != nil {
+		return x.Exclude
+	}
+	return nil
+}
+
 // UserSet represents a set of users that can be computed
 type UserSet struct {
 	state protoimpl.MessageState `protogen:"open.v1"`
@@ -278,7 +763,7 @@ type UserSet struct {
 
 func (x *UserSet) Reset() {
 	*x = UserSet{}
-	mi := &file_types_proto_msgTypes[3]
+	mi := &file_types_proto_msgTypes[10]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -290,7 +775,7 @@ func (x *UserSet) String() string {
 func (*UserSet) ProtoMessage() {}
 
 func (x *UserSet) ProtoReflect() protoreflect.Message {
-	mi := &file_types_proto_msgTypes[3]
+	mi := &file_types_proto_msgTypes[10]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -303,7 +788,7 @@ func (x *UserSet) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UserSet.ProtoReflect.Descriptor instead.
 func (*UserSet) Descriptor() ([]byte, []int) {
-	return file_types_proto_rawDescGZIP(), []int{3}
+	return file_types_proto_rawDescGZIP(), []int{10}
 }
 
 func (x *UserSet) GetUserset() isUserSet_Userset {
@@ -412,7 +897,7 @@ type ObjectRelation struct {
 
 func (x *ObjectRelation) Reset() {
 	*x = ObjectRelation{}
-	mi := &file_types_proto_msgTypes[4]
+	mi := &file_types_proto_msgTypes[11]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -424,7 +909,7 @@ func (x *ObjectRelation) String() string {
 func (*ObjectRelation) ProtoMessage() {}
 
 func (x *ObjectRelation) ProtoReflect() protoreflect.Message {
-	mi := &file_types_proto_msgTypes[4]
+	mi := &file_types_proto_msgTypes[11]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -437,7 +922,7 @@ func (x *ObjectRelation) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ObjectRelation.ProtoReflect.Descriptor instead.
 func (*ObjectRelation) Descriptor() ([]byte, []int) {
-	return file_types_proto_rawDescGZIP(), []int{4}
+	return file_types_proto_rawDescGZIP(), []int{11}
 }
 
 func (x *ObjectRelation) GetNamespace() string {
@@ -471,7 +956,7 @@ type UserSetUnion struct {
 
 func (x *UserSetUnion) Reset() {
 	*x = UserSetUnion{}
-	mi := &file_types_proto_msgTypes[5]
+	mi := &file_types_proto_msgTypes[12]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -483,7 +968,7 @@ func (x *UserSetUnion) String() string {
 func (*UserSetUnion) ProtoMessage() {}
 
 func (x *UserSetUnion) ProtoReflect() protoreflect.Message {
-	mi := &file_types_proto_msgTypes[5]
+	mi := &file_types_proto_msgTypes[12]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -496,7 +981,7 @@ func (x *UserSetUnion) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UserSetUnion.ProtoReflect.Descriptor instead.
 func (*UserSetUnion) Descriptor() ([]byte, []int) {
-	return file_types_proto_rawDescGZIP(), []int{5}
+	return file_types_proto_rawDescGZIP(), []int{12}
 }
 
 func (x *UserSetUnion) GetChildren() []*UserSet {
@@ -516,7 +1001,7 @@ type UserSetIntersection struct {
 
 func (x *UserSetIntersection) Reset() {
 	*x = UserSetIntersection{}
-	mi := &file_types_proto_msgTypes[6]
+	mi := &file_types_proto_msgTypes[13]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -528,7 +1013,7 @@ func (x *UserSetIntersection) String() string {
 func (*UserSetIntersection) ProtoMessage() {}
 
 func (x *UserSetIntersection) ProtoReflect() protoreflect.Message {
-	mi := &file_types_proto_msgTypes[6]
+	mi := &file_types_proto_msgTypes[13]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -541,7 +1026,7 @@ func (x *UserSetIntersection) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UserSetIntersection.ProtoReflect.Descriptor instead.
 func (*UserSetIntersection) Descriptor() ([]byte, []int) {
-	return file_types_proto_rawDescGZIP(), []int{6}
+	return file_types_proto_rawDescGZIP(), []int{13}
 }
 
 func (x *UserSetIntersection) GetChildren() []*UserSet {
@@ -562,7 +1047,7 @@ type UserSetExclusion struct {
 
 func (x *UserSetExclusion) Reset() {
 	*x = UserSetExclusion{}
-	mi := &file_types_proto_msgTypes[7]
+	mi := &file_types_proto_msgTypes[14]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -574,7 +1059,7 @@ func (x *UserSetExclusion) String() string {
 func (*UserSetExclusion) ProtoMessage() {}
 
 func (x *UserSetExclusion) ProtoReflect() protoreflect.Message {
-	mi := &file_types_proto_msgTypes[7]
+	mi := &file_types_proto_msgTypes[14]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -587,7 +1072,7 @@ func (x *UserSetExclusion) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use UserSetExclusion.ProtoReflect.Descriptor instead.
 func (*UserSetExclusion) Descriptor() ([]byte, []int) {
-	return file_types_proto_rawDescGZIP(), []int{7}
+	return file_types_proto_rawDescGZIP(), []int{14}
 }
 
 func (x *UserSetExclusion) GetBase() *UserSet {
@@ -618,7 +1103,7 @@ type ConsistencyToken struct {
 
 func (x *ConsistencyToken) Reset() {
 	*x = ConsistencyToken{}
-	mi := &file_types_proto_msgTypes[8]
+	mi := &file_types_proto_msgTypes[15]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -630,7 +1115,7 @@ func (x *ConsistencyToken) String() string {
 func (*ConsistencyToken) ProtoMessage() {}
 
 func (x *ConsistencyToken) ProtoReflect() protoreflect.Message {
-	mi := &file_types_proto_msgTypes[8]
+	mi := &file_types_proto_msgTypes[15]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -643,7 +1128,7 @@ func (x *ConsistencyToken) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use ConsistencyToken.ProtoReflect.Descriptor instead.
 func (*ConsistencyToken) Descriptor() ([]byte, []int) {
-	return file_types_proto_rawDescGZIP(), []int{8}
+	return file_types_proto_rawDescGZIP(), []int{15}
 }
 
 func (x *ConsistencyToken) GetToken() string {
@@ -670,14 +1155,17 @@ type Permission struct {
 	// The relation/permission name
 	Relation string `protobuf:"bytes,3,opt,name=relation,proto3" json:"relation,omitempty"`
 	// Whether this permission is allowed
-	Allowed       bool `protobuf:"varint,4,opt,name=allowed,proto3" json:"allowed,omitempty"`
+	Allowed bool `protobuf:"varint,4,opt,name=allowed,proto3" json:"allowed,omitempty"`
+	// Whether the permission is only held through a wildcard subject, making
+	// it public to every user of the type
+	Wildcard      bool `protobuf:"varint,5,opt,name=wildcard,proto3" json:"wildcard,omitempty"`
 	unknownFields protoimpl.UnknownFields
 	sizeCache     protoimpl.SizeCache
 }
 
 func (x *Permission) Reset() {
 	*x = Permission{}
-	mi := &file_types_proto_msgTypes[9]
+	mi := &file_types_proto_msgTypes[16]
 	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 	ms.StoreMessageInfo(mi)
 }
@@ -689,7 +1177,7 @@ func (x *Permission) String() string {
 func (*Permission) ProtoMessage() {}
 
 func (x *Permission) ProtoReflect() protoreflect.Message {
-	mi := &file_types_proto_msgTypes[9]
+	mi := &file_types_proto_msgTypes[16]
 	if x != nil {
 		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
 		if ms.LoadMessageInfo() == nil {
@@ -702,7 +1190,7 @@ func (x *Permission) ProtoReflect() protoreflect.Message {
 
 // Deprecated: Use Permission.ProtoReflect.Descriptor instead.
 func (*Permission) Descriptor() ([]byte, []int) {
-	return file_types_proto_rawDescGZIP(), []int{9}
+	return file_types_proto_rawDescGZIP(), []int{16}
 }
 
 func (x *Permission) GetNamespace() string {
@@ -733,11 +1221,18 @@ func (x *Permission) GetAllowed() bool {
 	return false
 }
 
+func (x *Permission) GetWildcard() bool {
+	if x != nil {
+		return x.Wildcard
+	}
+	return false
+}
+
 var File_types_proto protoreflect.FileDescriptor
 
 const file_types_proto_rawDesc = "" +
 	"\n" +
-	"\vtypes.proto\x12\bgoacl.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\x8f\x02\n" +
+	"\vtypes.proto\x12\bgoacl.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\xe8\x02\n" +
 	"\rRelationTuple\x12\x1c\n" +
 	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x12\x1b\n" +
 	"\tobject_id\x18\x02 \x01(\tR\bobjectId\x12\x1a\n" +
@@ -747,18 +1242,45 @@ const file_types_proto_rawDesc = "" +
 	"\n" +
 	"created_at\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
 	"\n" +
-	"updated_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"\xd3\x01\n" +
+	"updated_at\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12\x1c\n" +
+	"\tcondition\x18\b \x01(\tR\tcondition\x129\n" +
+	"\n" +
+	"expires_at\x18\t \x01(\v2\x1a.google.protobuf.TimestampR\texpiresAt\"\xed\x01\n" +
 	"\x0fNamespaceConfig\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x126\n" +
 	"\trelations\x18\x02 \x03(\v2\x18.goacl.v1.RelationConfigR\trelations\x129\n" +
 	"\n" +
 	"created_at\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x129\n" +
 	"\n" +
-	"updated_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\"k\n" +
+	"updated_at\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tupdatedAt\x12\x18\n" +
+	"\aversion\x18\x05 \x01(\x03R\aversion\"\xbf\x01\n" +
 	"\x0eRelationConfig\x12\x12\n" +
 	"\x04name\x18\x01 \x01(\tR\x04name\x12#\n" +
 	"\rrewrite_rules\x18\x02 \x01(\tR\frewriteRules\x12 \n" +
-	"\vdescription\x18\x03 \x01(\tR\vdescription\"\xa5\x02\n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\x12%\n" +
+	"\x0eallow_wildcard\x18\x04 \x01(\bR\rallowWildcard\x12+\n" +
+	"\arewrite\x18\x05 \x01(\v2\x11.goacl.v1.RewriteR\arewrite\"\x95\x03\n" +
+	"\aRewrite\x12+\n" +
+	"\x04this\x18\x01 \x01(\v2\x15.goacl.v1.RewriteThisH\x00R\x04this\x12M\n" +
+	"\x10computed_userset\x18\x02 \x01(\v2 .goacl.v1.RewriteComputedUsersetH\x00R\x0fcomputedUserset\x12K\n" +
+	"\x10tuple_to_userset\x18\x03 \x01(\v2\x1f.goacl.v1.RewriteTupleToUsersetH\x00R\x0etupleToUserset\x125\n" +
+	"\x05union\x18\x04 \x01(\v2\x1d.goacl.v1.RewriteSetOperationH\x00R\x05union\x12C\n" +
+	"\fintersection\x18\x05 \x01(\v2\x1d.goacl.v1.RewriteSetOperationH\x00R\fintersection\x12:\n" +
+	"\texclusion\x18\x06 \x01(\v2\x1a.goacl.v1.RewriteExclusionH\x00R\texclusionB\t\n" +
+	"\arewrite\"\r\n" +
+	"\vRewriteThis\"4\n" +
+	"\x16RewriteComputedUserset\x12\x1a\n" +
+	"\brelation\x18\x01 \x01(\tR\brelation\"-\n" +
+	"\x0fRewriteTupleset\x12\x1a\n" +
+	"\brelation\x18\x01 \x01(\tR\brelation\"\x9b\x01\n" +
+	"\x15RewriteTupleToUserset\x125\n" +
+	"\btupleset\x18\x01 \x01(\v2\x19.goacl.v1.RewriteTuplesetR\btupleset\x12K\n" +
+	"\x10computed_userset\x18\x02 \x01(\v2 .goacl.v1.RewriteComputedUsersetR\x0fcomputedUserset\">\n" +
+	"\x13RewriteSetOperation\x12'\n" +
+	"\x05child\x18\x01 \x03(\v2\x11.goacl.v1.RewriteR\x05child\"f\n" +
+	"\x10RewriteExclusion\x12%\n" +
+	"\x04base\x18\x01 \x01(\v2\x11.goacl.v1.RewriteR\x04base\x12+\n" +
+	"\aexclude\x18\x02 \x01(\v2\x11.goacl.v1.RewriteR\aexclude\"\xa5\x02\n" +
 	"\aUserSet\x12\x19\n" +
 	"\auser_id\x18\x01 \x01(\tH\x00R\x06userId\x12C\n" +
 	"\x0fobject_relation\x18\x02 \x01(\v2\x18.goacl.v1.ObjectRelationH\x00R\x0eobjectRelation\x12.\n" +
@@ -779,13 +1301,14 @@ const file_types_proto_rawDesc = "" +
 	"\aexclude\x18\x02 \x01(\v2\x11.goacl.v1.UserSetR\aexclude\"a\n" +
 	"\x10ConsistencyToken\x12\x14\n" +
 	"\x05token\x18\x01 \x01(\tR\x05token\x127\n" +
-	"\tissued_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\bissuedAt\"}\n" +
+	"\tissued_at\x18\x02 \x01(\v2\x1a.google.protobuf.TimestampR\bissuedAt\"\x99\x01\n" +
 	"\n" +
 	"Permission\x12\x1c\n" +
 	"\tnamespace\x18\x01 \x01(\tR\tnamespace\x12\x1b\n" +
 	"\tobject_id\x18\x02 \x01(\tR\bobjectId\x12\x1a\n" +
 	"\brelation\x18\x03 \x01(\tR\brelation\x12\x18\n" +
-	"\aallowed\x18\x04 \x01(\bR\aallowedB|\n" +
+	"\aallowed\x18\x04 \x01(\bR\aallowed\x12\x1a\n" +
+	"\bwildcard\x18\x05 \x01(\bR\bwildcardB|\n" +
 	"\fcom.goacl.v1B\n" +
 	"TypesProtoP\x01Z\x1fgithub.com/DangVTNhan/goacl/api\xa2\x02\x03GXX\xaa\x02\bGoacl.V1\xca\x02\bGoacl\\V1\xe2\x02\x14Goacl\\V1\\GPBMetadata\xea\x02\tGoacl::V1b\x06proto3"
 
@@ -801,40 +1324,60 @@ func file_types_proto_rawDescGZIP() []byte {
 	return file_types_proto_rawDescData
 }
 
-var file_types_proto_msgTypes = make([]protoimpl.MessageInfo, 10)
+var file_types_proto_msgTypes = make([]protoimpl.MessageInfo, 17)
 var file_types_proto_goTypes = []any{
-	(*RelationTuple)(nil),         // 0: goacl.v1.RelationTuple
-	(*NamespaceConfig)(nil),       // 1: goacl.v1.NamespaceConfig
-	(*RelationConfig)(nil),        // 2: goacl.v1.RelationConfig
-	(*UserSet)(nil),               // 3: goacl.v1.UserSet
-	(*ObjectRelation)(nil),        // 4: goacl.v1.ObjectRelation
-	(*UserSetUnion)(nil),          // 5: goacl.v1.UserSetUnion
-	(*UserSetIntersection)(nil),   // 6: goacl.v1.UserSetIntersection
-	(*UserSetExclusion)(nil),      // 7: goacl.v1.UserSetExclusion
-	(*ConsistencyToken)(nil),      // 8: goacl.v1.ConsistencyToken
-	(*Permission)(nil),            // 9: goacl.v1.Permission
-	(*timestamppb.Timestamp)(nil), // 10: google.protobuf.Timestamp
+	(*RelationTuple)(nil),          // 0: goacl.v1.RelationTuple
+	(*NamespaceConfig)(nil),        // 1: goacl.v1.NamespaceConfig
+	(*RelationConfig)(nil),         // 2: goacl.v1.RelationConfig
+	(*Rewrite)(nil),                // 3: goacl.v1.Rewrite
+	(*RewriteThis)(nil),            // 4: goacl.v1.RewriteThis
+	(*RewriteComputedUserset)(nil), // 5: goacl.v1.RewriteComputedUserset
+	(*RewriteTupleset)(nil),        // 6: goacl.v1.RewriteTupleset
+	(*RewriteTupleToUserset)(nil),  // 7: goacl.v1.RewriteTupleToUserset
+	(*RewriteSetOperation)(nil),    // 8: goacl.v1.RewriteSetOperation
+	(*RewriteExclusion)(nil),       // 9: goacl.v1.RewriteExclusion
+	(*UserSet)(nil),                // 10: goacl.v1.UserSet
+	(*ObjectRelation)(nil),         // 11: goacl.v1.ObjectRelation
+	(*UserSetUnion)(nil),           // 12: goacl.v1.UserSetUnion
+	(*UserSetIntersection)(nil),    // 13: goacl.v1.UserSetIntersection
+	(*UserSetExclusion)(nil),       // 14: goacl.v1.UserSetExclusion
+	(*ConsistencyToken)(nil),       // 15: goacl.v1.ConsistencyToken
+	(*Permission)(nil),             // 16: goacl.v1.Permission
+	(*timestamppb.Timestamp)(nil),  // 17: google.protobuf.Timestamp
 }
 var file_types_proto_depIdxs = []int32{
-	10, // 0: goacl.v1.RelationTuple.created_at:type_name -> google.protobuf.Timestamp
-	10, // 1: goacl.v1.RelationTuple.updated_at:type_name -> google.protobuf.Timestamp
-	2,  // 2: goacl.v1.NamespaceConfig.relations:type_name -> goacl.v1.RelationConfig
-	10, // 3: goacl.v1.NamespaceConfig.created_at:type_name -> google.protobuf.Timestamp
-	10, // 4: goacl.v1.NamespaceConfig.updated_at:type_name -> google.protobuf.Timestamp
-	4,  // 5: goacl.v1.UserSet.object_relation:type_name -> goacl.v1.ObjectRelation
-	5,  // 6: goacl.v1.UserSet.union:type_name -> goacl.v1.UserSetUnion
-	6,  // 7: goacl.v1.UserSet.intersection:type_name -> goacl.v1.UserSetIntersection
-	7,  // 8: goacl.v1.UserSet.exclusion:type_name -> goacl.v1.UserSetExclusion
-	3,  // 9: goacl.v1.UserSetUnion.children:type_name -> goacl.v1.UserSet
-	3,  // 10: goacl.v1.UserSetIntersection.children:type_name -> goacl.v1.UserSet
-	3,  // 11: goacl.v1.UserSetExclusion.base:type_name -> goacl.v1.UserSet
-	3,  // 12: goacl.v1.UserSetExclusion.exclude:type_name -> goacl.v1.UserSet
-	10, // 13: goacl.v1.ConsistencyToken.issued_at:type_name -> google.protobuf.Timestamp
-	14, // [14:14] is the sub-list for method output_type
-	14, // [14:14] is the sub-list for method input_type
-	14, // [14:14] is the sub-list for extension type_name
-	14, // [14:14] is the sub-list for extension extendee
-	0,  // [0:14] is the sub-list for field type_name
+	17, // 0: goacl.v1.RelationTuple.created_at:type_name -> google.protobuf.Timestamp
+	17, // 1: goacl.v1.RelationTuple.updated_at:type_name -> google.protobuf.Timestamp
+	17, // 2: goacl.v1.RelationTuple.expires_at:type_name -> google.protobuf.Timestamp
+	2,  // 3: goacl.v1.NamespaceConfig.relations:type_name -> goacl.v1.RelationConfig
+	17, // 4: goacl.v1.NamespaceConfig.created_at:type_name -> google.protobuf.Timestamp
+	17, // 5: goacl.v1.NamespaceConfig.updated_at:type_name -> google.protobuf.Timestamp
+	3,  // 6: goacl.v1.RelationConfig.rewrite:type_name -> goacl.v1.Rewrite
+	4,  // 7: goacl.v1.Rewrite.this:type_name -> goacl.v1.RewriteThis
+	5,  // 8: goacl.v1.Rewrite.computed_userset:type_name -> goacl.v1.RewriteComputedUserset
+	7,  // 9: goacl.v1.Rewrite.tuple_to_userset:type_name -> goacl.v1.RewriteTupleToUserset
+	8,  // 10: goacl.v1.Rewrite.union:type_name -> goacl.v1.RewriteSetOperation
+	8,  // 11: goacl.v1.Rewrite.intersection:type_name -> goacl.v1.RewriteSetOperation
+	9,  // 12: goacl.v1.Rewrite.exclusion:type_name -> goacl.v1.RewriteExclusion
+	6,  // 13: goacl.v1.RewriteTupleToUserset.tupleset:type_name -> goacl.v1.RewriteTupleset
+	5,  // 14: goacl.v1.RewriteTupleToUserset.computed_userset:type_name -> goacl.v1.RewriteComputedUserset
+	3,  // 15: goacl.v1.RewriteSetOperation.child:type_name -> goacl.v1.Rewrite
+	3,  // 16: goacl.v1.RewriteExclusion.base:type_name -> goacl.v1.Rewrite
+	3,  // 17: goacl.v1.RewriteExclusion.exclude:type_name -> goacl.v1.Rewrite
+	11, // 18: goacl.v1.UserSet.object_relation:type_name -> goacl.v1.ObjectRelation
+	12, // 19: goacl.v1.UserSet.union:type_name -> goacl.v1.UserSetUnion
+	13, // 20: goacl.v1.UserSet.intersection:type_name -> goacl.v1.UserSetIntersection
+	14, // 21: goacl.v1.UserSet.exclusion:type_name -> goacl.v1.UserSetExclusion
+	10, // 22: goacl.v1.UserSetUnion.children:type_name -> goacl.v1.UserSet
+	10, // 23: goacl.v1.UserSetIntersection.children:type_name -> goacl.v1.UserSet
+	10, // 24: goacl.v1.UserSetExclusion.base:type_name -> goacl.v1.UserSet
+	10, // 25: goacl.v1.UserSetExclusion.exclude:type_name -> goacl.v1.UserSet
+	17, // 26: goacl.v1.ConsistencyToken.issued_at:type_name -> google.protobuf.Timestamp
+	27, // [27:27] is the sub-list for method output_type
+	27, // [27:27] is the sub-list for method input_type
+	27, // [27:27] is the sub-list for extension type_name
+	27, // [27:27] is the sub-list for extension extendee
+	0,  // [0:27] is the sub-list for field type_name
 }
 
 func init() { file_types_proto_init() }
@@ -843,6 +1386,14 @@ func file_types_proto_init() {
 		return
 	}
 	file_types_proto_msgTypes[3].OneofWrappers = []any{
+		(*Rewrite_This)(nil),
+		(*Rewrite_ComputedUserset)(nil),
+		(*Rewrite_TupleToUserset)(nil),
+		(*Rewrite_Union)(nil),
+		(*Rewrite_Intersection)(nil),
+		(*Rewrite_Exclusion)(nil),
+	}
+	file_types_proto_msgTypes[10].OneofWrappers = []any{
 		(*UserSet_UserId)(nil),
 		(*UserSet_ObjectRelation)(nil),
 		(*UserSet_Union)(nil),
@@ -855,7 +1406,7 @@ func file_types_proto_init() {
 			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
 			RawDescriptor: unsafe.Slice(unsafe.StringData(file_types_proto_rawDesc), len(file_types_proto_rawDesc)),
 			NumEnums:      0,
-			NumMessages:   10,
+			NumMessages:   17,
 			NumExtensions: 0,
 			NumServices:   0,
 		},
@@ -2,6 +2,9 @@ package app
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"io/fs"
 	"log"
 	"os"
 	"os/signal"
@@ -10,7 +13,9 @@ import (
 
 	"github.com/DangVTNhan/goacl/internal/config"
 	"github.com/DangVTNhan/goacl/internal/database"
+	"github.com/DangVTNhan/goacl/internal/schema"
 	"github.com/DangVTNhan/goacl/internal/server"
+	"github.com/DangVTNhan/goacl/internal/service"
 )
 
 // App represents the application
@@ -52,6 +57,12 @@ func (a *App) Run() error {
 		return err
 	}
 
+	// Apply the namespace definitions
+	if err := a.bootstrapNamespaces(initCtx); err != nil {
+		a.db.Close()
+		return err
+	}
+
 	// Perform health check
 	if err := a.db.HealthCheck(ctx); err != nil {
 		a.db.Close()
@@ -73,6 +84,14 @@ func (a *App) Run() error {
 		return err
 	}
 
+	// Delete expired relation tuples in the background
+	reaper := service.NewTupleReaper(a.db, a.config.Authorization.ReapInterval)
+	go reaper.Run(ctx)
+
+	// Relay tuple changes their writers failed to relay
+	relay := service.NewChangeRelay(a.db, a.config.Authorization.RelayInterval)
+	go relay.Run(ctx)
+
 	log.Println("Application started. Press Ctrl+C to gracefully shutdown...")
 
 	// Wait for interrupt signal
@@ -101,3 +120,42 @@ func (a *App) Run() error {
 	log.Println("Application stopped")
 	return nil
 }
+
+// bootstrapNamespaces reconciles the stored namespace configurations with
+// the definitions in the namespace directory
+// A missing default directory leaves the stored namespaces as they are,
+// while a missing directory that was configured explicitly fails startup
+func (a *App) bootstrapNamespaces(ctx context.Context) error {
+	cfg := a.config.Namespaces
+	if cfg.Dir == "" {
+		return nil
+	}
+
+	declared, err := schema.LoadDir(cfg.Dir)
+	if errors.Is(err, fs.ErrNotExist) && !cfg.Required {
+		log.Printf("Warning: namespace directory %s does not exist, skipping namespace bootstrap", cfg.Dir)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Reconciling %d namespaces from %s...", len(declared), cfg.Dir)
+	plan, err := service.NewConfigurationService(a.db).Reconcile(ctx, declared, service.ReconcileOptions{
+		Prune:                cfg.Prune,
+		DryRun:               cfg.DryRun,
+		AllowBreakingChanges: cfg.AllowBreakingChanges,
+		Author:               "bootstrap",
+	})
+	if plan != nil {
+		log.Printf("Namespace changes:\n%s", plan)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to bootstrap namespaces: %w", err)
+	}
+
+	if cfg.DryRun {
+		log.Println("Namespace dry run enabled, no changes applied")
+	}
+	return nil
+}
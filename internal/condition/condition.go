@@ -0,0 +1,335 @@
+// Package condition parses and evaluates the condition expressions that can
+// be attached to relation tuples
+//
+// An expression compares values taken from the check request context with
+// literals, for example:
+//
+//	request.ip in 10.0.0.0/8
+//	now < 2026-12-31 && request.region in ["eu", "us"]
+//
+// Identifiers refer to context keys, with an optional "request." prefix, and
+// "now" refers to the time of the check. Text literals must be quoted; dates,
+// numbers and CIDR ranges may be written bare.
+package condition
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Outcome is the three-valued result of evaluating an expression
+type Outcome int
+
+const (
+	// False means the condition does not hold
+	False Outcome = iota
+
+	// True means the condition holds
+	True
+
+	// Unknown means the context lacks a value the condition depends on
+	Unknown
+)
+
+// Expression is a parsed condition expression
+type Expression struct {
+	source string
+	root   node
+}
+
+// Parse parses a condition expression
+func Parse(source string) (*Expression, error) {
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	root, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, fmt.Errorf("unexpected %q at offset %d", tok.text, tok.pos)
+	}
+
+	return &Expression{source: source, root: root}, nil
+}
+
+// String returns the source text of the expression
+func (e *Expression) String() string {
+	return e.source
+}
+
+// Evaluate evaluates the expression against the request context
+// When the outcome is Unknown the missing context keys are returned in sorted order
+func (e *Expression) Evaluate(context map[string]string, now time.Time) (Outcome, []string, error) {
+	env := &environment{context: context, now: now, missing: make(map[string]bool)}
+
+	outcome, err := e.root.eval(env)
+	if err != nil {
+		return False, nil, err
+	}
+
+	if outcome != Unknown {
+		return outcome, nil, nil
+	}
+
+	missing := make([]string, 0, len(env.missing))
+	for key := range env.missing {
+		missing = append(missing, key)
+	}
+	sort.Strings(missing)
+
+	return Unknown, missing, nil
+}
+
+// environment holds the values available while evaluating an expression
+type environment struct {
+	context map[string]string
+	now     time.Time
+	missing map[string]bool
+}
+
+// node is an element of the expression tree
+type node interface {
+	eval(env *environment) (Outcome, error)
+}
+
+// andNode holds when both sides hold
+type andNode struct{ left, right node }
+
+func (n *andNode) eval(env *environment) (Outcome, error) {
+	left, err := n.left.eval(env)
+	if err != nil || left == False {
+		return False, err
+	}
+	right, err := n.right.eval(env)
+	if err != nil || right == False {
+		return False, err
+	}
+	if left == Unknown || right == Unknown {
+		return Unknown, nil
+	}
+	return True, nil
+}
+
+// orNode holds when either side holds
+type orNode struct{ left, right node }
+
+func (n *orNode) eval(env *environment) (Outcome, error) {
+	left, err := n.left.eval(env)
+	if err != nil {
+		return False, err
+	}
+	if left == True {
+		return True, nil
+	}
+	right, err := n.right.eval(env)
+	if err != nil {
+		return False, err
+	}
+	if right == True {
+		return True, nil
+	}
+	if left == Unknown || right == Unknown {
+		return Unknown, nil
+	}
+	return False, nil
+}
+
+// notNode negates its operand
+type notNode struct{ operand node }
+
+func (n *notNode) eval(env *environment) (Outcome, error) {
+	outcome, err := n.operand.eval(env)
+	if err != nil {
+		return False, err
+	}
+	switch outcome {
+	case True:
+		return False, nil
+	case False:
+		return True, nil
+	}
+	return Unknown, nil
+}
+
+// literalNode is a constant boolean
+type literalNode struct{ value bool }
+
+func (n *literalNode) eval(*environment) (Outcome, error) {
+	if n.value {
+		return True, nil
+	}
+	return False, nil
+}
+
+// compareNode compares two operands
+type compareNode struct {
+	op          string
+	left, right operand
+}
+
+func (n *compareNode) eval(env *environment) (Outcome, error) {
+	left, ok := n.left.resolve(env)
+	if !ok {
+		return Unknown, nil
+	}
+
+	if n.op == "in" {
+		return n.evalIn(env, left)
+	}
+
+	right, ok := n.right.resolve(env)
+	if !ok {
+		return Unknown, nil
+	}
+
+	cmp, err := compare(left, right)
+	if err != nil {
+		if n.op == "==" || n.op == "!=" {
+			cmp = strings.Compare(left, right)
+		} else {
+			return False, fmt.Errorf("cannot compare %q %s %q: %w", left, n.op, right, err)
+		}
+	}
+
+	var holds bool
+	switch n.op {
+	case "==":
+		holds = cmp == 0
+	case "!=":
+		holds = cmp != 0
+	case "<":
+		holds = cmp < 0
+	case "<=":
+		holds = cmp <= 0
+	case ">":
+		holds = cmp > 0
+	case ">=":
+		holds = cmp >= 0
+	}
+
+	if holds {
+		return True, nil
+	}
+	return False, nil
+}
+
+// evalIn checks membership of value in a list or an IP in a CIDR range
+func (n *compareNode) evalIn(env *environment, value string) (Outcome, error) {
+	if list, ok := n.right.(*listOperand); ok {
+		for _, item := range list.items {
+			if item == value || cidrContains(item, value) {
+				return True, nil
+			}
+		}
+		return False, nil
+	}
+
+	right, ok := n.right.resolve(env)
+	if !ok {
+		return Unknown, nil
+	}
+
+	_, network, err := net.ParseCIDR(right)
+	if err != nil {
+		return False, fmt.Errorf("right side of 'in' must be a list or CIDR range, got %q", right)
+	}
+
+	ip := net.ParseIP(value)
+	if ip == nil {
+		return False, fmt.Errorf("left side of 'in' must be an IP address, got %q", value)
+	}
+
+	if network.Contains(ip) {
+		return True, nil
+	}
+	return False, nil
+}
+
+// cidrContains reports whether cidr is a CIDR range containing the IP value
+func cidrContains(cidr, value string) bool {
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return false
+	}
+	ip := net.ParseIP(value)
+	return ip != nil && network.Contains(ip)
+}
+
+// compare orders two values as times or numbers
+func compare(left, right string) (int, error) {
+	if lt, err := parseTime(left); err == nil {
+		if rt, err := parseTime(right); err == nil {
+			return lt.Compare(rt), nil
+		}
+	}
+
+	if lf, err := strconv.ParseFloat(left, 64); err == nil {
+		if rf, err := strconv.ParseFloat(right, 64); err == nil {
+			switch {
+			case lf < rf:
+				return -1, nil
+			case lf > rf:
+				return 1, nil
+			}
+			return 0, nil
+		}
+	}
+
+	return 0, fmt.Errorf("values are neither both times nor both numbers")
+}
+
+// parseTime parses an RFC 3339 timestamp or a plain date
+func parseTime(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339Nano, value); err == nil {
+		return t, nil
+	}
+	return time.Parse(time.DateOnly, value)
+}
+
+// operand is a value referenced by a comparison
+type operand interface {
+	resolve(env *environment) (string, bool)
+}
+
+// identOperand reads a value from the request context
+type identOperand struct{ name string }
+
+func (o *identOperand) resolve(env *environment) (string, bool) {
+	if o.name == "now" {
+		return env.now.UTC().Format(time.RFC3339Nano), true
+	}
+
+	key := strings.TrimPrefix(o.name, "request.")
+	if value, ok := env.context[key]; ok {
+		return value, true
+	}
+	if value, ok := env.context[o.name]; ok {
+		return value, true
+	}
+
+	env.missing[key] = true
+	return "", false
+}
+
+// literalOperand is a constant value
+type literalOperand struct{ value string }
+
+func (o *literalOperand) resolve(*environment) (string, bool) {
+	return o.value, true
+}
+
+// listOperand is a list of constant values, only valid on the right of 'in'
+type listOperand struct{ items []string }
+
+func (o *listOperand) resolve(*environment) (string, bool) {
+	return strings.Join(o.items, ","), true
+}
@@ -0,0 +1,74 @@
+package condition
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEvaluate(t *testing.T) {
+	now := time.Date(2026, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		expr    string
+		context map[string]string
+		outcome Outcome
+		missing []string
+	}{
+		{`request.ip in 10.0.0.0/8`, map[string]string{"ip": "10.1.2.3"}, True, nil},
+		{`request.ip in 10.0.0.0/8`, map[string]string{"ip": "192.168.0.1"}, False, nil},
+		{`request.ip in 10.0.0.0/8`, nil, Unknown, []string{"ip"}},
+		{`now < 2026-12-31`, nil, True, nil},
+		{`now >= 2026-12-31T00:00:00Z`, nil, False, nil},
+		{`request.region in ["eu", "us"]`, map[string]string{"region": "eu"}, True, nil},
+		{`region == "eu" && request.level >= 3`, map[string]string{"region": "eu", "level": "10"}, True, nil},
+		{`region == "eu" and level >= 3`, map[string]string{"region": "us"}, False, nil},
+		{`region == "eu" || level >= 3`, map[string]string{"region": "us"}, Unknown, []string{"level"}},
+		{`!(request.ip in 10.0.0.0/8) || now > 2030-01-01`, nil, Unknown, []string{"ip"}},
+		{`true && !false`, nil, True, nil},
+	}
+
+	for _, tt := range tests {
+		expr, err := Parse(tt.expr)
+		if err != nil {
+			t.Fatalf("Parse(%q) returned error: %v", tt.expr, err)
+		}
+
+		outcome, missing, err := expr.Evaluate(tt.context, now)
+		if err != nil {
+			t.Fatalf("Evaluate(%q) returned error: %v", tt.expr, err)
+		}
+		if outcome != tt.outcome {
+			t.Errorf("Evaluate(%q): expected outcome %v, got %v", tt.expr, tt.outcome, outcome)
+		}
+		if !reflect.DeepEqual(missing, tt.missing) {
+			t.Errorf("Evaluate(%q): expected missing %v, got %v", tt.expr, tt.missing, missing)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{
+		``,
+		`request.ip in`,
+		`request.ip = 1`,
+		`(now < 2026-12-31`,
+		`"unterminated == x`,
+		`ip == [1, 2]`,
+		`now < 2026-12-31 extra`,
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q): expected error", expr)
+		}
+	}
+}
+
+func TestEvaluateTypeErrors(t *testing.T) {
+	expr, err := Parse(`request.ip in 10.0.0.0/8`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := expr.Evaluate(map[string]string{"ip": "not-an-ip"}, time.Now()); err == nil {
+		t.Error("Expected error for an invalid IP address")
+	}
+}
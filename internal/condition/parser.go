@@ -0,0 +1,281 @@
+package condition
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// tokenKind classifies lexical tokens
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenLiteral
+	tokenString
+	tokenOperator
+	tokenLParen
+	tokenRParen
+	tokenLBracket
+	tokenRBracket
+	tokenComma
+)
+
+// token is a lexical token with its offset in the source
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// keywords maps word operators to their symbolic form
+var keywords = map[string]string{
+	"and": "&&",
+	"or":  "||",
+	"not": "!",
+	"in":  "in",
+}
+
+// lex splits an expression into tokens
+func lex(source string) ([]token, error) {
+	var tokens []token
+	i := 0
+
+	for i < len(source) {
+		c := source[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+
+		case c == '(':
+			tokens = append(tokens, token{tokenLParen, "(", i})
+			i++
+		case c == ')':
+			tokens = append(tokens, token{tokenRParen, ")", i})
+			i++
+		case c == '[':
+			tokens = append(tokens, token{tokenLBracket, "[", i})
+			i++
+		case c == ']':
+			tokens = append(tokens, token{tokenRBracket, "]", i})
+			i++
+		case c == ',':
+			tokens = append(tokens, token{tokenComma, ",", i})
+			i++
+
+		case c == '"' || c == '\'':
+			end := strings.IndexByte(source[i+1:], c)
+			if end < 0 {
+				return nil, fmt.Errorf("unterminated string at offset %d", i)
+			}
+			tokens = append(tokens, token{tokenString, source[i+1 : i+1+end], i})
+			i += end + 2
+
+		case strings.ContainsRune("=!<>&|", rune(c)):
+			op := string(c)
+			if i+1 < len(source) {
+				two := source[i : i+2]
+				switch two {
+				case "==", "!=", "<=", ">=", "&&", "||":
+					op = two
+				}
+			}
+			if op == "=" || op == "&" || op == "|" {
+				return nil, fmt.Errorf("unexpected %q at offset %d", op, i)
+			}
+			tokens = append(tokens, token{tokenOperator, op, i})
+			i += len(op)
+
+		default:
+			start := i
+			for i < len(source) && !isDelimiter(source[i]) {
+				i++
+			}
+			word := source[start:i]
+			switch {
+			case keywords[strings.ToLower(word)] != "":
+				tokens = append(tokens, token{tokenOperator, keywords[strings.ToLower(word)], start})
+			case unicode.IsLetter(rune(word[0])) || word[0] == '_':
+				if !isIdentifier(word) {
+					return nil, fmt.Errorf("invalid identifier %q at offset %d", word, start)
+				}
+				tokens = append(tokens, token{tokenIdent, word, start})
+			default:
+				tokens = append(tokens, token{tokenLiteral, word, start})
+			}
+		}
+	}
+
+	return append(tokens, token{tokenEOF, "", len(source)}), nil
+}
+
+// isDelimiter reports whether c ends a bare word
+func isDelimiter(c byte) bool {
+	return strings.IndexByte(" \t\r\n()[],\"'=!<>&|", c) >= 0
+}
+
+// isIdentifier reports whether word is a dotted identifier
+func isIdentifier(word string) bool {
+	for _, r := range word {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_' && r != '.' {
+			return false
+		}
+	}
+	return true
+}
+
+// parser is a recursive descent parser over a token stream
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// parseOr parses: and ('||' and)*
+func (p *parser) parseOr() (node, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == tokenOperator && p.peek().text == "||" {
+		p.next()
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = &orNode{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+// parseAnd parses: unary ('&&' unary)*
+func (p *parser) parseAnd() (node, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+
+	for p.peek().kind == tokenOperator && p.peek().text == "&&" {
+		p.next()
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = &andNode{left: left, right: right}
+	}
+
+	return left, nil
+}
+
+// parseUnary parses: '!' unary | '(' or ')' | true | false | comparison
+func (p *parser) parseUnary() (node, error) {
+	tok := p.peek()
+
+	switch {
+	case tok.kind == tokenOperator && tok.text == "!":
+		p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &notNode{operand: operand}, nil
+
+	case tok.kind == tokenLParen:
+		p.next()
+		inner, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, fmt.Errorf("expected ')' at offset %d", closing.pos)
+		}
+		return inner, nil
+
+	case tok.kind == tokenIdent && (tok.text == "true" || tok.text == "false"):
+		p.next()
+		return &literalNode{value: tok.text == "true"}, nil
+	}
+
+	return p.parseComparison()
+}
+
+// parseComparison parses: operand op operand
+func (p *parser) parseComparison() (node, error) {
+	left, err := p.parseOperand(false)
+	if err != nil {
+		return nil, err
+	}
+
+	op := p.next()
+	if op.kind != tokenOperator || op.text == "&&" || op.text == "||" || op.text == "!" {
+		return nil, fmt.Errorf("expected comparison operator at offset %d", op.pos)
+	}
+
+	right, err := p.parseOperand(op.text == "in")
+	if err != nil {
+		return nil, err
+	}
+
+	return &compareNode{op: op.text, left: left, right: right}, nil
+}
+
+// parseOperand parses an identifier, literal or, when allowed, a list
+func (p *parser) parseOperand(allowList bool) (operand, error) {
+	tok := p.next()
+
+	switch tok.kind {
+	case tokenIdent:
+		return &identOperand{name: tok.text}, nil
+	case tokenLiteral, tokenString:
+		return &literalOperand{value: tok.text}, nil
+	case tokenLBracket:
+		if !allowList {
+			return nil, fmt.Errorf("list is only allowed after 'in' at offset %d", tok.pos)
+		}
+		return p.parseList()
+	}
+
+	if tok.kind == tokenEOF {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	return nil, fmt.Errorf("unexpected %q at offset %d", tok.text, tok.pos)
+}
+
+// parseList parses the items of a list literal after its opening bracket
+func (p *parser) parseList() (operand, error) {
+	list := &listOperand{}
+
+	for {
+		tok := p.next()
+		switch tok.kind {
+		case tokenRBracket:
+			return list, nil
+		case tokenLiteral, tokenString:
+			list.items = append(list.items, tok.text)
+		default:
+			return nil, fmt.Errorf("expected list item at offset %d", tok.pos)
+		}
+
+		switch sep := p.next(); sep.kind {
+		case tokenComma:
+		case tokenRBracket:
+			return list, nil
+		default:
+			return nil, fmt.Errorf("expected ',' or ']' at offset %d", sep.pos)
+		}
+	}
+}
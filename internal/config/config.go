@@ -3,6 +3,7 @@ package config
 import (
 	"log"
 	"os"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"time"
@@ -14,10 +15,12 @@ import (
 
 // Config holds all configuration for the application
 type Config struct {
-	GRPC   GRPCConfig
-	HTTP   HTTPConfig
-	Dgraph *dgraph.Config
-	Redis  *redis.Config
+	GRPC          GRPCConfig
+	HTTP          HTTPConfig
+	Authorization AuthorizationConfig
+	Namespaces    NamespacesConfig
+	Dgraph        *dgraph.Config
+	Redis         *redis.Config
 }
 
 // GRPCConfig holds gRPC server configuration
@@ -30,6 +33,42 @@ type HTTPConfig struct {
 	Port string
 }
 
+// AuthorizationConfig holds permission resolution configuration
+type AuthorizationConfig struct {
+	// MaxDepth is the longest chain of object relations a check may follow
+	MaxDepth int
+
+	// ReapInterval is how often expired relation tuples are deleted
+	ReapInterval time.Duration
+
+	// RelayInterval is how often tuple changes left unrelayed by their
+	// writers are moved to the change stream
+	RelayInterval time.Duration
+
+	// Debug includes resolution traces in every check response
+	Debug bool
+}
+
+// NamespacesConfig holds namespace bootstrap configuration
+type NamespacesConfig struct {
+	// Dir holds the namespace definition files applied at startup; empty
+	// disables the bootstrap
+	Dir string
+
+	// Required fails startup when Dir does not exist, as it is set when
+	// NAMESPACE_DIR names the directory explicitly
+	Required bool
+
+	// Prune deletes stored namespaces without tuples that Dir does not define
+	Prune bool
+
+	// DryRun logs the changes the bootstrap would make without applying them
+	DryRun bool
+
+	// AllowBreakingChanges applies updates that can take access away
+	AllowBreakingChanges bool
+}
+
 // Load loads configuration from environment variables with defaults
 // It automatically loads .env files in the following order:
 // 1. .env (if exists)
@@ -39,6 +78,11 @@ func Load() *Config {
 	// Load .env files automatically
 	loadEnvFiles()
 
+	namespaceDir, namespaceDirSet := os.LookupEnv("NAMESPACE_DIR")
+	if !namespaceDirSet {
+		namespaceDir = defaultNamespaceDir()
+	}
+
 	return &Config{
 		GRPC: GRPCConfig{
 			Port: getEnv("GRPC_PORT", "50051"),
@@ -46,6 +90,19 @@ func Load() *Config {
 		HTTP: HTTPConfig{
 			Port: getEnv("HTTP_PORT", "8080"),
 		},
+		Authorization: AuthorizationConfig{
+			MaxDepth:      getEnvInt("CHECK_MAX_DEPTH", 50),
+			ReapInterval:  getEnvDuration("TUPLE_REAP_INTERVAL", time.Minute),
+			RelayInterval: getEnvDuration("CHANGE_RELAY_INTERVAL", 5*time.Second),
+			Debug:         getEnvBool("DEBUG", false),
+		},
+		Namespaces: NamespacesConfig{
+			Dir:                  namespaceDir,
+			Required:             namespaceDirSet,
+			Prune:                getEnvBool("NAMESPACE_PRUNE", false),
+			DryRun:               getEnvBool("NAMESPACE_DRY_RUN", false),
+			AllowBreakingChanges: getEnvBool("NAMESPACE_ALLOW_BREAKING_CHANGES", false),
+		},
 		Dgraph: loadDgraphConfig(),
 		Redis:  loadRedisConfig(),
 	}
@@ -64,6 +121,30 @@ func loadEnvFiles() {
 	}
 }
 
+// defaultNamespaceDir returns the shipped namespaces directory, looked up
+// next to the executable, in its parent directory for binaries built into
+// bin, and then in the working directory, as with go run
+// When none exists the working directory one is returned
+func defaultNamespaceDir() string {
+	var candidates []string
+	if exe, err := os.Executable(); err == nil {
+		dir := filepath.Dir(exe)
+		candidates = append(candidates, filepath.Join(dir, "namespaces"), filepath.Join(dir, "..", "namespaces"))
+	}
+	fallback := "namespaces"
+	if wd, err := os.Getwd(); err == nil {
+		fallback = filepath.Join(wd, "namespaces")
+	}
+	candidates = append(candidates, fallback)
+
+	for _, dir := range candidates {
+		if info, err := os.Stat(dir); err == nil && info.IsDir() {
+			return filepath.Clean(dir)
+		}
+	}
+	return fallback
+}
+
 // loadDgraphConfig loads Dgraph configuration from environment variables
 func loadDgraphConfig() *dgraph.Config {
 	config := dgraph.DefaultConfig()
@@ -0,0 +1,492 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/dgo/v240/protos/api"
+	goredis "github.com/redis/go-redis/v9"
+)
+
+const (
+	// changeStreamKey is the Redis stream recording every change to relation
+	// tuples
+	changeStreamKey = "tuple:changelog"
+
+	// changePositionsKey is the sorted set mapping the read timestamp of every
+	// relay pass, as its score, to the stream position that every change
+	// committed before that timestamp precedes
+	changePositionsKey = "tuple:changelog:positions"
+
+	// changeCommitKeyPrefix prefixes the keys recording the commit timestamp
+	// of a change batch until it is relayed
+	changeCommitKeyPrefix = "tuple:changelog:commit:"
+
+	// changeCommitTTL is how long the commit timestamp of a batch is kept
+	// for the relay
+	changeCommitTTL = time.Hour
+
+	// changeRelayLockKey is held by the relay appending to the change stream
+	changeRelayLockKey = "tuple:changelog:relay"
+
+	// changeRelayPendingKey is set when changes are committed, so the relay
+	// holding the lock runs another pass for them
+	changeRelayPendingKey = "tuple:changelog:pending"
+
+	// changeRelayLease is how long the relay lock is held without renewal
+	changeRelayLease = 30 * time.Second
+
+	// changeLogMaxLen approximately caps the number of changes retained
+	changeLogMaxLen = 1000000
+
+	// changeRelayBatch is the number of change batches relayed at a time
+	changeRelayBatch = 100
+)
+
+// ErrChangesExpired is returned when changes after the requested position
+// are no longer retained in the change log
+var ErrChangesExpired = errors.New("changes after the requested position are no longer retained")
+
+// ChangeType describes how a tuple changed
+type ChangeType string
+
+const (
+	ChangeCreated ChangeType = "created"
+	ChangeUpdated ChangeType = "updated"
+	ChangeDeleted ChangeType = "deleted"
+)
+
+// Change is a single change to a relation tuple recorded in the change log
+type Change struct {
+	// Position is the ID of the change in the change log
+	Position string
+
+	Type  ChangeType
+	Tuple RelationTuple
+
+	// CommitTs is the Dgraph timestamp of the commit that made the change
+	CommitTs  uint64
+	ChangedAt time.Time
+}
+
+// batchedChange is a change as stored in a change batch
+type batchedChange struct {
+	Type  ChangeType    `json:"type"`
+	Tuple RelationTuple `json:"tuple"`
+}
+
+// changeBatch is the record of the changes of one commit, written to Dgraph
+// in the committing transaction and relayed to the change stream afterwards
+type changeBatch struct {
+	UID string `json:"uid"`
+
+	// StartTs is the start timestamp of the committing transaction
+	StartTs   uint64 `json:"change_start_ts"`
+	Changes   string `json:"change_batch"`
+	CreatedAt string `json:"created_at"`
+}
+
+// stageChanges returns the mutation recording the changes of a transaction
+// that started at startTs as a change batch
+// Each transaction writes its own batch, so recording changes never makes
+// transactions conflict
+func stageChanges(startTs uint64, changes []Change) (*api.Mutation, error) {
+	batched := make([]batchedChange, len(changes))
+	for i, change := range changes {
+		batched[i] = batchedChange{Type: change.Type, Tuple: change.Tuple}
+	}
+	changesJSON, err := json.Marshal(batched)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal tuple changes: %w", err)
+	}
+
+	setJSON, err := json.Marshal(map[string]interface{}{
+		"uid":             "_:changes",
+		"dgraph.type":     "ChangeBatch",
+		"change_start_ts": startTs,
+		"change_batch":    string(changesJSON),
+		"created_at":      time.Now().UTC().Format(time.RFC3339Nano),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal change batch: %w", err)
+	}
+
+	return &api.Mutation{SetJson: setJSON}, nil
+}
+
+// publishChanges records the commit timestamp of a change batch and relays
+// it to the change stream
+// The batch is already stored in Dgraph, so a failure only delays the
+// changes until the next relay
+func (m *Manager) publishChanges(ctx context.Context, startTs, commitTs uint64) {
+	if err := m.Redis.Set(ctx, changeCommitKey(startTs), strconv.FormatUint(commitTs, 10), changeCommitTTL); err != nil {
+		log.Printf("Warning: failed to record commit of change batch %d: %v", startTs, err)
+	}
+	if _, err := m.RelayChanges(ctx); err != nil {
+		log.Printf("Warning: failed to relay change batch %d, it is retried by the next relay: %v", startTs, err)
+	}
+}
+
+// RelayChanges appends the change batches stored in Dgraph to the change
+// stream, deletes them from Dgraph and returns how many changes were appended
+// One relay appends at a time; a call made while another relay holds the
+// lock leaves its batches to that relay, which runs another pass for them
+func (m *Manager) RelayChanges(ctx context.Context) (int, error) {
+	if err := m.Redis.Set(ctx, changeRelayPendingKey, "1", changeRelayLease); err != nil {
+		return 0, err
+	}
+
+	total := 0
+	for {
+		acquired, err := m.Redis.SetNX(ctx, changeRelayLockKey, "1", changeRelayLease)
+		if err != nil || !acquired {
+			return total, err
+		}
+
+		if err := m.Redis.Del(ctx, changeRelayPendingKey); err != nil {
+			m.releaseRelay(ctx)
+			return total, err
+		}
+		relayed, err := m.relayPass(ctx)
+		total += relayed
+		m.releaseRelay(ctx)
+		if err != nil {
+			return total, err
+		}
+
+		// Changes committed during the pass may not have been visible to it
+		pending, err := m.Redis.Exists(ctx, changeRelayPendingKey)
+		if err != nil || !pending {
+			return total, err
+		}
+	}
+}
+
+// releaseRelay releases the relay lock
+func (m *Manager) releaseRelay(ctx context.Context) {
+	if err := m.Redis.Del(ctx, changeRelayLockKey); err != nil {
+		log.Printf("Warning: failed to release the change relay lock, it expires in %s: %v", changeRelayLease, err)
+	}
+}
+
+// relayPass appends every change batch visible at one read timestamp in the
+// order their transactions started, then records the stream position for
+// that timestamp
+// Two transactions changing the same tuple both commit only when one starts
+// after the other commits, so start order keeps their changes in commit
+// order; batches committed after the read are appended by a later pass
+func (m *Manager) relayPass(ctx context.Context) (int, error) {
+	var (
+		readTs uint64
+		after  uint64
+		total  int
+	)
+	for {
+		batches, ts, err := m.readChangeBatches(ctx, after, readTs)
+		if err != nil {
+			return total, err
+		}
+		readTs = ts
+		if len(batches) == 0 {
+			break
+		}
+
+		relayed, err := m.appendChangeBatches(ctx, batches, readTs)
+		total += relayed
+		if err != nil {
+			return total, err
+		}
+		if err := m.deleteChangeBatches(ctx, batches); err != nil {
+			return total, err
+		}
+		if err := m.Redis.Expire(ctx, changeRelayLockKey, changeRelayLease); err != nil {
+			return total, err
+		}
+
+		if len(batches) < changeRelayBatch {
+			break
+		}
+		after = batches[len(batches)-1].StartTs
+	}
+
+	head, err := m.ChangeLogHead(ctx)
+	if err != nil {
+		return total, err
+	}
+	pipe := m.Redis.TxPipeline()
+	pipe.ZAdd(ctx, changePositionsKey, goredis.Z{Score: float64(readTs), Member: head})
+	pipe.ZRemRangeByRank(ctx, changePositionsKey, 0, -changeLogMaxLen-1)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return total, fmt.Errorf("failed to record change log position: %w", err)
+	}
+
+	return total, nil
+}
+
+// readChangeBatches returns the oldest change batches of transactions that
+// started after the given timestamp, read at readTs or at the latest data
+// when it is zero, along with the timestamp they were read at
+func (m *Manager) readChangeBatches(ctx context.Context, after, readTs uint64) ([]changeBatch, uint64, error) {
+	query := `query changeBatches($after: int, $limit: int) {
+		batches(func: gt(change_start_ts, $after), orderasc: change_start_ts, first: $limit) @filter(type(ChangeBatch)) {
+			uid
+			change_start_ts
+			change_batch
+			created_at
+		}
+	}`
+	vars := map[string]string{
+		"$after": strconv.FormatUint(after, 10),
+		"$limit": strconv.Itoa(changeRelayBatch),
+	}
+
+	resp, err := m.queryAt(ctx, query, vars, readTs)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read change batches: %w", err)
+	}
+	if readTs == 0 {
+		readTs = resp.GetTxn().GetStartTs()
+	}
+
+	var result struct {
+		Batches []changeBatch `json:"batches"`
+	}
+	if err := json.Unmarshal(resp.Json, &result); err != nil {
+		return nil, 0, fmt.Errorf("failed to unmarshal change batches: %w", err)
+	}
+	return result.Batches, readTs, nil
+}
+
+// appendChangeBatches appends the changes of the batches to the change
+// stream in one Redis transaction and returns the number of changes appended
+// A batch whose commit timestamp was not recorded is given the read
+// timestamp, which is never earlier than its commit
+func (m *Manager) appendChangeBatches(ctx context.Context, batches []changeBatch, readTs uint64) (int, error) {
+	keys := make([]string, len(batches))
+	for i, batch := range batches {
+		keys[i] = changeCommitKey(batch.StartTs)
+	}
+	commits, err := m.Redis.MGet(ctx, keys...)
+	if err != nil {
+		return 0, err
+	}
+
+	appended := 0
+	pipe := m.Redis.TxPipeline()
+	for i, batch := range batches {
+		commitTs, err := strconv.ParseUint(commits[i], 10, 64)
+		if err != nil {
+			commitTs = readTs
+		}
+
+		var changes []batchedChange
+		if err := json.Unmarshal([]byte(batch.Changes), &changes); err != nil {
+			return 0, fmt.Errorf("failed to unmarshal change batch %d: %w", batch.StartTs, err)
+		}
+		for _, change := range changes {
+			tupleJSON, err := json.Marshal(change.Tuple)
+			if err != nil {
+				return 0, fmt.Errorf("failed to marshal change to tuple %s: %w", TupleKey(change.Tuple), err)
+			}
+
+			pipe.XAdd(ctx, &goredis.XAddArgs{
+				Stream: changeStreamKey,
+				MaxLen: changeLogMaxLen,
+				Approx: true,
+				Values: map[string]interface{}{
+					"type":       string(change.Type),
+					"tuple":      tupleJSON,
+					"commit_ts":  strconv.FormatUint(commitTs, 10),
+					"changed_at": batch.CreatedAt,
+				},
+			})
+			appended++
+		}
+	}
+	if appended == 0 {
+		return 0, nil
+	}
+
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, fmt.Errorf("failed to append change batches: %w", err)
+	}
+	return appended, nil
+}
+
+// deleteChangeBatches deletes relayed change batches from Dgraph
+// A relay stopped between appending and deleting batches appends them again,
+// so changes are delivered at least once
+func (m *Manager) deleteChangeBatches(ctx context.Context, batches []changeBatch) error {
+	removed := make([]string, len(batches))
+	for i, batch := range batches {
+		removed[i] = fmt.Sprintf("<%s> * * .", batch.UID)
+	}
+
+	txn := m.Dgraph.NewTransaction()
+	defer txn.Discard(ctx)
+
+	_, err := txn.Do(ctx, &api.Request{
+		Mutations: []*api.Mutation{{DelNquads: []byte(strings.Join(removed, "\n"))}},
+		CommitNow: true,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete relayed change batches: %w", err)
+	}
+	return nil
+}
+
+// changeCommitKey returns the key recording the commit timestamp of the
+// batch of a transaction
+func changeCommitKey(startTs uint64) string {
+	return changeCommitKeyPrefix + strconv.FormatUint(startTs, 10)
+}
+
+// ChangeLogHead returns the position of the latest recorded change, so a
+// reader starting there receives every change recorded afterwards
+func (m *Manager) ChangeLogHead(ctx context.Context) (string, error) {
+	exists, err := m.Redis.Exists(ctx, changeStreamKey)
+	if err != nil || !exists {
+		return "0-0", err
+	}
+
+	info, err := m.Redis.XInfoStream(ctx, changeStreamKey)
+	if err != nil {
+		return "", err
+	}
+	return info.LastGeneratedID, nil
+}
+
+// ChangeLogPositionAt returns a position after which every change committed
+// after commitTs is recorded
+// It is the position recorded by the latest relay pass that read at or
+// before commitTs; changes committed between that read and commitTs may
+// still follow it
+func (m *Manager) ChangeLogPositionAt(ctx context.Context, commitTs uint64) (string, error) {
+	positions, err := m.Redis.ZRevRangeByScore(ctx, changePositionsKey, "-inf", strconv.FormatUint(commitTs, 10), 1)
+	if err != nil {
+		return "", err
+	}
+	if len(positions) == 0 {
+		return "0-0", nil
+	}
+	return positions[0], nil
+}
+
+// ReadChanges returns up to count changes recorded after the given position,
+// waiting up to block for new changes when there are none
+// It fails with ErrChangesExpired when changes after the position have
+// already been trimmed from the change log
+func (m *Manager) ReadChanges(ctx context.Context, after string, count int64, block time.Duration) ([]Change, error) {
+	if !validStreamID(after) {
+		return nil, fmt.Errorf("invalid change log position %q", after)
+	}
+	if err := m.checkRetained(ctx, after); err != nil {
+		return nil, err
+	}
+
+	messages, err := m.Redis.XRead(ctx, changeStreamKey, after, count, block)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := make([]Change, 0, len(messages))
+	for _, message := range messages {
+		change, err := changeFromMessage(message)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, change)
+	}
+
+	return changes, nil
+}
+
+// checkRetained verifies that no change after the position has been trimmed
+func (m *Manager) checkRetained(ctx context.Context, after string) error {
+	exists, err := m.Redis.Exists(ctx, changeStreamKey)
+	if err != nil || !exists {
+		return err
+	}
+
+	info, err := m.Redis.XInfoStream(ctx, changeStreamKey)
+	if err != nil {
+		return err
+	}
+
+	// Servers before Redis 7 do not report trimmed entries
+	if info.MaxDeletedEntryID != "" && compareStreamIDs(after, info.MaxDeletedEntryID) < 0 {
+		return ErrChangesExpired
+	}
+	return nil
+}
+
+// changeFromMessage decodes a change log entry
+func changeFromMessage(message goredis.XMessage) (Change, error) {
+	change := Change{Position: message.ID}
+
+	changeType, _ := message.Values["type"].(string)
+	change.Type = ChangeType(changeType)
+
+	tupleJSON, _ := message.Values["tuple"].(string)
+	if err := json.Unmarshal([]byte(tupleJSON), &change.Tuple); err != nil {
+		return change, fmt.Errorf("failed to unmarshal change %s: %w", message.ID, err)
+	}
+
+	commitTs, _ := message.Values["commit_ts"].(string)
+	ts, err := strconv.ParseUint(commitTs, 10, 64)
+	if err != nil {
+		return change, fmt.Errorf("invalid commit timestamp in change %s: %w", message.ID, err)
+	}
+	change.CommitTs = ts
+
+	changedAt, _ := message.Values["changed_at"].(string)
+	change.ChangedAt, _ = time.Parse(time.RFC3339Nano, changedAt)
+
+	return change, nil
+}
+
+// validStreamID reports whether a value is a Redis stream ID of the form ms-seq
+func validStreamID(id string) bool {
+	_, _, ok := parseStreamID(id)
+	return ok
+}
+
+// compareStreamIDs orders two valid stream IDs
+func compareStreamIDs(a, b string) int {
+	aMs, aSeq, _ := parseStreamID(a)
+	bMs, bSeq, _ := parseStreamID(b)
+	switch {
+	case aMs < bMs:
+		return -1
+	case aMs > bMs:
+		return 1
+	case aSeq < bSeq:
+		return -1
+	case aSeq > bSeq:
+		return 1
+	}
+	return 0
+}
+
+// parseStreamID splits a stream ID into its time and sequence parts
+func parseStreamID(id string) (uint64, uint64, bool) {
+	msPart, seqPart, ok := strings.Cut(id, "-")
+	if !ok {
+		return 0, 0, false
+	}
+	ms, err := strconv.ParseUint(msPart, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	seq, err := strconv.ParseUint(seqPart, 10, 64)
+	if err != nil {
+		return 0, 0, false
+	}
+	return ms, seq, true
+}
@@ -206,6 +206,26 @@ func (c *Client) QueryWithVars(ctx context.Context, query string, vars map[strin
 	return txn.QueryWithVars(ctx, query, vars)
 }
 
+// QueryWithVarsAt executes a read-only query with variables against the
+// snapshot at the given read timestamp
+func (c *Client) QueryWithVarsAt(ctx context.Context, query string, vars map[string]string, readTs uint64) (*api.Response, error) {
+	if c.client == nil {
+		return nil, fmt.Errorf("Dgraph client is not initialized")
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.config.RequestTimeout)
+	defer cancel()
+
+	req := &api.Request{
+		Query:    query,
+		Vars:     vars,
+		StartTs:  readTs,
+		ReadOnly: true,
+	}
+
+	return api.NewDgraphClient(c.conn).Query(ctx, req)
+}
+
 // Mutate executes a mutation and returns the result
 func (c *Client) Mutate(ctx context.Context, mu *api.Mutation) (*api.Response, error) {
 	if c.client == nil {
@@ -1,18 +1,22 @@
 package dgraph
 
+import "strings"
+
 // Schema contains the Dgraph schema for the GoACL ReBAC system
+// Every node type is declared so deleting a node with "S * *" removes all of
+// its predicates, not only dgraph.type
 const Schema = `
 id: string @index(exact) .
 email: string @index(exact) .
-name: string @index(fulltext) .
+name: string @index(exact, fulltext) @upsert .
 created_at: datetime .
 updated_at: datetime .
 description: string @index(fulltext) .
 namespace: string @index(exact) .
-object_id: string @index(exact) .
+object_id: string @index(exact) @upsert .
 relation: string @index(exact) .
 user_id: string @index(exact) .
-userset: string .
+userset: string @index(exact) .
 action: string @index(exact) .
 resource_type: string @index(exact) .
 resource_id: string @index(exact) .
@@ -26,8 +30,14 @@ user_agent: string .
 conditions: string .
 permissions: [string] .
 granted_at: datetime .
-expires_at: datetime .
+expires_at: datetime @index(hour) .
 rewrite_rules: string .
+allow_wildcard: bool .
+version: int .
+author: string .
+revision_relations: string .
+change_start_ts: int @index(int) .
+change_batch: string .
 member_of: [uid] .
 owns: [uid] .
 has_role: [uid] .
@@ -43,123 +53,51 @@ resource: uid .
 granted_by: uid .
 relations: [uid] .
 actor: uid .
-`
 
-// InitialNamespaces contains the initial namespace configurations
-var InitialNamespaces = []NamespaceConfigData{
-	{
-		Name: "documents",
-		Relations: []RelationConfigData{
-			{
-				Name: "owner",
-				RewriteRules: `{"union": {"child": [{"_this": {}}]}}`,
-			},
-			{
-				Name: "editor",
-				RewriteRules: `{"union": {"child": [{"_this": {}}, {"computed_userset": {"relation": "owner"}}]}}`,
-			},
-			{
-				Name: "viewer",
-				RewriteRules: `{"union": {"child": [{"_this": {}}, {"computed_userset": {"relation": "editor"}}]}}`,
-			},
-			{
-				Name: "parent",
-				RewriteRules: `{"union": {"child": [{"_this": {}}]}}`,
-			},
-		},
-	},
-	{
-		Name: "folders",
-		Relations: []RelationConfigData{
-			{
-				Name: "owner",
-				RewriteRules: `{"union": {"child": [{"_this": {}}]}}`,
-			},
-			{
-				Name: "editor",
-				RewriteRules: `{"union": {"child": [{"_this": {}}, {"computed_userset": {"relation": "owner"}}]}}`,
-			},
-			{
-				Name: "viewer",
-				RewriteRules: `{"union": {"child": [{"_this": {}}, {"computed_userset": {"relation": "editor"}}, {"tuple_to_userset": {"tupleset": {"relation": "parent"}, "computed_userset": {"relation": "viewer"}}}]}}`,
-			},
-			{
-				Name: "parent",
-				RewriteRules: `{"union": {"child": [{"_this": {}}]}}`,
-			},
-		},
-	},
-	{
-		Name: "groups",
-		Relations: []RelationConfigData{
-			{
-				Name: "member",
-				RewriteRules: `{"union": {"child": [{"_this": {}}, {"tuple_to_userset": {"tupleset": {"relation": "parent"}, "computed_userset": {"relation": "member"}}}]}}`,
-			},
-			{
-				Name: "admin",
-				RewriteRules: `{"union": {"child": [{"_this": {}}]}}`,
-			},
-			{
-				Name: "parent",
-				RewriteRules: `{"union": {"child": [{"_this": {}}]}}`,
-			},
-		},
-	},
-	{
-		Name: "organizations",
-		Relations: []RelationConfigData{
-			{
-				Name: "member",
-				RewriteRules: `{"union": {"child": [{"_this": {}}]}}`,
-			},
-			{
-				Name: "admin",
-				RewriteRules: `{"union": {"child": [{"_this": {}}, {"computed_userset": {"relation": "owner"}}]}}`,
-			},
-			{
-				Name: "owner",
-				RewriteRules: `{"union": {"child": [{"_this": {}}]}}`,
-			},
-		},
-	},
+type RelationTuple {
+  namespace
+  object_id
+  relation
+  user_id
+  userset
+  conditions
+  expires_at
+  created_at
+  updated_at
 }
 
-// NamespaceConfigData represents the structure for namespace configuration
-type NamespaceConfigData struct {
-	Name      string               `json:"name"`
-	Relations []RelationConfigData `json:"relations"`
+type NamespaceConfig {
+  name
+  version
+  created_at
+  updated_at
+  relations
 }
 
-// RelationConfigData represents the structure for relation configuration
-type RelationConfigData struct {
-	Name         string `json:"name"`
-	RewriteRules string `json:"rewrite_rules"`
+type RelationConfig {
+  name
+  rewrite_rules
+  allow_wildcard
 }
 
-// GetSchemaWithoutTypes returns the schema without type definitions for updates
-func GetSchemaWithoutTypes() string {
-	return `
-# Indexes for performance optimization
-id: string @index(exact) .
-email: string @index(exact) .
-name: string @index(fulltext) .
-namespace: string @index(exact) .
-object_id: string @index(exact) .
-relation: string @index(exact) .
-user_id: string @index(exact) .
-action: string @index(exact) .
-resource_type: string @index(exact) .
-resource_id: string @index(exact) .
-timestamp: datetime @index(hour) .
-token: string @index(exact) .
-type: string @index(exact) .
-effect: string @index(exact) .
+type NamespaceRevision {
+  namespace
+  version
+  author
+  created_at
+  revision_relations
+}
 
-# Composite indexes for common query patterns
-RelationTuple.namespace_object_relation: string @index(exact) .
-RelationTuple.namespace_user_relation: string @index(exact) .
-RelationTuple.object_relation: string @index(exact) .
-AuditLog.resource_timestamp: string @index(exact) .
+type ChangeBatch {
+  change_start_ts
+  change_batch
+  created_at
+}
 `
+
+// GetSchemaWithoutTypes returns the predicates of Schema without its type
+// definitions, for updates
+func GetSchemaWithoutTypes() string {
+	predicates, _, _ := strings.Cut(Schema, "\ntype ")
+	return predicates + "\n"
 }
@@ -0,0 +1,83 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/dgo/v240"
+	"github.com/dgraph-io/dgo/v240/protos/api"
+)
+
+// DeleteExpiredTuples deletes up to limit relation tuples whose expiry is at
+// or before now and returns the deleted tuples
+// The lookup and the deletion run in one transaction, so deleting a tuple
+// extended concurrently conflicts and the tuple is left in place
+func (m *Manager) DeleteExpiredTuples(ctx context.Context, now time.Time, limit int) ([]RelationTuple, error) {
+	query := `query expired($now: string, $limit: int) {
+		tuples(func: le(expires_at, $now), first: $limit) @filter(type(RelationTuple)) {
+			` + tupleFields + `
+		}
+	}`
+	vars := map[string]string{
+		"$now":   now.UTC().Format(time.RFC3339Nano),
+		"$limit": strconv.Itoa(limit),
+	}
+
+	txn := m.Dgraph.NewTransaction()
+	defer txn.Discard(ctx)
+
+	resp, err := txn.QueryWithVars(ctx, query, vars)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find expired tuples: %w", err)
+	}
+
+	var result struct {
+		Tuples []RelationTuple `json:"tuples"`
+	}
+	if err := json.Unmarshal(resp.Json, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal expired tuples: %w", err)
+	}
+	if len(result.Tuples) == 0 {
+		return nil, nil
+	}
+
+	removed := make([]string, len(result.Tuples))
+	changes := make([]Change, len(result.Tuples))
+	for i, tuple := range result.Tuples {
+		removed[i] = fmt.Sprintf("<%s> * * .", tuple.UID)
+		changes[i] = Change{Type: ChangeDeleted, Tuple: tuple}
+	}
+	startTs := resp.GetTxn().GetStartTs()
+	batch, err := stageChanges(startTs, changes)
+	if err != nil {
+		return nil, err
+	}
+
+	mutResp, err := txn.Do(ctx, &api.Request{
+		Mutations: []*api.Mutation{{DelNquads: []byte(strings.Join(removed, "\n"))}, batch},
+		CommitNow: true,
+	})
+	if err != nil {
+		if errors.Is(err, dgo.ErrAborted) {
+			return nil, fmt.Errorf("%w: %v", ErrConflict, err)
+		}
+		return nil, fmt.Errorf("failed to delete expired tuples: %w", err)
+	}
+	m.publishChanges(ctx, startTs, mutResp.GetTxn().GetCommitTs())
+
+	// Invalidate the cached tuple lists the deleted tuples belonged to
+	for _, tuple := range result.Tuples {
+		cacheKey := tupleCacheKey(tuple.Namespace, tuple.ObjectID, tuple.Relation)
+		if err := m.Redis.Del(ctx, cacheKey); err != nil {
+			log.Printf("Warning: failed to invalidate cache for key %s: %v", cacheKey, err)
+		}
+	}
+
+	return result.Tuples, nil
+}
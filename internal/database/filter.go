@@ -0,0 +1,157 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// TupleFilter selects relation tuples by any combination of their fields
+// Empty fields match every tuple
+type TupleFilter struct {
+	Namespace string
+	ObjectID  string
+	Relation  string
+	UserID    string
+	Userset   string
+}
+
+// IsEmpty reports whether the filter matches every tuple
+func (f TupleFilter) IsEmpty() bool {
+	return f == TupleFilter{}
+}
+
+// Matches reports whether a tuple satisfies the filter
+func (f TupleFilter) Matches(tuple RelationTuple) bool {
+	switch {
+	case f.Namespace != "" && tuple.Namespace != f.Namespace:
+		return false
+	case f.ObjectID != "" && tuple.ObjectID != f.ObjectID:
+		return false
+	case f.Relation != "" && tuple.Relation != f.Relation:
+		return false
+	case f.UserID != "" && (tuple.Userset != "" || tuple.UserID != f.UserID):
+		return false
+	case f.Userset != "" && tuple.Userset != f.Userset:
+		return false
+	}
+	return true
+}
+
+// ErrInvalidCursor is returned when a page is requested after a malformed uid
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// TuplePage is one page of the tuples matching a filter
+type TuplePage struct {
+	// Tuples are the matching tuples on the page, in ascending uid order
+	Tuples []RelationTuple
+
+	// After is the uid to continue reading from, empty on the last page
+	After string
+
+	// ReadTs is the Dgraph timestamp the page was read at
+	ReadTs uint64
+}
+
+// ReadTuples returns up to limit tuples matching the filter, ordered by uid
+// and starting after the given uid
+// The page is read at readTs when it is non-zero, so a caller paging through
+// a large result can pin every page to the snapshot of the first one
+func (m *Manager) ReadTuples(ctx context.Context, filter TupleFilter, after string, limit int, readTs uint64) (*TuplePage, error) {
+	pagination := fmt.Sprintf(", first: %d", limit+1)
+	if after != "" {
+		uid, err := strconv.ParseUint(strings.TrimPrefix(after, "0x"), 16, 64)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q", ErrInvalidCursor, after)
+		}
+		pagination += fmt.Sprintf(", after: %#x", uid)
+	}
+
+	query, vars := filter.query("readTuples", pagination)
+
+	resp, err := m.queryAt(ctx, query, vars, readTs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read tuples: %w", err)
+	}
+
+	var result struct {
+		Tuples []RelationTuple `json:"tuples"`
+	}
+	if err := json.Unmarshal(resp.Json, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal tuples result: %w", err)
+	}
+
+	page := &TuplePage{Tuples: result.Tuples, ReadTs: readTs}
+	if readTs == 0 {
+		page.ReadTs = resp.GetTxn().GetStartTs()
+	}
+	if len(page.Tuples) > limit {
+		page.Tuples = page.Tuples[:limit]
+		page.After = page.Tuples[limit-1].UID
+	}
+
+	return page, nil
+}
+
+// query builds a DQL query returning the tuples that match the filter
+// The root function uses the most selective field that is set, and
+// pagination holds any extra root arguments such as first and after
+func (f TupleFilter) query(name, pagination string) (string, map[string]string) {
+	fields := []struct {
+		predicate string
+		value     string
+	}{
+		{"object_id", f.ObjectID},
+		{"user_id", f.UserID},
+		{"userset", f.Userset},
+		{"namespace", f.Namespace},
+		{"relation", f.Relation},
+	}
+
+	var (
+		root       string
+		params     []string
+		conditions = []string{"type(RelationTuple)"}
+		vars       = make(map[string]string)
+	)
+	for _, field := range fields {
+		if field.value == "" {
+			continue
+		}
+		variable := "$" + field.predicate
+		vars[variable] = field.value
+		params = append(params, variable+": string")
+
+		condition := "eq(" + field.predicate + ", " + variable + ")"
+		if root == "" {
+			root = condition
+		} else {
+			conditions = append(conditions, condition)
+		}
+	}
+	if f.UserID != "" {
+		conditions = append(conditions, "NOT has(userset)")
+	}
+
+	filter := " @filter(" + strings.Join(conditions, " AND ") + ")"
+	if root == "" {
+		root = "type(RelationTuple)"
+		filter = ""
+	}
+
+	header := name
+	if len(params) > 0 {
+		header += "(" + strings.Join(params, ", ") + ")"
+	}
+
+	query := `query ` + header + ` {
+		tuples(func: ` + root + pagination + `)` + filter + ` {
+			` + tupleFields + `
+		}
+	}`
+
+	return query, vars
+}
@@ -0,0 +1,207 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/dgo/v240"
+	"github.com/dgraph-io/dgo/v240/protos/api"
+)
+
+// ImportResult reports the outcome of importing a batch of tuples
+type ImportResult struct {
+	// Imported are the tuples that were stored
+	Imported []RelationTuple
+
+	// Skipped is the number of tuples that already existed or were repeated
+	// within the batch
+	Skipped int
+
+	// CommitTs is the Dgraph timestamp of the import, zero when nothing was stored
+	CommitTs uint64
+}
+
+// ImportTuples stores a batch of tuples in a single transaction, skipping
+// those that already exist
+// Unlike TupleTxn it looks up every tuple of the batch in one query and
+// stores the new ones in one mutation, trading preconditions and updates
+// for throughput
+func (m *Manager) ImportTuples(ctx context.Context, tuples []RelationTuple) (*ImportResult, error) {
+	result := &ImportResult{}
+
+	// Drop duplicates within the batch
+	unique := make([]RelationTuple, 0, len(tuples))
+	seen := make(map[string]bool, len(tuples))
+	for _, tuple := range tuples {
+		key := TupleKey(tuple)
+		if seen[key] {
+			result.Skipped++
+			continue
+		}
+		seen[key] = true
+		unique = append(unique, tuple)
+	}
+	if len(unique) == 0 {
+		return result, nil
+	}
+
+	txn := m.Dgraph.NewTransaction()
+	defer txn.Discard(ctx)
+
+	stored, startTs, err := storedTuples(ctx, txn, unique)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		nodes   []map[string]interface{}
+		removed []string
+	)
+	now := time.Now()
+	timestamp := now.Format(time.RFC3339)
+	for i, tuple := range unique {
+		if stored[i] != nil && !stored[i].Expired(now) {
+			result.Skipped++
+			continue
+		}
+
+		node := map[string]interface{}{
+			"dgraph.type": "RelationTuple",
+			"namespace":   tuple.Namespace,
+			"object_id":   tuple.ObjectID,
+			"relation":    tuple.Relation,
+			"user_id":     tuple.UserID,
+			"created_at":  timestamp,
+			"updated_at":  timestamp,
+		}
+
+		// An expired copy that has not been reaped yet is replaced in place
+		if expired := stored[i]; expired != nil {
+			node["uid"] = expired.UID
+			if expired.Conditions != "" && tuple.Conditions == "" {
+				removed = append(removed, fmt.Sprintf("<%s> <conditions> * .", expired.UID))
+			}
+			if tuple.ExpiresAt == "" {
+				removed = append(removed, fmt.Sprintf("<%s> <expires_at> * .", expired.UID))
+			}
+		}
+		if tuple.Userset != "" {
+			node["userset"] = tuple.Userset
+		}
+		if tuple.Conditions != "" {
+			node["conditions"] = tuple.Conditions
+		}
+		if tuple.ExpiresAt != "" {
+			node["expires_at"] = tuple.ExpiresAt
+		}
+		nodes = append(nodes, node)
+		result.Imported = append(result.Imported, tuple)
+	}
+	if len(nodes) == 0 {
+		return result, nil
+	}
+
+	setJSON, err := json.Marshal(nodes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal imported tuples: %w", err)
+	}
+
+	mutation := &api.Mutation{SetJson: setJSON}
+	if len(removed) > 0 {
+		mutation.DelNquads = []byte(strings.Join(removed, "\n"))
+	}
+
+	changes := make([]Change, len(result.Imported))
+	for i, tuple := range result.Imported {
+		changes[i] = Change{Type: ChangeCreated, Tuple: tuple}
+	}
+	batch, err := stageChanges(startTs, changes)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := txn.Do(ctx, &api.Request{
+		Mutations: []*api.Mutation{mutation, batch},
+		CommitNow: true,
+	})
+	if err != nil {
+		if errors.Is(err, dgo.ErrAborted) {
+			return nil, fmt.Errorf("%w: %v", ErrConflict, err)
+		}
+		return nil, fmt.Errorf("failed to import tuples: %w", err)
+	}
+	result.CommitTs = resp.GetTxn().GetCommitTs()
+
+	m.publishChanges(ctx, startTs, result.CommitTs)
+
+	cacheKeys := make(map[string]bool)
+	for _, tuple := range result.Imported {
+		cacheKeys[tupleCacheKey(tuple.Namespace, tuple.ObjectID, tuple.Relation)] = true
+	}
+
+	// Invalidate the cached tuple lists the imported tuples belong to
+	for cacheKey := range cacheKeys {
+		if err := m.Redis.Del(ctx, cacheKey); err != nil {
+			log.Printf("Warning: failed to invalidate cache for key %s: %v", cacheKey, err)
+		}
+	}
+
+	return result, nil
+}
+
+// storedTuples returns, for each tuple, the stored copy or nil when there is
+// none, and the start timestamp of the transaction
+// Every tuple is looked up by its own block of a single query
+func storedTuples(ctx context.Context, txn *dgo.Txn, tuples []RelationTuple) ([]*RelationTuple, uint64, error) {
+	var (
+		params []string
+		blocks []string
+		vars   = make(map[string]string, 4*len(tuples))
+	)
+	for i, tuple := range tuples {
+		subjectFilter := fmt.Sprintf("eq(user_id, $s%d) AND NOT has(userset)", i)
+		subject := tuple.UserID
+		if tuple.Userset != "" {
+			subjectFilter = fmt.Sprintf("eq(userset, $s%d)", i)
+			subject = tuple.Userset
+		}
+
+		params = append(params, fmt.Sprintf("$n%d: string, $o%d: string, $r%d: string, $s%d: string", i, i, i, i))
+		blocks = append(blocks, fmt.Sprintf(
+			"t%d(func: eq(object_id, $o%d)) @filter(type(RelationTuple) AND eq(namespace, $n%d) AND eq(relation, $r%d) AND %s) { uid conditions expires_at }",
+			i, i, i, i, subjectFilter))
+
+		vars[fmt.Sprintf("$n%d", i)] = tuple.Namespace
+		vars[fmt.Sprintf("$o%d", i)] = tuple.ObjectID
+		vars[fmt.Sprintf("$r%d", i)] = tuple.Relation
+		vars[fmt.Sprintf("$s%d", i)] = subject
+	}
+
+	query := "query existing(" + strings.Join(params, ", ") + ") {\n" + strings.Join(blocks, "\n") + "\n}"
+
+	if stats := queryStatsFrom(ctx); stats != nil {
+		stats.DgraphQueries.Add(1)
+	}
+	resp, err := txn.QueryWithVars(ctx, query, vars)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to look up imported tuples: %w", err)
+	}
+
+	var result map[string][]RelationTuple
+	if err := json.Unmarshal(resp.Json, &result); err != nil {
+		return nil, 0, fmt.Errorf("failed to unmarshal imported tuples lookup: %w", err)
+	}
+
+	stored := make([]*RelationTuple, len(tuples))
+	for i := range tuples {
+		if copies := result[fmt.Sprintf("t%d", i)]; len(copies) > 0 {
+			stored[i] = &copies[0]
+		}
+	}
+	return stored, resp.GetTxn().GetStartTs(), nil
+}
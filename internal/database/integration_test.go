@@ -4,12 +4,16 @@ package database
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"sync"
 	"testing"
 	"time"
 
 	"github.com/DangVTNhan/goacl/internal/database/dgraph"
 	"github.com/DangVTNhan/goacl/internal/database/redis"
+	"github.com/DangVTNhan/goacl/internal/schema"
 	"github.com/testcontainers/testcontainers-go"
 	"github.com/testcontainers/testcontainers-go/wait"
 )
@@ -93,6 +97,22 @@ func TestDatabaseIntegration(t *testing.T) {
 		testRelationTupleOperations(t, manager)
 	})
 
+	t.Run("TupleDeletion", func(t *testing.T) {
+		testTupleDeletion(t, manager)
+	})
+
+	t.Run("TupleImport", func(t *testing.T) {
+		testTupleImport(t, manager)
+	})
+
+	t.Run("ChangeLog", func(t *testing.T) {
+		testChangeLog(t, manager)
+	})
+
+	t.Run("NamespaceWrites", func(t *testing.T) {
+		testNamespaceWrites(t, manager)
+	})
+
 	t.Run("CacheOperations", func(t *testing.T) {
 		testCacheOperations(t, manager)
 	})
@@ -182,21 +202,41 @@ func testSchemaInitialization(t *testing.T, manager *Manager) {
 		t.Errorf("Schema initialization failed: %v", err)
 	}
 
+	// Namespaces are bootstrapped by the application, so the tests create
+	// the shipped ones themselves
+	initial, err := schema.LoadDir("../../namespaces")
+	if err != nil {
+		t.Fatalf("Failed to load the shipped namespaces: %v", err)
+	}
+	for _, ns := range initial {
+		config := NamespaceConfig{Name: ns.GetName()}
+		for _, rel := range ns.GetRelations() {
+			config.Relations = append(config.Relations, RelationConfig{
+				Name:          rel.GetName(),
+				RewriteRules:  rel.GetRewriteRules(),
+				AllowWildcard: rel.GetAllowWildcard(),
+			})
+		}
+		if _, _, err := manager.WriteNamespaceConfig(ctx, NamespaceWrite{Config: config}); err != nil && !errors.Is(err, ErrAlreadyExists) {
+			t.Errorf("Failed to create namespace %s: %v", ns.GetName(), err)
+		}
+	}
+
 	// Verify namespaces were created
-	for _, nsData := range dgraph.InitialNamespaces {
-		ns, err := manager.GetNamespaceConfig(ctx, nsData.Name)
+	for _, expected := range initial {
+		ns, err := manager.GetNamespaceConfig(ctx, expected.GetName())
 		if err != nil {
-			t.Errorf("Failed to get namespace %s: %v", nsData.Name, err)
+			t.Errorf("Failed to get namespace %s: %v", expected.GetName(), err)
 			continue
 		}
 
-		if ns.Name != nsData.Name {
-			t.Errorf("Expected namespace name %s, got %s", nsData.Name, ns.Name)
+		if ns.Name != expected.GetName() {
+			t.Errorf("Expected namespace name %s, got %s", expected.GetName(), ns.Name)
 		}
 
-		if len(ns.Relations) != len(nsData.Relations) {
+		if len(ns.Relations) != len(expected.GetRelations()) {
 			t.Errorf("Expected %d relations for namespace %s, got %d",
-				len(nsData.Relations), nsData.Name, len(ns.Relations))
+				len(expected.GetRelations()), expected.GetName(), len(ns.Relations))
 		}
 	}
 }
@@ -271,6 +311,285 @@ func testRelationTupleOperations(t *testing.T, manager *Manager) {
 	}
 }
 
+// testTupleDeletion tests finding and deleting tuples by filter in a transaction
+func testTupleDeletion(t *testing.T, manager *Manager) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	for _, user := range []string{"carol", "dave"} {
+		tuple := &RelationTuple{Namespace: "documents", ObjectID: "doc-delete", Relation: "viewer", UserID: user}
+		if err := manager.CreateRelationTuple(ctx, tuple); err != nil {
+			t.Fatalf("Failed to create relation tuple: %v", err)
+		}
+	}
+
+	txn := manager.NewTupleTxn()
+	defer txn.Discard(ctx)
+
+	filter := TupleFilter{Namespace: "documents", ObjectID: "doc-delete"}
+	tuples, err := txn.Find(ctx, filter)
+	if err != nil {
+		t.Fatalf("Failed to find tuples: %v", err)
+	}
+	if len(tuples) != 2 {
+		t.Fatalf("Expected 2 matching tuples, got %d", len(tuples))
+	}
+
+	for _, tuple := range tuples {
+		if _, err := txn.Delete(ctx, tuple); err != nil {
+			t.Fatalf("Failed to delete tuple: %v", err)
+		}
+	}
+
+	// Deletions staged in the transaction are visible to later reads
+	if tuples, err := txn.Find(ctx, filter); err != nil || len(tuples) != 0 {
+		t.Fatalf("Expected no tuples after staging deletes, got %d (%v)", len(tuples), err)
+	}
+
+	if _, err := txn.Commit(ctx); err != nil {
+		t.Fatalf("Failed to commit deletes: %v", err)
+	}
+
+	remaining, err := manager.GetRelationTuples(ctx, "documents", "doc-delete", "viewer")
+	if err != nil {
+		t.Fatalf("Failed to read tuples: %v", err)
+	}
+	if len(remaining) != 0 {
+		t.Errorf("Expected deleted tuples to be gone, got %d", len(remaining))
+	}
+
+	// Deleted nodes keep none of their predicates, not even untyped reads find them
+	resp, err := manager.Dgraph.QueryWithVars(ctx, `query orphans($id: string) {
+		orphans(func: eq(object_id, $id)) {
+			uid
+		}
+	}`, map[string]string{"$id": "doc-delete"})
+	if err != nil {
+		t.Fatalf("Failed to query deleted nodes: %v", err)
+	}
+	var orphans struct {
+		Orphans []struct {
+			UID string `json:"uid"`
+		} `json:"orphans"`
+	}
+	if err := json.Unmarshal(resp.Json, &orphans); err != nil {
+		t.Fatalf("Failed to unmarshal deleted nodes: %v", err)
+	}
+	if len(orphans.Orphans) != 0 {
+		t.Errorf("Expected deleted tuples to keep no predicates, got %d nodes", len(orphans.Orphans))
+	}
+}
+
+// testTupleImport tests bulk importing tuples while skipping existing ones
+func testTupleImport(t *testing.T, manager *Manager) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	existing := &RelationTuple{Namespace: "documents", ObjectID: "doc-import", Relation: "viewer", UserID: "frank"}
+	if err := manager.CreateRelationTuple(ctx, existing); err != nil {
+		t.Fatalf("Failed to create relation tuple: %v", err)
+	}
+
+	result, err := manager.ImportTuples(ctx, []RelationTuple{
+		*existing,
+		{Namespace: "documents", ObjectID: "doc-import", Relation: "viewer", UserID: "grace"},
+		{Namespace: "documents", ObjectID: "doc-import", Relation: "viewer", Userset: "groups:eng#member"},
+		{Namespace: "documents", ObjectID: "doc-import", Relation: "viewer", UserID: "grace"},
+	})
+	if err != nil {
+		t.Fatalf("Failed to import tuples: %v", err)
+	}
+	if len(result.Imported) != 2 || result.Skipped != 2 {
+		t.Errorf("Expected 2 imported and 2 skipped tuples, got %d and %d", len(result.Imported), result.Skipped)
+	}
+
+	tuples, err := manager.GetRelationTuples(ctx, "documents", "doc-import", "viewer")
+	if err != nil {
+		t.Fatalf("Failed to read tuples: %v", err)
+	}
+	if len(tuples) != 3 {
+		t.Errorf("Expected 3 stored tuples, got %d", len(tuples))
+	}
+}
+
+// testChangeLog tests that tuple changes are recorded in the change log
+func testChangeLog(t *testing.T, manager *Manager) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	head, err := manager.ChangeLogHead(ctx)
+	if err != nil {
+		t.Fatalf("Failed to read change log head: %v", err)
+	}
+
+	tuple := &RelationTuple{Namespace: "documents", ObjectID: "doc-watch", Relation: "viewer", UserID: "erin"}
+	if err := manager.CreateRelationTuple(ctx, tuple); err != nil {
+		t.Fatalf("Failed to create relation tuple: %v", err)
+	}
+
+	changes, err := manager.ReadChanges(ctx, head, 10, time.Second)
+	if err != nil {
+		t.Fatalf("Failed to read changes: %v", err)
+	}
+	if len(changes) != 1 || changes[0].Type != ChangeCreated || TupleKey(changes[0].Tuple) != TupleKey(*tuple) {
+		t.Fatalf("Expected the creation of %s, got %+v", TupleKey(*tuple), changes)
+	}
+	if changes[0].CommitTs == 0 {
+		t.Error("Expected the change to carry its commit timestamp")
+	}
+
+	// Writers of unrelated tuples do not conflict, and each change is logged
+	users := []string{"u1", "u2", "u3", "u4"}
+	var wg sync.WaitGroup
+	for _, user := range users {
+		wg.Add(1)
+		go func(user string) {
+			defer wg.Done()
+			tuple := &RelationTuple{Namespace: "documents", ObjectID: "doc-watch-" + user, Relation: "viewer", UserID: user}
+			if err := manager.CreateRelationTuple(ctx, tuple); err != nil {
+				t.Errorf("Failed to create relation tuple: %v", err)
+			}
+		}(user)
+	}
+	wg.Wait()
+
+	changes, err = manager.ReadChanges(ctx, changes[0].Position, 10, time.Second)
+	if err != nil {
+		t.Fatalf("Failed to read changes: %v", err)
+	}
+	logged := make(map[string]bool)
+	for _, change := range changes {
+		logged[change.Tuple.UserID] = true
+	}
+	if len(changes) != len(users) || len(logged) != len(users) {
+		t.Fatalf("Expected one change per writer, got %+v", changes)
+	}
+
+	// Every change committed after a commit follows its position
+	position, err := manager.ChangeLogPositionAt(ctx, changes[1].CommitTs)
+	if err != nil {
+		t.Fatalf("Failed to locate commit: %v", err)
+	}
+	after, err := manager.ReadChanges(ctx, position, 10, time.Second)
+	if err != nil {
+		t.Fatalf("Failed to read changes: %v", err)
+	}
+	following := make(map[string]bool)
+	for _, change := range after {
+		following[change.Position] = true
+	}
+	for _, change := range changes {
+		if change.CommitTs > changes[1].CommitTs && !following[change.Position] {
+			t.Errorf("Expected change %s committed at %d to follow the position of %d", change.Position, change.CommitTs, changes[1].CommitTs)
+		}
+	}
+
+	// Nothing is left for the relay once writers relayed their changes
+	if relayed, err := manager.RelayChanges(ctx); err != nil || relayed != 0 {
+		t.Errorf("Expected nothing to relay, got %d (%v)", relayed, err)
+	}
+}
+
+// testNamespaceWrites tests versioned namespace writes and cascading deletes
+func testNamespaceWrites(t *testing.T, manager *Manager) {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	config := NamespaceConfig{Name: "teams", Relations: []RelationConfig{{Name: "member"}}}
+	created, _, err := manager.WriteNamespaceConfig(ctx, NamespaceWrite{Config: config})
+	if err != nil {
+		t.Fatalf("Failed to create namespace: %v", err)
+	}
+	if created.Version != 1 {
+		t.Errorf("Expected a new namespace at version 1, got %d", created.Version)
+	}
+
+	if _, _, err := manager.WriteNamespaceConfig(ctx, NamespaceWrite{Config: config}); !errors.Is(err, ErrAlreadyExists) {
+		t.Errorf("Expected creating the namespace again to fail, got %v", err)
+	}
+
+	config.Relations = append(config.Relations, RelationConfig{Name: "lead"})
+	update := NamespaceWrite{Config: config, AllowUpdate: true, ExpectedVersion: 1}
+	if _, _, err := manager.WriteNamespaceConfig(ctx, update); err != nil {
+		t.Fatalf("Failed to update namespace: %v", err)
+	}
+	if _, _, err := manager.WriteNamespaceConfig(ctx, update); !errors.Is(err, ErrVersionMismatch) {
+		t.Errorf("Expected a stale update to fail, got %v", err)
+	}
+
+	stored, err := manager.GetNamespaceConfig(ctx, "teams")
+	if err != nil {
+		t.Fatalf("Failed to get namespace: %v", err)
+	}
+	if stored.Version != 2 || len(stored.Relations) != 2 {
+		t.Errorf("Expected version 2 with 2 relations, got version %d with %d", stored.Version, len(stored.Relations))
+	}
+
+	page, err := manager.ReadNamespaceRevisions(ctx, "teams", 0, 10)
+	if err != nil {
+		t.Fatalf("Failed to read revisions: %v", err)
+	}
+	if len(page.Revisions) != 2 || page.Revisions[0].Version != 2 || len(page.Revisions[1].Relations) != 1 {
+		t.Errorf("Expected revisions 2 and 1, got %+v", page.Revisions)
+	}
+
+	tuple := &RelationTuple{Namespace: "teams", ObjectID: "eng", Relation: "member", UserID: "heidi"}
+	if err := manager.CreateRelationTuple(ctx, tuple); err != nil {
+		t.Fatalf("Failed to create relation tuple: %v", err)
+	}
+
+	counts, err := manager.CountNamespaceTuples(ctx, "teams")
+	if err != nil {
+		t.Fatalf("Failed to count tuples: %v", err)
+	}
+	if counts["member"] != 1 || counts["lead"] != 0 {
+		t.Errorf("Expected 1 member tuple, got %v", counts)
+	}
+
+	// The check sees the tuple counts read in the write transaction
+	errInUse := errors.New("member has tuples")
+	removal := NamespaceWrite{
+		Config:          NamespaceConfig{Name: "teams", Relations: []RelationConfig{{Name: "lead"}}},
+		AllowUpdate:     true,
+		ExpectedVersion: 2,
+		Check: func(stored NamespaceConfig, tuples map[string]int) error {
+			if stored.Version != 2 || tuples["member"] != 1 {
+				t.Errorf("Expected version 2 with 1 member tuple, got version %d with %v", stored.Version, tuples)
+			}
+			return errInUse
+		},
+	}
+	if _, _, err := manager.WriteNamespaceConfig(ctx, removal); !errors.Is(err, errInUse) {
+		t.Errorf("Expected the check to fail the update, got %v", err)
+	}
+
+	if _, err := manager.DeleteNamespaceConfig(ctx, "teams", false, 0); !errors.Is(err, ErrNamespaceInUse) {
+		t.Errorf("Expected deleting a namespace in use to fail, got %v", err)
+	}
+
+	deletion, err := manager.DeleteNamespaceConfig(ctx, "teams", true, 2)
+	if err != nil {
+		t.Fatalf("Failed to force delete namespace: %v", err)
+	}
+	if deletion.DeletedTuples != 1 {
+		t.Errorf("Expected 1 deleted tuple, got %d", deletion.DeletedTuples)
+	}
+
+	if _, err := manager.GetNamespaceConfig(ctx, "teams"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected the namespace to be deleted, got %v", err)
+	}
+	if _, err := manager.ReadNamespaceRevision(ctx, "teams", 1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Expected the revisions to be deleted, got %v", err)
+	}
+	tuples, err := manager.GetRelationTuples(ctx, "teams", "eng", "member")
+	if err != nil {
+		t.Fatalf("Failed to read tuples: %v", err)
+	}
+	if len(tuples) != 0 {
+		t.Errorf("Expected the tuples of the namespace to be deleted, got %d", len(tuples))
+	}
+}
+
 // testCacheOperations tests Redis caching functionality
 func testCacheOperations(t *testing.T, manager *Manager) {
 	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
@@ -3,8 +3,10 @@ package database
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"sort"
 	"time"
 
 	"github.com/DangVTNhan/goacl/internal/database/dgraph"
@@ -12,6 +14,37 @@ import (
 	"github.com/dgraph-io/dgo/v240/protos/api"
 )
 
+// ErrNotFound is returned when a requested record does not exist
+var ErrNotFound = errors.New("not found")
+
+// tupleFields lists the predicates selected when reading relation tuples
+const tupleFields = `uid
+			namespace
+			object_id
+			relation
+			user_id
+			userset
+			conditions
+			expires_at
+			created_at
+			updated_at`
+
+// namespaceFields lists the predicates selected when reading namespace configurations
+const namespaceFields = `uid
+			name
+			version
+			created_at
+			updated_at
+			relations {
+				uid
+				name
+				rewrite_rules
+				allow_wildcard
+			}`
+
+// tupleCacheTTL is how long relation tuple lists stay cached in Redis
+const tupleCacheTTL = 5 * time.Minute
+
 // Manager manages both Dgraph and Redis connections
 type Manager struct {
 	Dgraph *dgraph.Client
@@ -42,20 +75,15 @@ func NewManager(dgraphConfig *dgraph.Config, redisConfig *redis.Config) (*Manage
 	return manager, nil
 }
 
-// Initialize sets up the database schema and initial data
+// Initialize sets up the database schema
 func (m *Manager) Initialize(ctx context.Context) error {
-	log.Println("Initializing database schema and data...")
+	log.Println("Initializing database schema...")
 
 	// Apply Dgraph schema
 	if err := m.Dgraph.ApplySchema(ctx, dgraph.Schema); err != nil {
 		return fmt.Errorf("failed to apply Dgraph schema: %w", err)
 	}
 
-	// Create initial namespace configurations
-	if err := m.createInitialNamespaces(ctx); err != nil {
-		return fmt.Errorf("failed to create initial namespaces: %w", err)
-	}
-
 	// Test Redis connectivity
 	if err := m.Redis.Set(ctx, "goacl:init", "success", time.Minute); err != nil {
 		return fmt.Errorf("failed to test Redis connectivity: %w", err)
@@ -107,229 +135,261 @@ func (m *Manager) Close() error {
 	return nil
 }
 
-// createInitialNamespaces creates the initial namespace configurations in Dgraph
-func (m *Manager) createInitialNamespaces(ctx context.Context) error {
-	log.Println("Creating initial namespace configurations...")
-
-	for _, nsData := range dgraph.InitialNamespaces {
-		// Check if namespace already exists
-		query := `query checkNamespace($name: string) {
-			namespace(func: eq(name, $name)) @filter(type(NamespaceConfig)) {
-				uid
-				name
-			}
-		}`
+// GetNamespaceConfig retrieves a namespace configuration by name
+func (m *Manager) GetNamespaceConfig(ctx context.Context, name string) (*NamespaceConfig, error) {
+	return m.getNamespaceConfig(ctx, name, 0)
+}
 
-		vars := map[string]string{"$name": nsData.Name}
-		resp, err := m.Dgraph.QueryWithVars(ctx, query, vars)
-		if err != nil {
-			return fmt.Errorf("failed to check namespace %s: %w", nsData.Name, err)
+// getNamespaceConfig retrieves a namespace configuration, reading at readTs when it is non-zero
+func (m *Manager) getNamespaceConfig(ctx context.Context, name string, readTs uint64) (*NamespaceConfig, error) {
+	query := `query getNamespace($name: string) {
+		namespace(func: eq(name, $name)) @filter(type(NamespaceConfig)) {
+			` + namespaceFields + `
 		}
+	}`
 
-		var result struct {
-			Namespace []struct {
-				UID  string `json:"uid"`
-				Name string `json:"name"`
-			} `json:"namespace"`
-		}
+	vars := map[string]string{"$name": name}
+	resp, err := m.queryAt(ctx, query, vars, readTs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query namespace %s: %w", name, err)
+	}
 
-		if err := json.Unmarshal(resp.Json, &result); err != nil {
-			return fmt.Errorf("failed to unmarshal namespace check result: %w", err)
-		}
+	var result struct {
+		Namespace []NamespaceConfig `json:"namespace"`
+	}
 
-		// Skip if namespace already exists
-		if len(result.Namespace) > 0 {
-			log.Printf("Namespace %s already exists, skipping", nsData.Name)
-			continue
-		}
+	if err := json.Unmarshal(resp.Json, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal namespace result: %w", err)
+	}
 
-		// Create namespace
-		if err := m.createNamespace(ctx, nsData); err != nil {
-			return fmt.Errorf("failed to create namespace %s: %w", nsData.Name, err)
-		}
+	if len(result.Namespace) == 0 {
+		return nil, fmt.Errorf("namespace %s %w", name, ErrNotFound)
+	}
 
-		log.Printf("Created namespace: %s", nsData.Name)
+	config := result.Namespace[0]
+	config.Version = storedVersion(config.Version)
+	return &config, nil
+}
+
+// CreateRelationTuple creates a new relation tuple, or updates the condition
+// and expiry of an existing one
+func (m *Manager) CreateRelationTuple(ctx context.Context, tuple *RelationTuple) error {
+	txn := m.NewTupleTxn()
+	defer txn.Discard(ctx)
+
+	if _, err := txn.Write(ctx, *tuple); err != nil {
+		return err
 	}
 
-	return nil
+	_, err := txn.Commit(ctx)
+	return err
 }
 
-// createNamespace creates a single namespace with its relations
-func (m *Manager) createNamespace(ctx context.Context, nsData dgraph.NamespaceConfigData) error {
-	// Create namespace mutation
-	now := time.Now().Format(time.RFC3339)
-
-	// Build the mutation JSON
-	namespaceUID := "_:namespace"
-	mutation := map[string]interface{}{
-		"uid":         namespaceUID,
-		"dgraph.type": "NamespaceConfig",
-		"name":        nsData.Name,
-		"created_at":  now,
-		"updated_at":  now,
-		"relations":   []interface{}{},
-	}
-
-	// Add relations
-	relations := make([]interface{}, len(nsData.Relations))
-	for i, relData := range nsData.Relations {
-		relationUID := fmt.Sprintf("_:relation_%d", i)
-		relations[i] = map[string]interface{}{
-			"uid":           relationUID,
-			"dgraph.type":   "RelationConfig",
-			"name":          relData.Name,
-			"rewrite_rules": relData.RewriteRules,
-			"namespace":     map[string]interface{}{"uid": namespaceUID},
+// GetRelationTuples returns all tuples stored for the given object and relation
+// Results are cached in Redis and invalidated whenever a matching tuple is written
+func (m *Manager) GetRelationTuples(ctx context.Context, namespace, objectID, relation string) ([]RelationTuple, error) {
+	cacheKey := tupleCacheKey(namespace, objectID, relation)
+
+	cached, err := m.Redis.Get(ctx, cacheKey)
+	if err != nil {
+		log.Printf("Warning: failed to read cache for key %s: %v", cacheKey, err)
+	} else if cached != "" {
+		var tuples []RelationTuple
+		if err := json.Unmarshal([]byte(cached), &tuples); err == nil {
+			if stats := queryStatsFrom(ctx); stats != nil {
+				stats.CacheHits.Add(1)
+			}
+			return tuples, nil
 		}
 	}
-	mutation["relations"] = relations
 
-	// Convert to JSON
-	mutationJSON, err := json.Marshal(mutation)
+	if stats := queryStatsFrom(ctx); stats != nil {
+		stats.CacheMisses.Add(1)
+	}
+
+	tuples, err := m.getRelationTuples(ctx, namespace, objectID, relation, 0)
 	if err != nil {
-		return fmt.Errorf("failed to marshal namespace mutation: %w", err)
+		return nil, err
 	}
 
-	// Execute mutation
-	mu := &api.Mutation{
-		SetJson:   mutationJSON,
-		CommitNow: true,
+	if data, err := json.Marshal(tuples); err == nil {
+		if err := m.Redis.Set(ctx, cacheKey, data, tupleCacheTTL); err != nil {
+			log.Printf("Warning: failed to cache tuples for key %s: %v", cacheKey, err)
+		}
 	}
 
-	_, err = m.Dgraph.Mutate(ctx, mu)
+	return tuples, nil
+}
+
+// getRelationTuples reads the tuples of an object relation from Dgraph,
+// reading at readTs when it is non-zero
+func (m *Manager) getRelationTuples(ctx context.Context, namespace, objectID, relation string, readTs uint64) ([]RelationTuple, error) {
+	query := `query getTuples($namespace: string, $object_id: string, $relation: string) {
+		tuples(func: eq(object_id, $object_id)) @filter(type(RelationTuple) AND eq(namespace, $namespace) AND eq(relation, $relation)) {
+			` + tupleFields + `
+		}
+	}`
+
+	vars := map[string]string{
+		"$namespace": namespace,
+		"$object_id": objectID,
+		"$relation":  relation,
+	}
+
+	resp, err := m.queryAt(ctx, query, vars, readTs)
 	if err != nil {
-		return fmt.Errorf("failed to execute namespace mutation: %w", err)
+		return nil, fmt.Errorf("failed to query tuples for %s:%s#%s: %w", namespace, objectID, relation, err)
 	}
 
-	return nil
+	var result struct {
+		Tuples []RelationTuple `json:"tuples"`
+	}
+
+	if err := json.Unmarshal(resp.Json, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal tuples result: %w", err)
+	}
+
+	return result.Tuples, nil
 }
 
-// GetNamespaceConfig retrieves a namespace configuration by name
-func (m *Manager) GetNamespaceConfig(ctx context.Context, name string) (*NamespaceConfig, error) {
-	query := `query getNamespace($name: string) {
-		namespace(func: eq(name, $name)) @filter(type(NamespaceConfig)) {
-			uid
-			name
-			created_at
-			updated_at
-			relations {
-				uid
-				name
-				rewrite_rules
-			}
+// GetTuplesByUser returns all tuples granting a relation directly to the user
+func (m *Manager) GetTuplesByUser(ctx context.Context, userID string) ([]RelationTuple, error) {
+	return m.getTuplesByUser(ctx, userID, 0)
+}
+
+// getTuplesByUser reads the tuples of a user, reading at readTs when it is non-zero
+func (m *Manager) getTuplesByUser(ctx context.Context, userID string, readTs uint64) ([]RelationTuple, error) {
+	query := `query getTuplesByUser($user_id: string) {
+		tuples(func: eq(user_id, $user_id)) @filter(type(RelationTuple) AND NOT has(userset)) {
+			` + tupleFields + `
 		}
 	}`
 
-	vars := map[string]string{"$name": name}
-	resp, err := m.Dgraph.QueryWithVars(ctx, query, vars)
+	vars := map[string]string{"$user_id": userID}
+	resp, err := m.queryAt(ctx, query, vars, readTs)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query namespace %s: %w", name, err)
+		return nil, fmt.Errorf("failed to query tuples for user %s: %w", userID, err)
 	}
 
 	var result struct {
-		Namespace []NamespaceConfig `json:"namespace"`
+		Tuples []RelationTuple `json:"tuples"`
 	}
 
 	if err := json.Unmarshal(resp.Json, &result); err != nil {
-		return nil, fmt.Errorf("failed to unmarshal namespace result: %w", err)
+		return nil, fmt.Errorf("failed to unmarshal tuples result: %w", err)
 	}
 
-	if len(result.Namespace) == 0 {
-		return nil, fmt.Errorf("namespace %s not found", name)
-	}
+	return result.Tuples, nil
+}
 
-	return &result.Namespace[0], nil
+// GetTuplesByUsersetObject returns all tuples whose userset references the
+// object, either as namespace:object or as namespace:object#relation
+func (m *Manager) GetTuplesByUsersetObject(ctx context.Context, namespace, objectID string) ([]RelationTuple, error) {
+	return m.getTuplesByUsersetObject(ctx, namespace, objectID, 0)
 }
 
-// CreateRelationTuple creates a new relation tuple
-func (m *Manager) CreateRelationTuple(ctx context.Context, tuple *RelationTuple) error {
-	// Check if tuple already exists
-	query := `query checkTuple($namespace: string, $object_id: string, $relation: string, $user_id: string) {
-		tuple(func: eq(namespace, $namespace)) @filter(type(RelationTuple) AND eq(object_id, $object_id) AND eq(relation, $relation) AND eq(user_id, $user_id)) {
-			uid
+// getTuplesByUsersetObject reads the tuples referencing an object, reading at
+// readTs when it is non-zero
+func (m *Manager) getTuplesByUsersetObject(ctx context.Context, namespace, objectID string, readTs uint64) ([]RelationTuple, error) {
+	// '$' sorts directly after '#', bounding every namespace:object#relation value
+	object := namespace + ":" + objectID
+	query := `query getTuplesByUserset($object: string, $from: string, $to: string) {
+		exact as var(func: eq(userset, $object))
+		related as var(func: ge(userset, $from)) @filter(lt(userset, $to))
+
+		tuples(func: uid(exact, related)) @filter(type(RelationTuple)) {
+			` + tupleFields + `
 		}
 	}`
 
 	vars := map[string]string{
-		"$namespace": tuple.Namespace,
-		"$object_id": tuple.ObjectID,
-		"$relation":  tuple.Relation,
-		"$user_id":   tuple.UserID,
+		"$object": object,
+		"$from":   object + "#",
+		"$to":     object + "$",
 	}
 
-	resp, err := m.Dgraph.QueryWithVars(ctx, query, vars)
+	resp, err := m.queryAt(ctx, query, vars, readTs)
 	if err != nil {
-		return fmt.Errorf("failed to check existing tuple: %w", err)
+		return nil, fmt.Errorf("failed to query tuples referencing %s: %w", object, err)
 	}
 
 	var result struct {
-		Tuple []struct {
-			UID string `json:"uid"`
-		} `json:"tuple"`
+		Tuples []RelationTuple `json:"tuples"`
 	}
 
 	if err := json.Unmarshal(resp.Json, &result); err != nil {
-		return fmt.Errorf("failed to unmarshal tuple check result: %w", err)
+		return nil, fmt.Errorf("failed to unmarshal tuples result: %w", err)
 	}
 
-	// If tuple exists, update it
-	var uid string
-	if len(result.Tuple) > 0 {
-		uid = result.Tuple[0].UID
-	} else {
-		uid = "_:tuple"
-	}
+	return result.Tuples, nil
+}
 
-	// Create mutation
-	now := time.Now().Format(time.RFC3339)
-	mutation := map[string]interface{}{
-		"uid":         uid,
-		"dgraph.type": "RelationTuple",
-		"namespace":   tuple.Namespace,
-		"object_id":   tuple.ObjectID,
-		"relation":    tuple.Relation,
-		"user_id":     tuple.UserID,
-		"updated_at":  now,
-	}
+// ListNamespaceConfigs returns every namespace configuration ordered by name
+func (m *Manager) ListNamespaceConfigs(ctx context.Context) ([]NamespaceConfig, error) {
+	return m.listNamespaceConfigs(ctx, 0)
+}
+
+// ListNamespaceConfigsAt returns every namespace configuration ordered by
+// name, read at readTs when it is non-zero
+func (m *Manager) ListNamespaceConfigsAt(ctx context.Context, readTs uint64) ([]NamespaceConfig, error) {
+	return m.listNamespaceConfigs(ctx, readTs)
+}
+
+// listNamespaceConfigs reads every namespace configuration, reading at readTs
+// when it is non-zero
+func (m *Manager) listNamespaceConfigs(ctx context.Context, readTs uint64) ([]NamespaceConfig, error) {
+	query := `{
+		namespaces(func: type(NamespaceConfig)) {
+			` + namespaceFields + `
+		}
+	}`
 
-	if tuple.Userset != "" {
-		mutation["userset"] = tuple.Userset
+	resp, err := m.queryAt(ctx, query, nil, readTs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query namespaces: %w", err)
 	}
 
-	if uid == "_:tuple" {
-		mutation["created_at"] = now
+	var result struct {
+		Namespaces []NamespaceConfig `json:"namespaces"`
 	}
 
-	// Convert to JSON
-	mutationJSON, err := json.Marshal(mutation)
-	if err != nil {
-		return fmt.Errorf("failed to marshal tuple mutation: %w", err)
+	if err := json.Unmarshal(resp.Json, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal namespaces result: %w", err)
 	}
 
-	// Execute mutation
-	mu := &api.Mutation{
-		SetJson:   mutationJSON,
-		CommitNow: true,
+	sort.Slice(result.Namespaces, func(i, j int) bool {
+		return result.Namespaces[i].Name < result.Namespaces[j].Name
+	})
+	for i := range result.Namespaces {
+		result.Namespaces[i].Version = storedVersion(result.Namespaces[i].Version)
 	}
 
-	_, err = m.Dgraph.Mutate(ctx, mu)
-	if err != nil {
-		return fmt.Errorf("failed to execute tuple mutation: %w", err)
+	return result.Namespaces, nil
+}
+
+// queryAt runs a read-only query, pinned to readTs when it is non-zero
+func (m *Manager) queryAt(ctx context.Context, query string, vars map[string]string, readTs uint64) (*api.Response, error) {
+	if stats := queryStatsFrom(ctx); stats != nil {
+		stats.DgraphQueries.Add(1)
 	}
 
-	// Invalidate related cache entries
-	cacheKey := fmt.Sprintf("tuple:%s:%s:%s", tuple.Namespace, tuple.ObjectID, tuple.Relation)
-	if err := m.Redis.Del(ctx, cacheKey); err != nil {
-		log.Printf("Warning: failed to invalidate cache for key %s: %v", cacheKey, err)
+	if readTs == 0 {
+		return m.Dgraph.QueryWithVars(ctx, query, vars)
 	}
+	return m.Dgraph.QueryWithVarsAt(ctx, query, vars, readTs)
+}
 
-	return nil
+// tupleCacheKey returns the Redis key caching the tuples of an object relation
+func tupleCacheKey(namespace, objectID, relation string) string {
+	return fmt.Sprintf("tuple:%s:%s:%s", namespace, objectID, relation)
 }
 
 // NamespaceConfig represents a namespace configuration
 type NamespaceConfig struct {
-	UID       string           `json:"uid"`
-	Name      string           `json:"name"`
+	UID  string `json:"uid"`
+	Name string `json:"name"`
+	// Version is incremented by every write of the configuration
+	Version   int64            `json:"version"`
 	CreatedAt string           `json:"created_at"`
 	UpdatedAt string           `json:"updated_at"`
 	Relations []RelationConfig `json:"relations"`
@@ -340,6 +400,8 @@ type RelationConfig struct {
 	UID          string `json:"uid"`
 	Name         string `json:"name"`
 	RewriteRules string `json:"rewrite_rules"`
+	// AllowWildcard permits tuples granting the relation to every user of a type
+	AllowWildcard bool `json:"allow_wildcard"`
 }
 
 // RelationTuple represents a relation tuple
@@ -350,6 +412,20 @@ type RelationTuple struct {
 	Relation  string `json:"relation"`
 	UserID    string `json:"user_id"`
 	Userset   string `json:"userset,omitempty"`
+	// Conditions is an optional expression that must hold for the tuple to apply
+	Conditions string `json:"conditions,omitempty"`
+	// ExpiresAt is an optional RFC 3339 time after which the tuple no longer applies
+	ExpiresAt string `json:"expires_at,omitempty"`
 	CreatedAt string `json:"created_at,omitempty"`
 	UpdatedAt string `json:"updated_at,omitempty"`
 }
+
+// Expired reports whether the tuple has an expiry at or before now
+func (t RelationTuple) Expired(now time.Time) bool {
+	if t.ExpiresAt == "" {
+		return false
+	}
+
+	expiresAt, err := time.Parse(time.RFC3339Nano, t.ExpiresAt)
+	return err == nil && !now.Before(expiresAt)
+}
@@ -0,0 +1,505 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/dgo/v240"
+	"github.com/dgraph-io/dgo/v240/protos/api"
+)
+
+var (
+	// ErrAlreadyExists is returned when creating a record that already exists
+	ErrAlreadyExists = errors.New("already exists")
+
+	// ErrVersionMismatch is returned when a namespace configuration is not at
+	// the version a change expected
+	ErrVersionMismatch = errors.New("version mismatch")
+
+	// ErrNamespaceInUse is returned when deleting a namespace that still has
+	// relation tuples
+	ErrNamespaceInUse = errors.New("namespace in use")
+)
+
+// namespaceDeleteBatch is the number of tuples removed per transaction when
+// a namespace is deleted together with its tuples
+const namespaceDeleteBatch = 1000
+
+// NamespacePage is one page of namespace configurations ordered by name
+type NamespacePage struct {
+	Configs []NamespaceConfig
+
+	// After is the name to continue from, empty on the last page
+	After string
+
+	// ReadTs is the Dgraph timestamp the page was read at
+	ReadTs uint64
+}
+
+// NamespaceWrite describes a write of a namespace configuration
+type NamespaceWrite struct {
+	Config NamespaceConfig
+
+	// AllowUpdate permits replacing a stored configuration
+	AllowUpdate bool
+
+	// ExpectedVersion is the stored version an update replaces, zero when the
+	// namespace is expected not to exist yet
+	ExpectedVersion int64
+
+	// Author is recorded in the revision the write creates
+	Author string
+
+	// Check, when set, is called before an update with the stored
+	// configuration and the live tuple counts of its relations, read in the
+	// write transaction; an error it returns fails the write as is
+	// Tuples written concurrently with the update are not counted
+	Check func(stored NamespaceConfig, tuples map[string]int) error
+}
+
+// NamespaceDeletion reports the outcome of deleting a namespace
+type NamespaceDeletion struct {
+	// DeletedTuples is the number of relation tuples deleted with the namespace
+	DeletedTuples int
+
+	// CommitTs is the Dgraph timestamp of the transaction deleting the namespace
+	CommitTs uint64
+}
+
+// ReadNamespaceConfigs returns up to limit namespace configurations named
+// after the given name, read at readTs when it is non-zero
+func (m *Manager) ReadNamespaceConfigs(ctx context.Context, after string, limit int, readTs uint64) (*NamespacePage, error) {
+	query := `{
+		namespaces(func: type(NamespaceConfig)) {
+			` + namespaceFields + `
+		}
+	}`
+
+	resp, err := m.queryAt(ctx, query, nil, readTs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query namespaces: %w", err)
+	}
+
+	var result struct {
+		Namespaces []NamespaceConfig `json:"namespaces"`
+	}
+	if err := json.Unmarshal(resp.Json, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal namespaces result: %w", err)
+	}
+
+	sort.Slice(result.Namespaces, func(i, j int) bool {
+		return result.Namespaces[i].Name < result.Namespaces[j].Name
+	})
+	start := sort.Search(len(result.Namespaces), func(i int) bool {
+		return result.Namespaces[i].Name > after
+	})
+
+	page := &NamespacePage{ReadTs: readTs}
+	if page.ReadTs == 0 {
+		page.ReadTs = resp.GetTxn().GetStartTs()
+	}
+
+	configs := result.Namespaces[start:]
+	if len(configs) > limit {
+		configs = configs[:limit]
+		page.After = configs[limit-1].Name
+	}
+	for _, config := range configs {
+		config.Version = storedVersion(config.Version)
+		page.Configs = append(page.Configs, config)
+	}
+
+	return page, nil
+}
+
+// WriteNamespaceConfig creates a namespace configuration, or replaces the
+// stored one when the write allows updates and expects its version
+// Every write records a revision of the configuration it writes
+// It returns the written configuration and the commit timestamp
+func (m *Manager) WriteNamespaceConfig(ctx context.Context, write NamespaceWrite) (*NamespaceConfig, uint64, error) {
+	name := write.Config.Name
+
+	txn := m.Dgraph.NewTransaction()
+	defer txn.Discard(ctx)
+
+	stored, err := storedNamespace(ctx, txn, name)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var current int64
+	if stored != nil {
+		if !write.AllowUpdate {
+			return nil, 0, fmt.Errorf("namespace %s %w", name, ErrAlreadyExists)
+		}
+		current = stored.Version
+	}
+	if write.AllowUpdate && write.ExpectedVersion != current {
+		return nil, 0, versionMismatch(name, write.ExpectedVersion, current)
+	}
+
+	if stored != nil && write.Check != nil {
+		tuples, err := namespaceTupleCounts(ctx, txn, name)
+		if err != nil {
+			return nil, 0, err
+		}
+		if err := write.Check(*stored, tuples); err != nil {
+			return nil, 0, err
+		}
+	}
+
+	now := time.Now().Format(time.RFC3339)
+	written := write.Config
+	written.Version = current + 1
+	written.CreatedAt = now
+	written.UpdatedAt = now
+
+	uid := "_:namespace"
+	mutation := &api.Mutation{}
+	var nodes []interface{}
+	if stored != nil {
+		uid = stored.UID
+		written.CreatedAt = stored.CreatedAt
+
+		// A configuration written before revisions were recorded gets its
+		// revision now, so the write can be rolled back
+		recorded, err := revisionVersions(ctx, txn, name)
+		if err != nil {
+			return nil, 0, err
+		}
+		if !recorded[stored.Version] {
+			node, err := revisionNode("_:previous", currentRevision(*stored))
+			if err != nil {
+				return nil, 0, err
+			}
+			nodes = append(nodes, node)
+		}
+
+		// The relations are replaced as a whole
+		removed := []string{fmt.Sprintf("<%s> <relations> * .", stored.UID)}
+		for _, rel := range stored.Relations {
+			removed = append(removed, fmt.Sprintf("<%s> * * .", rel.UID))
+		}
+		mutation.DelNquads = []byte(strings.Join(removed, "\n"))
+	}
+
+	revision, err := revisionNode("_:revision", NamespaceRevision{
+		Namespace: name,
+		Version:   written.Version,
+		Author:    write.Author,
+		CreatedAt: now,
+		Relations: written.Relations,
+	})
+	if err != nil {
+		return nil, 0, err
+	}
+	nodes = append(nodes, namespaceNode(uid, written), revision)
+
+	setJSON, err := json.Marshal(nodes)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to marshal namespace mutation: %w", err)
+	}
+	mutation.SetJson = setJSON
+
+	resp, err := txn.Do(ctx, &api.Request{
+		Mutations: []*api.Mutation{mutation},
+		CommitNow: true,
+	})
+	if err != nil {
+		if errors.Is(err, dgo.ErrAborted) {
+			return nil, 0, fmt.Errorf("%w: %v", ErrConflict, err)
+		}
+		return nil, 0, fmt.Errorf("failed to write namespace %s: %w", name, err)
+	}
+
+	written.UID = uid
+	if stored == nil {
+		written.UID = resp.GetUids()["namespace"]
+	}
+	for i := range written.Relations {
+		written.Relations[i].UID = resp.GetUids()[fmt.Sprintf("relation_%d", i)]
+	}
+
+	return &written, resp.GetTxn().GetCommitTs(), nil
+}
+
+// DeleteNamespaceConfig deletes a namespace configuration
+// A namespace that still has live tuples is only deleted when force is set,
+// in which case its tuples are deleted first, in batches, and the last batch
+// is deleted together with the configuration
+// A non-zero expectedVersion must match the stored version
+// The revisions of the namespace are deleted with it
+func (m *Manager) DeleteNamespaceConfig(ctx context.Context, name string, force bool, expectedVersion int64) (*NamespaceDeletion, error) {
+	result := &NamespaceDeletion{}
+	for {
+		done, err := m.deleteNamespaceBatch(ctx, name, force, expectedVersion, result)
+		if err != nil {
+			return nil, err
+		}
+		if done {
+			return result, nil
+		}
+	}
+}
+
+// deleteNamespaceBatch deletes one batch of the tuples of a namespace, and
+// the namespace configuration once no tuples are left
+// It reports whether the configuration was deleted
+func (m *Manager) deleteNamespaceBatch(ctx context.Context, name string, force bool, expectedVersion int64, result *NamespaceDeletion) (bool, error) {
+	params := "$name: string, $limit: int"
+	tupleFilter := "type(RelationTuple)"
+	vars := map[string]string{
+		"$name":  name,
+		"$limit": strconv.Itoa(namespaceDeleteBatch),
+	}
+
+	// Tuples that have expired but were not reaped yet do not keep a
+	// namespace in use
+	if !force {
+		params += ", $now: string"
+		tupleFilter += " AND (NOT has(expires_at) OR gt(expires_at, $now))"
+		vars["$now"] = time.Now().UTC().Format(time.RFC3339Nano)
+	}
+
+	query := `query namespaceTuples(` + params + `) {
+		namespace(func: eq(name, $name)) @filter(type(NamespaceConfig)) {
+			uid
+			version
+			relations {
+				uid
+			}
+		}
+
+		revisions(func: eq(namespace, $name)) @filter(type(NamespaceRevision)) {
+			uid
+		}
+
+		tuples(func: eq(namespace, $name), first: $limit) @filter(` + tupleFilter + `) {
+			` + tupleFields + `
+		}
+	}`
+
+	txn := m.Dgraph.NewTransaction()
+	defer txn.Discard(ctx)
+
+	resp, err := txn.QueryWithVars(ctx, query, vars)
+	if err != nil {
+		return false, fmt.Errorf("failed to query namespace %s: %w", name, err)
+	}
+
+	var stored struct {
+		Namespace []NamespaceConfig `json:"namespace"`
+		Revisions []struct {
+			UID string `json:"uid"`
+		} `json:"revisions"`
+		Tuples []RelationTuple `json:"tuples"`
+	}
+	if err := json.Unmarshal(resp.Json, &stored); err != nil {
+		return false, fmt.Errorf("failed to unmarshal namespace result: %w", err)
+	}
+
+	if len(stored.Namespace) == 0 {
+		return false, fmt.Errorf("namespace %s %w", name, ErrNotFound)
+	}
+	namespace := stored.Namespace[0]
+	if version := storedVersion(namespace.Version); expectedVersion != 0 && expectedVersion != version {
+		return false, versionMismatch(name, expectedVersion, version)
+	}
+	if len(stored.Tuples) > 0 && !force {
+		return false, fmt.Errorf("%w: namespace %s still has relation tuples", ErrNamespaceInUse, name)
+	}
+
+	var removed []string
+	for _, tuple := range stored.Tuples {
+		removed = append(removed, fmt.Sprintf("<%s> * * .", tuple.UID))
+	}
+
+	// A full batch may not be the last one, so the configuration is kept
+	// until a batch comes back short
+	done := len(stored.Tuples) < namespaceDeleteBatch
+	if done {
+		removed = append(removed, fmt.Sprintf("<%s> * * .", namespace.UID))
+		for _, rel := range namespace.Relations {
+			removed = append(removed, fmt.Sprintf("<%s> * * .", rel.UID))
+		}
+		for _, revision := range stored.Revisions {
+			removed = append(removed, fmt.Sprintf("<%s> * * .", revision.UID))
+		}
+	}
+
+	mutations := []*api.Mutation{{DelNquads: []byte(strings.Join(removed, "\n"))}}
+	changes := make([]Change, len(stored.Tuples))
+	for i, tuple := range stored.Tuples {
+		changes[i] = Change{Type: ChangeDeleted, Tuple: tuple}
+	}
+	startTs := resp.GetTxn().GetStartTs()
+	if len(changes) > 0 {
+		batch, err := stageChanges(startTs, changes)
+		if err != nil {
+			return false, err
+		}
+		mutations = append(mutations, batch)
+	}
+
+	mutResp, err := txn.Do(ctx, &api.Request{
+		Mutations: mutations,
+		CommitNow: true,
+	})
+	if err != nil {
+		if errors.Is(err, dgo.ErrAborted) {
+			return false, fmt.Errorf("%w: %v", ErrConflict, err)
+		}
+		return false, fmt.Errorf("failed to delete namespace %s: %w", name, err)
+	}
+	result.CommitTs = mutResp.GetTxn().GetCommitTs()
+	result.DeletedTuples += len(stored.Tuples)
+
+	if len(changes) > 0 {
+		m.publishChanges(ctx, startTs, result.CommitTs)
+	}
+
+	cacheKeys := make(map[string]bool)
+	for _, tuple := range stored.Tuples {
+		cacheKeys[tupleCacheKey(tuple.Namespace, tuple.ObjectID, tuple.Relation)] = true
+	}
+
+	// Invalidate the cached tuple lists the deleted tuples belonged to
+	for cacheKey := range cacheKeys {
+		if err := m.Redis.Del(ctx, cacheKey); err != nil {
+			log.Printf("Warning: failed to invalidate cache for key %s: %v", cacheKey, err)
+		}
+	}
+
+	return done, nil
+}
+
+// CountNamespaceTuples returns the number of live tuples of every relation
+// of a namespace that has any
+func (m *Manager) CountNamespaceTuples(ctx context.Context, name string) (map[string]int, error) {
+	txn := m.Dgraph.NewReadOnlyTransaction()
+	defer txn.Discard(ctx)
+
+	return namespaceTupleCounts(ctx, txn, name)
+}
+
+// namespaceTupleCounts counts the live tuples of every relation of a
+// namespace within a transaction
+func namespaceTupleCounts(ctx context.Context, txn *dgo.Txn, name string) (map[string]int, error) {
+	query := `query namespaceTupleCounts($name: string, $now: string) {
+		tuples(func: eq(namespace, $name)) @filter(type(RelationTuple) AND (NOT has(expires_at) OR gt(expires_at, $now))) @groupby(relation) {
+			count(uid)
+		}
+	}`
+
+	vars := map[string]string{
+		"$name": name,
+		"$now":  time.Now().UTC().Format(time.RFC3339Nano),
+	}
+	resp, err := txn.QueryWithVars(ctx, query, vars)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count tuples of namespace %s: %w", name, err)
+	}
+
+	var result struct {
+		Tuples []struct {
+			Groups []struct {
+				Relation string `json:"relation"`
+				Count    int    `json:"count"`
+			} `json:"@groupby"`
+		} `json:"tuples"`
+	}
+	if err := json.Unmarshal(resp.Json, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal tuple counts: %w", err)
+	}
+
+	counts := make(map[string]int)
+	for _, tuples := range result.Tuples {
+		for _, group := range tuples.Groups {
+			counts[group.Relation] += group.Count
+		}
+	}
+	return counts, nil
+}
+
+// storedNamespace reads a namespace configuration within a transaction, or
+// returns nil when it does not exist
+func storedNamespace(ctx context.Context, txn *dgo.Txn, name string) (*NamespaceConfig, error) {
+	query := `query storedNamespace($name: string) {
+		namespace(func: eq(name, $name)) @filter(type(NamespaceConfig)) {
+			` + namespaceFields + `
+		}
+	}`
+
+	resp, err := txn.QueryWithVars(ctx, query, map[string]string{"$name": name})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query namespace %s: %w", name, err)
+	}
+
+	var result struct {
+		Namespace []NamespaceConfig `json:"namespace"`
+	}
+	if err := json.Unmarshal(resp.Json, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal namespace result: %w", err)
+	}
+
+	if len(result.Namespace) == 0 {
+		return nil, nil
+	}
+
+	config := result.Namespace[0]
+	config.Version = storedVersion(config.Version)
+	return &config, nil
+}
+
+// namespaceNode builds the mutation JSON of a namespace configuration and
+// its relations, which are always created as new nodes
+func namespaceNode(uid string, config NamespaceConfig) map[string]interface{} {
+	relations := make([]interface{}, len(config.Relations))
+	for i, rel := range config.Relations {
+		relations[i] = map[string]interface{}{
+			"uid":            fmt.Sprintf("_:relation_%d", i),
+			"dgraph.type":    "RelationConfig",
+			"name":           rel.Name,
+			"rewrite_rules":  rel.RewriteRules,
+			"allow_wildcard": rel.AllowWildcard,
+			"namespace":      map[string]interface{}{"uid": uid},
+		}
+	}
+
+	return map[string]interface{}{
+		"uid":         uid,
+		"dgraph.type": "NamespaceConfig",
+		"name":        config.Name,
+		"version":     config.Version,
+		"created_at":  config.CreatedAt,
+		"updated_at":  config.UpdatedAt,
+		"relations":   relations,
+	}
+}
+
+// storedVersion returns the version of a stored configuration
+// Configurations written before versions were tracked count as version 1
+func storedVersion(version int64) int64 {
+	if version == 0 {
+		return 1
+	}
+	return version
+}
+
+// versionMismatch reports a namespace that is not at the expected version
+func versionMismatch(name string, expected, actual int64) error {
+	switch {
+	case actual == 0:
+		return fmt.Errorf("%w: namespace %s does not exist, expected version %d", ErrVersionMismatch, name, expected)
+	case expected == 0:
+		return fmt.Errorf("%w: namespace %s already exists at version %d", ErrVersionMismatch, name, actual)
+	}
+	return fmt.Errorf("%w: namespace %s is at version %d, expected %d", ErrVersionMismatch, name, actual, expected)
+}
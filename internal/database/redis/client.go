@@ -231,6 +231,16 @@ func (c *Client) Set(ctx context.Context, key string, value interface{}, expirat
 	return nil
 }
 
+// SetNX stores a key-value pair with optional expiration unless the key
+// exists, reporting whether it was stored
+func (c *Client) SetNX(ctx context.Context, key string, value interface{}, expiration time.Duration) (bool, error) {
+	result := c.client.SetNX(ctx, key, value, expiration)
+	if err := result.Err(); err != nil {
+		return false, fmt.Errorf("failed to set key %s: %w", key, err)
+	}
+	return result.Val(), nil
+}
+
 // Del deletes one or more keys
 func (c *Client) Del(ctx context.Context, keys ...string) error {
 	if err := c.client.Del(ctx, keys...).Err(); err != nil {
@@ -334,6 +344,61 @@ func (c *Client) Subscribe(ctx context.Context, channels ...string) *redis.PubSu
 	return c.client.Subscribe(ctx, channels...)
 }
 
+// XRead returns up to count stream entries after the given ID, waiting up to
+// block for new entries when there are none
+func (c *Client) XRead(ctx context.Context, stream, after string, count int64, block time.Duration) ([]redis.XMessage, error) {
+	result := c.client.XRead(ctx, &redis.XReadArgs{
+		Streams: []string{stream, after},
+		Count:   count,
+		Block:   block,
+	})
+	if err := result.Err(); err != nil {
+		if err == redis.Nil {
+			return nil, nil // No new entries
+		}
+		return nil, fmt.Errorf("failed to xread stream %s: %w", stream, err)
+	}
+
+	var messages []redis.XMessage
+	for _, s := range result.Val() {
+		messages = append(messages, s.Messages...)
+	}
+	return messages, nil
+}
+
+// MGet returns the values of several keys, empty for keys that do not exist
+func (c *Client) MGet(ctx context.Context, keys ...string) ([]string, error) {
+	result := c.client.MGet(ctx, keys...)
+	if err := result.Err(); err != nil {
+		return nil, fmt.Errorf("failed to mget keys %v: %w", keys, err)
+	}
+
+	values := make([]string, len(keys))
+	for i, value := range result.Val() {
+		values[i], _ = value.(string)
+	}
+	return values, nil
+}
+
+// ZRevRangeByScore returns up to count members of a sorted set with scores
+// between min and max, highest score first
+func (c *Client) ZRevRangeByScore(ctx context.Context, key, min, max string, count int64) ([]string, error) {
+	result := c.client.ZRevRangeByScore(ctx, key, &redis.ZRangeBy{Min: min, Max: max, Count: count})
+	if err := result.Err(); err != nil {
+		return nil, fmt.Errorf("failed to zrevrangebyscore key %s: %w", key, err)
+	}
+	return result.Val(), nil
+}
+
+// XInfoStream returns information about a stream
+func (c *Client) XInfoStream(ctx context.Context, stream string) (*redis.XInfoStream, error) {
+	result := c.client.XInfoStream(ctx, stream)
+	if err := result.Err(); err != nil {
+		return nil, fmt.Errorf("failed to xinfo stream %s: %w", stream, err)
+	}
+	return result.Val(), nil
+}
+
 // FlushPattern deletes all keys matching a pattern
 func (c *Client) FlushPattern(ctx context.Context, pattern string) error {
 	iter := c.client.Scan(ctx, 0, pattern, 0).Iterator()
@@ -0,0 +1,213 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/dgraph-io/dgo/v240"
+)
+
+// revisionFields lists the predicates selected when reading namespace revisions
+const revisionFields = `namespace
+			version
+			author
+			created_at
+			revision_relations`
+
+// NamespaceRevision is an immutable record of one write of a namespace
+// configuration
+type NamespaceRevision struct {
+	Namespace string
+	Version   int64
+	Author    string
+	CreatedAt string
+	Relations []RelationConfig
+}
+
+// Config returns the namespace configuration as the revision wrote it
+func (r NamespaceRevision) Config() NamespaceConfig {
+	return NamespaceConfig{
+		Name:      r.Namespace,
+		Version:   r.Version,
+		UpdatedAt: r.CreatedAt,
+		Relations: r.Relations,
+	}
+}
+
+// RevisionPage is one page of namespace revisions, newest first
+type RevisionPage struct {
+	Revisions []NamespaceRevision
+
+	// Before is the version to continue below, zero on the last page
+	Before int64
+}
+
+// storedRevision is a namespace revision as stored in Dgraph, with its
+// relations kept as a JSON snapshot so later writes cannot change them
+type storedRevision struct {
+	Namespace string `json:"namespace"`
+	Version   int64  `json:"version"`
+	Author    string `json:"author"`
+	CreatedAt string `json:"created_at"`
+	Relations string `json:"revision_relations"`
+}
+
+// ReadNamespaceRevisions returns up to limit revisions of a namespace with
+// a version below before, or the newest ones when before is zero
+func (m *Manager) ReadNamespaceRevisions(ctx context.Context, name string, before int64, limit int) (*RevisionPage, error) {
+	revisions, err := m.namespaceRevisions(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	start := 0
+	if before > 0 {
+		start = sort.Search(len(revisions), func(i int) bool {
+			return revisions[i].Version < before
+		})
+	}
+
+	page := &RevisionPage{Revisions: revisions[start:]}
+	if len(page.Revisions) > limit {
+		page.Revisions = page.Revisions[:limit]
+		page.Before = page.Revisions[limit-1].Version
+	}
+	return page, nil
+}
+
+// ReadNamespaceRevision returns the revision of a namespace at a version
+func (m *Manager) ReadNamespaceRevision(ctx context.Context, name string, version int64) (*NamespaceRevision, error) {
+	revisions, err := m.namespaceRevisions(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, revision := range revisions {
+		if revision.Version == version {
+			return &revision, nil
+		}
+	}
+	return nil, fmt.Errorf("revision %d of namespace %s %w", version, name, ErrNotFound)
+}
+
+// namespaceRevisions reads every revision of a namespace, newest first
+// A configuration written before revisions were recorded is reported as a
+// revision of its current version without an author
+func (m *Manager) namespaceRevisions(ctx context.Context, name string) ([]NamespaceRevision, error) {
+	query := `query namespaceRevisions($name: string) {
+		namespace(func: eq(name, $name)) @filter(type(NamespaceConfig)) {
+			` + namespaceFields + `
+		}
+
+		revisions(func: eq(namespace, $name)) @filter(type(NamespaceRevision)) {
+			` + revisionFields + `
+		}
+	}`
+
+	resp, err := m.Dgraph.QueryWithVars(ctx, query, map[string]string{"$name": name})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query revisions of namespace %s: %w", name, err)
+	}
+
+	var result struct {
+		Namespace []NamespaceConfig `json:"namespace"`
+		Revisions []storedRevision  `json:"revisions"`
+	}
+	if err := json.Unmarshal(resp.Json, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal revisions result: %w", err)
+	}
+
+	if len(result.Namespace) == 0 {
+		return nil, fmt.Errorf("namespace %s %w", name, ErrNotFound)
+	}
+	current := result.Namespace[0]
+	current.Version = storedVersion(current.Version)
+
+	revisions := make([]NamespaceRevision, 0, len(result.Revisions)+1)
+	recorded := false
+	for _, stored := range result.Revisions {
+		revision := NamespaceRevision{
+			Namespace: stored.Namespace,
+			Version:   stored.Version,
+			Author:    stored.Author,
+			CreatedAt: stored.CreatedAt,
+		}
+		if err := json.Unmarshal([]byte(stored.Relations), &revision.Relations); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal relations of revision %d of namespace %s: %w", stored.Version, name, err)
+		}
+		revisions = append(revisions, revision)
+		recorded = recorded || stored.Version == current.Version
+	}
+	if !recorded {
+		revisions = append(revisions, currentRevision(current))
+	}
+
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Version > revisions[j].Version
+	})
+	return revisions, nil
+}
+
+// revisionVersions reads the versions of the recorded revisions of a
+// namespace within a transaction
+func revisionVersions(ctx context.Context, txn *dgo.Txn, name string) (map[int64]bool, error) {
+	query := `query revisionVersions($name: string) {
+		revisions(func: eq(namespace, $name)) @filter(type(NamespaceRevision)) {
+			version
+		}
+	}`
+
+	resp, err := txn.QueryWithVars(ctx, query, map[string]string{"$name": name})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query revisions of namespace %s: %w", name, err)
+	}
+
+	var result struct {
+		Revisions []storedRevision `json:"revisions"`
+	}
+	if err := json.Unmarshal(resp.Json, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal revisions result: %w", err)
+	}
+
+	versions := make(map[int64]bool, len(result.Revisions))
+	for _, revision := range result.Revisions {
+		versions[revision.Version] = true
+	}
+	return versions, nil
+}
+
+// currentRevision describes a stored configuration as the revision that
+// wrote it, for configurations written before revisions were recorded
+func currentRevision(config NamespaceConfig) NamespaceRevision {
+	return NamespaceRevision{
+		Namespace: config.Name,
+		Version:   config.Version,
+		CreatedAt: config.UpdatedAt,
+		Relations: config.Relations,
+	}
+}
+
+// revisionNode builds the mutation JSON of a revision
+func revisionNode(uid string, revision NamespaceRevision) (map[string]interface{}, error) {
+	relations := make([]RelationConfig, len(revision.Relations))
+	for i, rel := range revision.Relations {
+		rel.UID = ""
+		relations[i] = rel
+	}
+	snapshot, err := json.Marshal(relations)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal revision relations: %w", err)
+	}
+
+	return map[string]interface{}{
+		"uid":                uid,
+		"dgraph.type":        "NamespaceRevision",
+		"namespace":          revision.Namespace,
+		"version":            revision.Version,
+		"author":             revision.Author,
+		"created_at":         revision.CreatedAt,
+		"revision_relations": string(snapshot),
+	}, nil
+}
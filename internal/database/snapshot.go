@@ -0,0 +1,118 @@
+package database
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// consistencyTokenPrefix versions the encoding of consistency tokens
+const consistencyTokenPrefix = "v1:"
+
+// ErrInvalidConsistencyToken is returned when a consistency token cannot be decoded
+var ErrInvalidConsistencyToken = errors.New("invalid consistency token")
+
+// EncodeConsistencyToken returns the opaque consistency token for a Dgraph timestamp
+func EncodeConsistencyToken(ts uint64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(consistencyTokenPrefix + strconv.FormatUint(ts, 10)))
+}
+
+// DecodeConsistencyToken returns the Dgraph timestamp encoded in a consistency token
+// Tokens handed out by the change stream are accepted as well
+func DecodeConsistencyToken(token string) (uint64, error) {
+	ts, _, err := DecodeChangeToken(token)
+	return ts, err
+}
+
+// EncodeChangeToken returns the consistency token of a recorded change,
+// which also carries the change's position in the change log so a watch
+// can resume right after it
+func EncodeChangeToken(ts uint64, position string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(consistencyTokenPrefix + strconv.FormatUint(ts, 10) + ":" + position))
+}
+
+// DecodeChangeToken returns the Dgraph timestamp and change log position
+// encoded in a consistency token
+// The position is empty for tokens that do not come from the change stream
+func DecodeChangeToken(token string) (uint64, string, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return 0, "", ErrInvalidConsistencyToken
+	}
+
+	value, ok := strings.CutPrefix(string(data), consistencyTokenPrefix)
+	if !ok {
+		return 0, "", ErrInvalidConsistencyToken
+	}
+	value, position, _ := strings.Cut(value, ":")
+
+	ts, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || ts == 0 {
+		return 0, "", ErrInvalidConsistencyToken
+	}
+	if position != "" && !validStreamID(position) {
+		return 0, "", ErrInvalidConsistencyToken
+	}
+
+	return ts, position, nil
+}
+
+// Snapshot reads namespace configurations and relation tuples as of a fixed
+// Dgraph read timestamp
+// Reads through a snapshot bypass the Redis cache, which only tracks the latest data
+type Snapshot struct {
+	manager *Manager
+	readTs  uint64
+}
+
+// Snapshot returns a reader pinned to the given read timestamp
+func (m *Manager) Snapshot(readTs uint64) *Snapshot {
+	return &Snapshot{manager: m, readTs: readTs}
+}
+
+// ReadTimestamp returns the timestamp of the latest data, at which a
+// snapshot sees every commit made so far
+func (m *Manager) ReadTimestamp(ctx context.Context) (uint64, error) {
+	resp, err := m.Dgraph.NewReadOnlyTransaction().Query(ctx, `{
+		latest(func: uid(0x1)) {
+			uid
+		}
+	}`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read the latest timestamp: %w", err)
+	}
+	return resp.GetTxn().GetStartTs(), nil
+}
+
+// ReadTs returns the read timestamp of the snapshot
+func (s *Snapshot) ReadTs() uint64 {
+	return s.readTs
+}
+
+// GetNamespaceConfig retrieves a namespace configuration by name
+func (s *Snapshot) GetNamespaceConfig(ctx context.Context, name string) (*NamespaceConfig, error) {
+	return s.manager.getNamespaceConfig(ctx, name, s.readTs)
+}
+
+// ListNamespaceConfigs returns every namespace configuration ordered by name
+func (s *Snapshot) ListNamespaceConfigs(ctx context.Context) ([]NamespaceConfig, error) {
+	return s.manager.listNamespaceConfigs(ctx, s.readTs)
+}
+
+// GetRelationTuples returns all tuples stored for the given object and relation
+func (s *Snapshot) GetRelationTuples(ctx context.Context, namespace, objectID, relation string) ([]RelationTuple, error) {
+	return s.manager.getRelationTuples(ctx, namespace, objectID, relation, s.readTs)
+}
+
+// GetTuplesByUser returns all tuples granting a relation directly to the user
+func (s *Snapshot) GetTuplesByUser(ctx context.Context, userID string) ([]RelationTuple, error) {
+	return s.manager.getTuplesByUser(ctx, userID, s.readTs)
+}
+
+// GetTuplesByUsersetObject returns all tuples whose userset references the object
+func (s *Snapshot) GetTuplesByUsersetObject(ctx context.Context, namespace, objectID string) ([]RelationTuple, error) {
+	return s.manager.getTuplesByUsersetObject(ctx, namespace, objectID, s.readTs)
+}
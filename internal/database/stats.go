@@ -0,0 +1,33 @@
+package database
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+// QueryStats counts the reads served on behalf of a single request
+type QueryStats struct {
+	// DgraphQueries is the number of queries sent to Dgraph
+	DgraphQueries atomic.Int64
+
+	// CacheHits is the number of tuple reads answered by Redis
+	CacheHits atomic.Int64
+
+	// CacheMisses is the number of cacheable tuple reads that fell through to Dgraph
+	CacheMisses atomic.Int64
+}
+
+// queryStatsKey is the context key holding the QueryStats of a request
+type queryStatsKey struct{}
+
+// WithQueryStats returns a context that counts the reads made with it
+func WithQueryStats(ctx context.Context) (context.Context, *QueryStats) {
+	stats := &QueryStats{}
+	return context.WithValue(ctx, queryStatsKey{}, stats), stats
+}
+
+// queryStatsFrom returns the QueryStats attached to the context, if any
+func queryStatsFrom(ctx context.Context) *QueryStats {
+	stats, _ := ctx.Value(queryStatsKey{}).(*QueryStats)
+	return stats
+}
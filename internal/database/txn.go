@@ -0,0 +1,408 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/dgraph-io/dgo/v240"
+	"github.com/dgraph-io/dgo/v240/protos/api"
+)
+
+var (
+	// ErrPreconditionFailed is returned when a precondition of a write does not hold
+	ErrPreconditionFailed = errors.New("precondition failed")
+
+	// ErrConflict is returned when a concurrent transaction modified the same tuples
+	ErrConflict = errors.New("transaction conflict")
+)
+
+// Precondition requires a relation tuple to exist, or not to exist, when a
+// transaction is applied
+type Precondition struct {
+	Tuple     RelationTuple
+	MustExist bool
+}
+
+// PreconditionError reports a precondition that did not hold
+type PreconditionError struct {
+	Precondition Precondition
+}
+
+func (e *PreconditionError) Error() string {
+	requirement := "not exist"
+	if e.Precondition.MustExist {
+		requirement = "exist"
+	}
+	return fmt.Sprintf("%v: tuple %s must %s", ErrPreconditionFailed, TupleKey(e.Precondition.Tuple), requirement)
+}
+
+func (e *PreconditionError) Unwrap() error {
+	return ErrPreconditionFailed
+}
+
+// TupleTxn stages changes to relation tuples and applies them atomically in
+// a single Dgraph transaction
+// Reads made while staging observe the changes staged before them, so
+// preconditions and later operations see the effect of earlier ones
+type TupleTxn struct {
+	manager *Manager
+	txn     *dgo.Txn
+	readTs  uint64
+
+	staged map[string]*stagedTuple
+	order  []string
+}
+
+// stagedTuple tracks the stored and desired state of a single tuple
+type stagedTuple struct {
+	// uid is the Dgraph node of the stored tuple, empty when none is stored
+	uid string
+
+	// stored is the tuple as stored, nil when none is stored
+	stored *RelationTuple
+
+	// tuple is the desired state, meaningful when present is set
+	tuple   RelationTuple
+	present bool
+	dirty   bool
+}
+
+// NewTupleTxn starts a transaction for changing relation tuples
+// The transaction must be committed or discarded
+func (m *Manager) NewTupleTxn() *TupleTxn {
+	return &TupleTxn{
+		manager: m,
+		txn:     m.Dgraph.NewTransaction(),
+		staged:  make(map[string]*stagedTuple),
+	}
+}
+
+// Discard releases the transaction without applying the staged changes
+func (t *TupleTxn) Discard(ctx context.Context) {
+	if err := t.txn.Discard(ctx); err != nil {
+		log.Printf("Warning: failed to discard tuple transaction: %v", err)
+	}
+}
+
+// Check verifies a precondition against the stored and staged tuples
+func (t *TupleTxn) Check(ctx context.Context, precondition Precondition) error {
+	st, err := t.lookup(ctx, precondition.Tuple)
+	if err != nil {
+		return err
+	}
+
+	if st.present != precondition.MustExist {
+		return &PreconditionError{Precondition: precondition}
+	}
+
+	return nil
+}
+
+// Write stages the creation or update of a tuple
+// It reports false when an identical tuple is already present
+func (t *TupleTxn) Write(ctx context.Context, tuple RelationTuple) (bool, error) {
+	st, err := t.lookup(ctx, tuple)
+	if err != nil {
+		return false, err
+	}
+
+	if st.present && sameAttributes(st.tuple, tuple) {
+		return false, nil
+	}
+
+	st.tuple = tuple
+	st.present = true
+	st.dirty = true
+	return true, nil
+}
+
+// Delete stages the deletion of a tuple
+// It reports false when no such tuple is present
+func (t *TupleTxn) Delete(ctx context.Context, tuple RelationTuple) (bool, error) {
+	st, err := t.lookup(ctx, tuple)
+	if err != nil {
+		return false, err
+	}
+
+	if !st.present {
+		return false, nil
+	}
+
+	st.present = false
+	st.dirty = true
+	return true, nil
+}
+
+// Find returns the tuples matching a filter, as seen by the transaction
+// Stored tuples are overlaid with the staged changes, so tuples deleted
+// earlier in the transaction are left out and tuples written are included
+func (t *TupleTxn) Find(ctx context.Context, filter TupleFilter) ([]RelationTuple, error) {
+	query, vars := filter.query("findTuples", "")
+
+	stored, err := t.query(ctx, query, vars)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find tuples: %w", err)
+	}
+
+	now := time.Now()
+	for _, tuple := range stored {
+		key := TupleKey(tuple)
+		if _, ok := t.staged[key]; ok {
+			continue
+		}
+
+		stored := tuple
+		t.staged[key] = &stagedTuple{
+			uid:     tuple.UID,
+			stored:  &stored,
+			tuple:   tuple,
+			present: !tuple.Expired(now),
+		}
+		t.order = append(t.order, key)
+	}
+
+	var tuples []RelationTuple
+	for _, key := range t.order {
+		st := t.staged[key]
+		if st.present && filter.Matches(st.tuple) {
+			tuples = append(tuples, st.tuple)
+		}
+	}
+
+	return tuples, nil
+}
+
+// Commit applies the staged changes and returns the commit timestamp
+// When nothing changed the transaction's read timestamp is returned instead
+func (t *TupleTxn) Commit(ctx context.Context) (uint64, error) {
+	now := time.Now().Format(time.RFC3339)
+
+	var (
+		mutations []*api.Mutation
+		cacheKeys []string
+		changes   []Change
+		blank     int
+	)
+	for _, key := range t.order {
+		st := t.staged[key]
+		if !st.dirty {
+			continue
+		}
+
+		mutation, err := st.mutation(now, &blank)
+		if err != nil {
+			return 0, err
+		}
+		if mutation == nil {
+			continue
+		}
+
+		mutations = append(mutations, mutation)
+		cacheKeys = append(cacheKeys, tupleCacheKey(st.tuple.Namespace, st.tuple.ObjectID, st.tuple.Relation))
+		changes = append(changes, Change{Type: st.changeType(), Tuple: st.tuple})
+	}
+
+	if len(mutations) == 0 {
+		t.Discard(ctx)
+		return t.readTs, nil
+	}
+
+	batch, err := stageChanges(t.readTs, changes)
+	if err != nil {
+		return 0, err
+	}
+	mutations = append(mutations, batch)
+
+	resp, err := t.txn.Do(ctx, &api.Request{Mutations: mutations, CommitNow: true})
+	if err != nil {
+		if errors.Is(err, dgo.ErrAborted) {
+			return 0, fmt.Errorf("%w: %v", ErrConflict, err)
+		}
+		return 0, fmt.Errorf("failed to commit tuple transaction: %w", err)
+	}
+
+	// Invalidate the cached tuple lists the changes belong to
+	for _, cacheKey := range cacheKeys {
+		if err := t.manager.Redis.Del(ctx, cacheKey); err != nil {
+			log.Printf("Warning: failed to invalidate cache for key %s: %v", cacheKey, err)
+		}
+	}
+
+	commitTs := resp.GetTxn().GetCommitTs()
+	t.manager.publishChanges(ctx, t.readTs, commitTs)
+
+	return commitTs, nil
+}
+
+// changeType classifies the change made to the tuple for the change log
+// Replacing an expired tuple counts as creating it
+func (st *stagedTuple) changeType() ChangeType {
+	switch {
+	case !st.present:
+		return ChangeDeleted
+	case st.stored == nil || st.stored.Expired(time.Now()):
+		return ChangeCreated
+	}
+	return ChangeUpdated
+}
+
+// mutation builds the Dgraph mutation moving the stored tuple to its desired state
+func (st *stagedTuple) mutation(now string, blank *int) (*api.Mutation, error) {
+	if !st.present {
+		if st.uid == "" {
+			return nil, nil
+		}
+		return &api.Mutation{DelNquads: []byte(fmt.Sprintf("<%s> * * .", st.uid))}, nil
+	}
+
+	uid := st.uid
+	if uid == "" {
+		*blank++
+		uid = fmt.Sprintf("_:tuple%d", *blank)
+	}
+
+	node := map[string]interface{}{
+		"uid":         uid,
+		"dgraph.type": "RelationTuple",
+		"namespace":   st.tuple.Namespace,
+		"object_id":   st.tuple.ObjectID,
+		"relation":    st.tuple.Relation,
+		"user_id":     st.tuple.UserID,
+		"updated_at":  now,
+	}
+	if st.tuple.Userset != "" {
+		node["userset"] = st.tuple.Userset
+	}
+	if st.tuple.Conditions != "" {
+		node["conditions"] = st.tuple.Conditions
+	}
+	if st.tuple.ExpiresAt != "" {
+		node["expires_at"] = st.tuple.ExpiresAt
+	}
+	if st.stored == nil {
+		node["created_at"] = now
+	}
+
+	setJSON, err := json.Marshal(node)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal tuple mutation: %w", err)
+	}
+	mutation := &api.Mutation{SetJson: setJSON}
+
+	// Attributes dropped by an update are removed from the stored node
+	var removed []string
+	if st.stored != nil && st.stored.Conditions != "" && st.tuple.Conditions == "" {
+		removed = append(removed, fmt.Sprintf("<%s> <conditions> * .", st.uid))
+	}
+	if st.stored != nil && st.stored.ExpiresAt != "" && st.tuple.ExpiresAt == "" {
+		removed = append(removed, fmt.Sprintf("<%s> <expires_at> * .", st.uid))
+	}
+	if len(removed) > 0 {
+		mutation.DelNquads = []byte(strings.Join(removed, "\n"))
+	}
+
+	return mutation, nil
+}
+
+// lookup returns the staged state of a tuple, reading it from Dgraph within
+// the transaction the first time it is needed
+func (t *TupleTxn) lookup(ctx context.Context, tuple RelationTuple) (*stagedTuple, error) {
+	key := TupleKey(tuple)
+	if st, ok := t.staged[key]; ok {
+		return st, nil
+	}
+
+	subjectFilter := "eq(user_id, $subject) AND NOT has(userset)"
+	subject := tuple.UserID
+	if tuple.Userset != "" {
+		subjectFilter = "eq(userset, $subject)"
+		subject = tuple.Userset
+	}
+
+	query := `query lookupTuple($namespace: string, $object_id: string, $relation: string, $subject: string) {
+		tuples(func: eq(object_id, $object_id)) @filter(type(RelationTuple) AND eq(namespace, $namespace) AND eq(relation, $relation) AND ` + subjectFilter + `) {
+			` + tupleFields + `
+		}
+	}`
+
+	vars := map[string]string{
+		"$namespace": tuple.Namespace,
+		"$object_id": tuple.ObjectID,
+		"$relation":  tuple.Relation,
+		"$subject":   subject,
+	}
+
+	tuples, err := t.query(ctx, query, vars)
+	if err != nil {
+		return nil, fmt.Errorf("failed to look up tuple %s: %w", key, err)
+	}
+
+	st := &stagedTuple{}
+	if len(tuples) > 0 {
+		stored := tuples[0]
+		st.uid = stored.UID
+		st.stored = &stored
+		st.tuple = stored
+		st.present = !stored.Expired(time.Now())
+	}
+
+	t.staged[key] = st
+	t.order = append(t.order, key)
+	return st, nil
+}
+
+// query runs a read within the transaction and decodes the returned tuples
+func (t *TupleTxn) query(ctx context.Context, query string, vars map[string]string) ([]RelationTuple, error) {
+	if stats := queryStatsFrom(ctx); stats != nil {
+		stats.DgraphQueries.Add(1)
+	}
+
+	resp, err := t.txn.QueryWithVars(ctx, query, vars)
+	if err != nil {
+		return nil, err
+	}
+	t.readTs = resp.GetTxn().GetStartTs()
+
+	var result struct {
+		Tuples []RelationTuple `json:"tuples"`
+	}
+	if err := json.Unmarshal(resp.Json, &result); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal tuples result: %w", err)
+	}
+
+	return result.Tuples, nil
+}
+
+// TupleKey formats the identity of a tuple as namespace:object#relation@subject
+func TupleKey(tuple RelationTuple) string {
+	subject := tuple.UserID
+	if tuple.Userset != "" {
+		subject = tuple.Userset
+	}
+	return fmt.Sprintf("%s:%s#%s@%s", tuple.Namespace, tuple.ObjectID, tuple.Relation, subject)
+}
+
+// sameAttributes reports whether two tuples with the same identity carry the
+// same condition and expiry
+func sameAttributes(a, b RelationTuple) bool {
+	return a.Conditions == b.Conditions && sameTime(a.ExpiresAt, b.ExpiresAt)
+}
+
+// sameTime compares two optional RFC 3339 times, which Dgraph may format
+// differently from how they were written
+func sameTime(a, b string) bool {
+	if a == "" || b == "" {
+		return a == b
+	}
+	at, errA := time.Parse(time.RFC3339Nano, a)
+	bt, errB := time.Parse(time.RFC3339Nano, b)
+	if errA != nil || errB != nil {
+		return a == b
+	}
+	return at.Equal(bt)
+}
@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/DangVTNhan/goacl/api"
+	"github.com/DangVTNhan/goacl/internal/service"
+)
+
+type AuthorizationServer struct {
+	api.UnimplementedAuthorizationServiceServer
+	service *service.AuthorizationService
+}
+
+func NewAuthorizationServer(svc *service.AuthorizationService) *AuthorizationServer {
+	return &AuthorizationServer{service: svc}
+}
+
+func (s *AuthorizationServer) Check(ctx context.Context, req *api.CheckRequest) (*api.CheckResponse, error) {
+	return s.service.Check(ctx, req)
+}
+
+func (s *AuthorizationServer) Expand(ctx context.Context, req *api.ExpandRequest) (*api.ExpandResponse, error) {
+	return s.service.Expand(ctx, req)
+}
+
+func (s *AuthorizationServer) ListPermissions(ctx context.Context, req *api.ListPermissionsRequest) (*api.ListPermissionsResponse, error) {
+	return s.service.ListPermissions(ctx, req)
+}
+
+func (s *AuthorizationServer) BatchCheck(ctx context.Context, req *api.BatchCheckRequest) (*api.BatchCheckResponse, error) {
+	return s.service.BatchCheck(ctx, req)
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/DangVTNhan/goacl/api"
+	"github.com/DangVTNhan/goacl/internal/service"
+)
+
+type ConfigurationServer struct {
+	api.UnimplementedConfigurationServiceServer
+	service *service.ConfigurationService
+}
+
+func NewConfigurationServer(svc *service.ConfigurationService) *ConfigurationServer {
+	return &ConfigurationServer{service: svc}
+}
+
+func (s *ConfigurationServer) WriteNamespace(ctx context.Context, req *api.WriteNamespaceRequest) (*api.WriteNamespaceResponse, error) {
+	return s.service.WriteNamespace(ctx, req)
+}
+
+func (s *ConfigurationServer) ReadNamespace(ctx context.Context, req *api.ReadNamespaceRequest) (*api.ReadNamespaceResponse, error) {
+	return s.service.ReadNamespace(ctx, req)
+}
+
+func (s *ConfigurationServer) ListNamespaces(ctx context.Context, req *api.ListNamespacesRequest) (*api.ListNamespacesResponse, error) {
+	return s.service.ListNamespaces(ctx, req)
+}
+
+func (s *ConfigurationServer) DeleteNamespace(ctx context.Context, req *api.DeleteNamespaceRequest) (*api.DeleteNamespaceResponse, error) {
+	return s.service.DeleteNamespace(ctx, req)
+}
+
+func (s *ConfigurationServer) ValidateNamespace(ctx context.Context, req *api.ValidateNamespaceRequest) (*api.ValidateNamespaceResponse, error) {
+	return s.service.ValidateNamespace(ctx, req)
+}
+
+func (s *ConfigurationServer) ListNamespaceRevisions(ctx context.Context, req *api.ListNamespaceRevisionsRequest) (*api.ListNamespaceRevisionsResponse, error) {
+	return s.service.ListNamespaceRevisions(ctx, req)
+}
+
+func (s *ConfigurationServer) ReadNamespaceRevision(ctx context.Context, req *api.ReadNamespaceRevisionRequest) (*api.ReadNamespaceRevisionResponse, error) {
+	return s.service.ReadNamespaceRevision(ctx, req)
+}
+
+func (s *ConfigurationServer) DiffNamespaceRevisions(ctx context.Context, req *api.DiffNamespaceRevisionsRequest) (*api.DiffNamespaceRevisionsResponse, error) {
+	return s.service.DiffNamespaceRevisions(ctx, req)
+}
+
+func (s *ConfigurationServer) RollbackNamespace(ctx context.Context, req *api.RollbackNamespaceRequest) (*api.RollbackNamespaceResponse, error) {
+	return s.service.RollbackNamespace(ctx, req)
+}
@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/DangVTNhan/goacl/api"
+	"github.com/DangVTNhan/goacl/internal/service"
+	"google.golang.org/grpc"
+)
+
+type RelationshipServer struct {
+	api.UnimplementedRelationshipServiceServer
+	service *service.RelationshipService
+}
+
+func NewRelationshipServer(svc *service.RelationshipService) *RelationshipServer {
+	return &RelationshipServer{service: svc}
+}
+
+func (s *RelationshipServer) WriteRelation(ctx context.Context, req *api.WriteRelationRequest) (*api.WriteRelationResponse, error) {
+	return s.service.WriteRelation(ctx, req)
+}
+
+func (s *RelationshipServer) DeleteRelation(ctx context.Context, req *api.DeleteRelationRequest) (*api.DeleteRelationResponse, error) {
+	return s.service.DeleteRelation(ctx, req)
+}
+
+func (s *RelationshipServer) ReadRelations(ctx context.Context, req *api.ReadRelationsRequest) (*api.ReadRelationsResponse, error) {
+	return s.service.ReadRelations(ctx, req)
+}
+
+func (s *RelationshipServer) WatchRelations(req *api.WatchRelationsRequest, stream grpc.ServerStreamingServer[api.WatchRelationsResponse]) error {
+	return s.service.WatchRelations(req, stream)
+}
+
+func (s *RelationshipServer) BatchWrite(ctx context.Context, req *api.BatchWriteRequest) (*api.BatchWriteResponse, error) {
+	return s.service.BatchWrite(ctx, req)
+}
+
+func (s *RelationshipServer) ImportRelations(stream grpc.BidiStreamingServer[api.ImportRelationsRequest, api.ImportRelationsResponse]) error {
+	return s.service.ImportRelations(stream)
+}
+
+func (s *RelationshipServer) ExportRelations(req *api.ExportRelationsRequest, stream grpc.ServerStreamingServer[api.ExportRelationsResponse]) error {
+	return s.service.ExportRelations(req, stream)
+}
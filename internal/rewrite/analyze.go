@@ -0,0 +1,249 @@
+package rewrite
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Severity ranks the problems found by Analyze
+type Severity int
+
+const (
+	// SeverityError marks a rule that cannot be evaluated as written
+	SeverityError Severity = iota
+
+	// SeverityWarning marks a rule that can be evaluated but likely does not
+	// do what was meant
+	SeverityWarning
+)
+
+// Codes identifying the problems found by Analyze
+const (
+	CodeInvalidJSON       = "INVALID_JSON"
+	CodeInvalidNode       = "INVALID_NODE"
+	CodeUndefinedRelation = "UNDEFINED_RELATION"
+	CodeUnreachableBranch = "UNREACHABLE_BRANCH"
+	CodeRedundantBranch   = "REDUNDANT_BRANCH"
+	CodeRewriteCycle      = "REWRITE_CYCLE"
+)
+
+// Relation is a relation of a namespace with its JSON encoded rewrite rule
+type Relation struct {
+	Name  string
+	Rules string
+}
+
+// Problem is an issue found in the rewrite rule of a relation
+type Problem struct {
+	Severity Severity
+
+	// Relation is the index of the relation whose rule has the problem
+	Relation int
+
+	// Path is the JSONPath of the offending node within the rule
+	Path string
+
+	Code    string
+	Message string
+}
+
+// Analyze parses the rewrite rules of the relations of a namespace and checks
+// them against each other
+// Malformed rules and references to relations the namespace does not define
+// are errors; branches that never grant access or repeat another, and
+// computed_userset cycles that never terminate, are warnings
+func Analyze(relations []Relation) []Problem {
+	a := &analyzer{rules: make(map[string]*Rule, len(relations))}
+
+	parsed := make([]*Rule, len(relations))
+	for i, rel := range relations {
+		rule, err := Parse(rel.Rules)
+		if err != nil {
+			a.parseProblem(i, err)
+		} else {
+			parsed[i] = rule
+		}
+
+		// A relation defined twice is resolved by its first definition
+		if _, ok := a.rules[rel.Name]; !ok {
+			a.rules[rel.Name] = rule
+		}
+	}
+
+	for i, rule := range parsed {
+		if rule != nil {
+			a.check(i, relations[i].Name, rule, rootPath)
+		}
+	}
+	a.checkCycles()
+
+	return a.problems
+}
+
+// analyzer collects the problems of the rules of one namespace
+type analyzer struct {
+	// rules holds the rule of every defined relation, nil when it cannot be parsed
+	rules map[string]*Rule
+
+	// edges are the computed_userset references between relations
+	edges []reference
+
+	problems []Problem
+}
+
+// reference is a computed_userset node pointing from one relation to another
+// on the same object
+type reference struct {
+	relation int
+	from     string
+	to       string
+	path     string
+}
+
+func (a *analyzer) report(severity Severity, relation int, path, code, format string, args ...interface{}) {
+	a.problems = append(a.problems, Problem{
+		Severity: severity,
+		Relation: relation,
+		Path:     path,
+		Code:     code,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+// parseProblem reports a rule that cannot be parsed, locating the problem as
+// closely as the parse error allows
+func (a *analyzer) parseProblem(relation int, err error) {
+	var (
+		node      *NodeError
+		syntax    *json.SyntaxError
+		mismatch  *json.UnmarshalTypeError
+		malformed = errors.Unwrap(err)
+	)
+	if malformed == nil {
+		malformed = err
+	}
+
+	switch {
+	case errors.As(err, &node):
+		a.report(SeverityError, relation, node.Path, CodeInvalidNode, "%s", node.Message)
+	case errors.As(err, &syntax):
+		a.report(SeverityError, relation, rootPath, CodeInvalidJSON, "malformed JSON at offset %d: %v", syntax.Offset, malformed)
+	case errors.As(err, &mismatch) && mismatch.Field != "":
+		a.report(SeverityError, relation, rootPath+"."+mismatch.Field, CodeInvalidNode, "%v", malformed)
+	default:
+		a.report(SeverityError, relation, rootPath, CodeInvalidJSON, "%v", malformed)
+	}
+}
+
+// check walks the rule of a relation, reporting its problems
+func (a *analyzer) check(relation int, name string, r *Rule, path string) {
+	switch {
+	case r.ComputedUserset != nil:
+		target := r.ComputedUserset.Relation
+		if _, ok := a.rules[target]; !ok {
+			a.report(SeverityError, relation, path+".computed_userset.relation", CodeUndefinedRelation,
+				"relation %s is not defined in the namespace", target)
+			return
+		}
+		a.edges = append(a.edges, reference{relation: relation, from: name, to: target, path: path + ".computed_userset"})
+
+	case r.TupleToUserset != nil:
+		tupleset := r.TupleToUserset.Tupleset.Relation
+		rule, ok := a.rules[tupleset]
+		switch {
+		case !ok:
+			a.report(SeverityError, relation, path+".tuple_to_userset.tupleset.relation", CodeUndefinedRelation,
+				"relation %s is not defined in the namespace", tupleset)
+		case rule != nil && !rule.Direct():
+			a.report(SeverityWarning, relation, path+".tuple_to_userset.tupleset.relation", CodeUnreachableBranch,
+				"tupleset relation %s is computed and cannot hold tuples, so this branch never grants access", tupleset)
+		}
+
+	case r.Union != nil:
+		if len(r.Union.Child) == 0 {
+			a.report(SeverityWarning, relation, path+".union", CodeUnreachableBranch,
+				"union without children never grants access")
+		}
+		a.checkChildren(relation, name, r.Union.Child, path+".union")
+
+	case r.Intersection != nil:
+		a.checkChildren(relation, name, r.Intersection.Child, path+".intersection")
+
+	case r.Exclusion != nil:
+		if r.Exclusion.Base.String() == r.Exclusion.Exclude.String() {
+			a.report(SeverityWarning, relation, path+".exclusion", CodeUnreachableBranch,
+				"exclusion removes everything its base grants, so it never grants access")
+		}
+		a.check(relation, name, r.Exclusion.Base, path+".exclusion.base")
+		a.check(relation, name, r.Exclusion.Exclude, path+".exclusion.exclude")
+	}
+}
+
+// checkChildren walks the children of a set operation, reporting children
+// that repeat an earlier sibling or nest the same operation
+func (a *analyzer) checkChildren(relation int, name string, children []*Rule, path string) {
+	seen := make(map[string]int, len(children))
+	for i, child := range children {
+		childPath := fmt.Sprintf("%s.child[%d]", path, i)
+
+		encoded := child.String()
+		if first, ok := seen[encoded]; ok {
+			a.report(SeverityWarning, relation, childPath, CodeRedundantBranch,
+				"branch repeats child[%d] and has no effect", first)
+			continue
+		}
+		seen[encoded] = i
+
+		nested := child.Union != nil && strings.HasSuffix(path, ".union") ||
+			child.Intersection != nil && strings.HasSuffix(path, ".intersection")
+		if nested {
+			a.report(SeverityWarning, relation, childPath, CodeRedundantBranch,
+				"nested set operation can be merged into its parent")
+		}
+
+		a.check(relation, name, child, childPath)
+	}
+}
+
+// checkCycles reports every computed_userset reference that lies on a cycle
+// Such cycles stay on the same object, so resolving them never terminates
+func (a *analyzer) checkCycles() {
+	for _, edge := range a.edges {
+		cycle := a.route(edge.to, edge.from)
+		if cycle == nil {
+			continue
+		}
+		a.report(SeverityWarning, edge.relation, edge.path, CodeRewriteCycle,
+			"computed_userset closes the cycle %s -> %s, which never terminates",
+			edge.from, strings.Join(cycle, " -> "))
+	}
+}
+
+// route returns the shortest chain of computed_userset references leading
+// from one relation to another, or nil when there is none
+func (a *analyzer) route(from, to string) []string {
+	previous := map[string]string{from: ""}
+	queue := []string{from}
+	for len(queue) > 0 {
+		current := queue[0]
+		queue = queue[1:]
+
+		if current == to {
+			var chain []string
+			for name := current; name != ""; name = previous[name] {
+				chain = append([]string{name}, chain...)
+			}
+			return chain
+		}
+
+		for _, edge := range a.edges {
+			if _, ok := previous[edge.to]; edge.from == current && !ok {
+				previous[edge.to] = current
+				queue = append(queue, edge.to)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package rewrite
+
+// Subsumes reports whether every user the narrower rule grants is provably
+// granted by the wider rule as well, judging both by their structure
+// The comparison is conservative: rules referring to other relations are
+// assumed to grant the same users only when they refer to the same relation,
+// so false means access may be lost, not that it is
+func Subsumes(wider, narrower *Rule) bool {
+	if wider.String() == narrower.String() {
+		return true
+	}
+
+	switch {
+	case narrower.Union != nil && every(narrower.Union.Child, func(child *Rule) bool { return Subsumes(wider, child) }):
+		return true
+	case narrower.Intersection != nil && some(narrower.Intersection.Child, func(child *Rule) bool { return Subsumes(wider, child) }):
+		return true
+	case narrower.Exclusion != nil && Subsumes(wider, narrower.Exclusion.Base):
+		return true
+	}
+
+	switch {
+	case wider.Union != nil:
+		return some(wider.Union.Child, func(child *Rule) bool { return Subsumes(child, narrower) })
+	case wider.Intersection != nil:
+		return every(wider.Intersection.Child, func(child *Rule) bool { return Subsumes(child, narrower) })
+	case wider.Exclusion != nil && narrower.Exclusion != nil:
+		// Excluding no more users from a base that grants no fewer
+		return Subsumes(wider.Exclusion.Base, narrower.Exclusion.Base) &&
+			Subsumes(narrower.Exclusion.Exclude, wider.Exclusion.Exclude)
+	}
+	return false
+}
+
+// every reports whether the predicate holds for every rule
+func every(rules []*Rule, predicate func(*Rule) bool) bool {
+	for _, rule := range rules {
+		if !predicate(rule) {
+			return false
+		}
+	}
+	return true
+}
+
+// some reports whether the predicate holds for some rule
+func some(rules []*Rule, predicate func(*Rule) bool) bool {
+	for _, rule := range rules {
+		if predicate(rule) {
+			return true
+		}
+	}
+	return false
+}
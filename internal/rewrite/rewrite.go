@@ -0,0 +1,200 @@
+// Package rewrite parses and represents the userset rewrite rules stored on
+// relation configurations
+package rewrite
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// Rule is a single node of a userset rewrite tree
+// Exactly one of its fields is set
+type Rule struct {
+	This            *This            `json:"_this,omitempty"`
+	ComputedUserset *ComputedUserset `json:"computed_userset,omitempty"`
+	TupleToUserset  *TupleToUserset  `json:"tuple_to_userset,omitempty"`
+	Union           *SetOperation    `json:"union,omitempty"`
+	Intersection    *SetOperation    `json:"intersection,omitempty"`
+	Exclusion       *Exclusion       `json:"exclusion,omitempty"`
+}
+
+// This refers to the tuples stored directly for the relation
+type This struct{}
+
+// ComputedUserset refers to another relation on the same object
+type ComputedUserset struct {
+	Relation string `json:"relation"`
+}
+
+// Tupleset selects the tuples whose subjects are followed by TupleToUserset
+type Tupleset struct {
+	Relation string `json:"relation"`
+}
+
+// TupleToUserset follows the objects referenced by a tupleset and evaluates
+// a relation on each of them
+type TupleToUserset struct {
+	Tupleset        Tupleset        `json:"tupleset"`
+	ComputedUserset ComputedUserset `json:"computed_userset"`
+}
+
+// SetOperation combines the usersets of its children
+type SetOperation struct {
+	Child []*Rule `json:"child"`
+}
+
+// Exclusion removes the users of Exclude from the users of Base
+type Exclusion struct {
+	Base    *Rule `json:"base"`
+	Exclude *Rule `json:"exclude"`
+}
+
+// rootPath is the JSONPath of the root node of a rule
+const rootPath = "$"
+
+// Parse parses a JSON encoded rewrite rule
+// An empty string is treated as a relation that only holds direct tuples
+func Parse(data string) (*Rule, error) {
+	if strings.TrimSpace(data) == "" {
+		return &Rule{This: &This{}}, nil
+	}
+
+	var rule Rule
+	if err := json.Unmarshal([]byte(data), &rule); err != nil {
+		return nil, fmt.Errorf("invalid rewrite rules: %w", err)
+	}
+
+	if err := rule.validate(rootPath); err != nil {
+		return nil, fmt.Errorf("invalid rewrite rules: %w", err)
+	}
+
+	return &rule, nil
+}
+
+// String returns the JSON encoding of the rule
+func (r *Rule) String() string {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// Monotonic reports whether the rule only combines usersets with union, so
+// that granting a relation anywhere can never take access away
+func (r *Rule) Monotonic() bool {
+	switch {
+	case r.Intersection != nil, r.Exclusion != nil:
+		return false
+	case r.Union != nil:
+		for _, child := range r.Union.Child {
+			if !child.Monotonic() {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// Direct reports whether the rule reads the tuples stored for the relation
+// itself, so that the relation can hold direct tuples
+func (r *Rule) Direct() bool {
+	switch {
+	case r.This != nil:
+		return true
+	case r.Union != nil:
+		return anyDirect(r.Union.Child)
+	case r.Intersection != nil:
+		return anyDirect(r.Intersection.Child)
+	case r.Exclusion != nil:
+		return r.Exclusion.Base.Direct() || r.Exclusion.Exclude.Direct()
+	}
+	return false
+}
+
+// anyDirect reports whether any of the rules reads direct tuples
+func anyDirect(rules []*Rule) bool {
+	for _, rule := range rules {
+		if rule.Direct() {
+			return true
+		}
+	}
+	return false
+}
+
+// NodeError reports a rewrite node that is not well formed
+type NodeError struct {
+	// Path is the JSONPath of the node within the rule, such as $.union.child[1]
+	Path    string
+	Message string
+}
+
+func (e *NodeError) Error() string {
+	return e.Path + ": " + e.Message
+}
+
+// validate checks that every node in the tree sets exactly one operator,
+// reporting the first malformed node by its path
+func (r *Rule) validate(path string) error {
+	if r == nil {
+		return &NodeError{Path: path, Message: "empty rewrite node"}
+	}
+
+	set := 0
+	if r.This != nil {
+		set++
+	}
+	if r.ComputedUserset != nil {
+		set++
+		if r.ComputedUserset.Relation == "" {
+			return &NodeError{Path: path + ".computed_userset", Message: "computed_userset requires a relation"}
+		}
+	}
+	if r.TupleToUserset != nil {
+		set++
+		if r.TupleToUserset.Tupleset.Relation == "" {
+			return &NodeError{Path: path + ".tuple_to_userset", Message: "tuple_to_userset requires a tupleset relation"}
+		}
+		if r.TupleToUserset.ComputedUserset.Relation == "" {
+			return &NodeError{Path: path + ".tuple_to_userset", Message: "tuple_to_userset requires a computed_userset relation"}
+		}
+	}
+	if r.Union != nil {
+		set++
+		for i, child := range r.Union.Child {
+			if err := child.validate(fmt.Sprintf("%s.union.child[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	if r.Intersection != nil {
+		set++
+		if len(r.Intersection.Child) == 0 {
+			return &NodeError{Path: path + ".intersection", Message: "intersection requires at least one child"}
+		}
+		for i, child := range r.Intersection.Child {
+			if err := child.validate(fmt.Sprintf("%s.intersection.child[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+	}
+	if r.Exclusion != nil {
+		set++
+		if r.Exclusion.Base == nil || r.Exclusion.Exclude == nil {
+			return &NodeError{Path: path + ".exclusion", Message: "exclusion requires both base and exclude"}
+		}
+		if err := r.Exclusion.Base.validate(path + ".exclusion.base"); err != nil {
+			return err
+		}
+		if err := r.Exclusion.Exclude.validate(path + ".exclusion.exclude"); err != nil {
+			return err
+		}
+	}
+
+	if set != 1 {
+		return &NodeError{Path: path, Message: fmt.Sprintf("rewrite node must set exactly one operator, got %d", set)}
+	}
+
+	return nil
+}
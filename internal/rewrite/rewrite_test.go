@@ -0,0 +1,184 @@
+package rewrite
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	rule, err := Parse(`{"union": {"child": [{"_this": {}}, {"tuple_to_userset": {"tupleset": {"relation": "parent"}, "computed_userset": {"relation": "viewer"}}}]}}`)
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+
+	if rule.Union == nil || len(rule.Union.Child) != 2 {
+		t.Fatalf("Expected union with 2 children, got %s", rule)
+	}
+
+	ttu := rule.Union.Child[1].TupleToUserset
+	if ttu == nil || ttu.Tupleset.Relation != "parent" || ttu.ComputedUserset.Relation != "viewer" {
+		t.Errorf("Unexpected tuple_to_userset node: %s", rule.Union.Child[1])
+	}
+
+	empty, err := Parse("")
+	if err != nil || empty.This == nil {
+		t.Errorf("Expected empty rules to parse as _this, got %v, %v", empty, err)
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	invalid := []string{
+		`not json`,
+		`{}`,
+		`{"_this": {}, "computed_userset": {"relation": "owner"}}`,
+		`{"computed_userset": {}}`,
+		`{"union": {"child": [{"tuple_to_userset": {"tupleset": {"relation": "parent"}}}]}}`,
+	}
+
+	for _, data := range invalid {
+		if _, err := Parse(data); err == nil {
+			t.Errorf("Expected error parsing %s", data)
+		}
+	}
+}
+
+func TestParseSetOperations(t *testing.T) {
+	rule, err := Parse(`{"intersection": {"child": [{"computed_userset": {"relation": "reader"}}, {"tuple_to_userset": {"tupleset": {"relation": "org"}, "computed_userset": {"relation": "member"}}}]}}`)
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if rule.Intersection == nil || len(rule.Intersection.Child) != 2 {
+		t.Errorf("Expected intersection with 2 children, got %s", rule)
+	}
+	if rule.Monotonic() {
+		t.Error("Expected intersection not to be monotonic")
+	}
+
+	rule, err = Parse(`{"exclusion": {"base": {"_this": {}}, "exclude": {"computed_userset": {"relation": "banned"}}}}`)
+	if err != nil {
+		t.Fatalf("Parse returned error: %v", err)
+	}
+	if rule.Exclusion == nil || rule.Exclusion.Base.This == nil || rule.Exclusion.Exclude.ComputedUserset == nil {
+		t.Errorf("Unexpected exclusion node: %s", rule)
+	}
+
+	invalid := []string{
+		`{"intersection": {"child": []}}`,
+		`{"exclusion": {"base": {"_this": {}}}}`,
+		`{"exclusion": {"base": {"_this": {}}, "exclude": {}}}`,
+	}
+	for _, data := range invalid {
+		if _, err := Parse(data); err == nil {
+			t.Errorf("Expected error parsing %s", data)
+		}
+	}
+}
+
+func TestDirect(t *testing.T) {
+	tests := map[string]bool{
+		``: true,
+		`{"union": {"child": [{"_this": {}}, {"computed_userset": {"relation": "owner"}}]}}`:                                        true,
+		`{"computed_userset": {"relation": "owner"}}`:                                                                               false,
+		`{"tuple_to_userset": {"tupleset": {"relation": "parent"}, "computed_userset": {"relation": "viewer"}}}`:                    false,
+		`{"exclusion": {"base": {"computed_userset": {"relation": "viewer"}}, "exclude": {"_this": {}}}}`:                           true,
+		`{"intersection": {"child": [{"computed_userset": {"relation": "viewer"}}, {"computed_userset": {"relation": "member"}}]}}`: false,
+	}
+
+	for data, want := range tests {
+		rule, err := Parse(data)
+		if err != nil {
+			t.Fatalf("Parse(%s) returned error: %v", data, err)
+		}
+		if got := rule.Direct(); got != want {
+			t.Errorf("Direct() for %s = %v, want %v", data, got, want)
+		}
+	}
+}
+
+func TestAnalyze(t *testing.T) {
+	problems := Analyze([]Relation{
+		{Name: "owner"},
+		{Name: "parent", Rules: `{"computed_userset": {"relation": "owner"}}`},
+		{Name: "editor", Rules: `{"union": {"child": [{"_this": {}}, {"computed_userset": {"relation": "ownr"}}, {"_this": {}}]}}`},
+		{Name: "viewer", Rules: `{"union": {"child": [{"computed_userset": {"relation": "reader"}}, {"tuple_to_userset": {"tupleset": {"relation": "parent"}, "computed_userset": {"relation": "viewer"}}}]}}`},
+		{Name: "reader", Rules: `{"intersection": {"child": [{"computed_userset": {"relation": "viewer"}}]}}`},
+		{Name: "banned", Rules: `{"exclusion": {"base": {"_this": {}}, "exclude": {"_this": {}}}}`},
+		{Name: "broken", Rules: `{"union": {"child": [{"_this": {}, "computed_userset": {"relation": "owner"}}]}}`},
+		{Name: "garbled", Rules: `{"union": `},
+	})
+
+	want := []string{
+		"broken $.union.child[0] INVALID_NODE error",
+		"garbled $ INVALID_JSON error",
+		"editor $.union.child[1].computed_userset.relation UNDEFINED_RELATION error",
+		"editor $.union.child[2] REDUNDANT_BRANCH warning",
+		"viewer $.union.child[1].tuple_to_userset.tupleset.relation UNREACHABLE_BRANCH warning",
+		"banned $.exclusion UNREACHABLE_BRANCH warning",
+		"viewer $.union.child[0].computed_userset REWRITE_CYCLE warning",
+		"reader $.intersection.child[0].computed_userset REWRITE_CYCLE warning",
+	}
+	names := []string{"owner", "parent", "editor", "viewer", "reader", "banned", "broken", "garbled"}
+
+	var got []string
+	for _, problem := range problems {
+		severity := "error"
+		if problem.Severity == SeverityWarning {
+			severity = "warning"
+		}
+		got = append(got, fmt.Sprintf("%s %s %s %s", names[problem.Relation], problem.Path, problem.Code, severity))
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected problems:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestSubsumes(t *testing.T) {
+	const (
+		this    = `{"_this": {}}`
+		owner   = `{"computed_userset": {"relation": "owner"}}`
+		editor  = `{"computed_userset": {"relation": "editor"}}`
+		blocked = `{"computed_userset": {"relation": "blocked"}}`
+		parent  = `{"tuple_to_userset": {"tupleset": {"relation": "parent"}, "computed_userset": {"relation": "viewer"}}}`
+	)
+	union := func(children ...string) string {
+		return `{"union": {"child": [` + strings.Join(children, ",") + `]}}`
+	}
+	intersection := func(children ...string) string {
+		return `{"intersection": {"child": [` + strings.Join(children, ",") + `]}}`
+	}
+	exclusion := func(base, exclude string) string {
+		return `{"exclusion": {"base": ` + base + `, "exclude": ` + exclude + `}}`
+	}
+
+	tests := []struct {
+		wider, narrower string
+		expected        bool
+	}{
+		{union(this, owner), union(owner, this), true},
+		{union(this, owner, parent), union(this, owner), true},
+		{union(this, owner), union(this, owner, parent), false},
+		{union(this), "", true},
+		{union(owner), union(this), false},
+		{union(this, owner), intersection(owner, editor), true},
+		{intersection(owner, editor), union(owner), false},
+		{union(this, owner), exclusion(union(this, owner), blocked), true},
+		{exclusion(union(this, owner), blocked), union(this, owner), false},
+		{exclusion(union(this, owner, editor), blocked), exclusion(union(this, owner), blocked), true},
+		{exclusion(union(this, owner), blocked), exclusion(union(this, owner), editor), false},
+	}
+
+	for i, tt := range tests {
+		wider, err := Parse(tt.wider)
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v", tt.wider, err)
+		}
+		narrower, err := Parse(tt.narrower)
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v", tt.narrower, err)
+		}
+		if got := Subsumes(wider, narrower); got != tt.expected {
+			t.Errorf("case %d: expected Subsumes(%s, %s) to be %v", i, tt.wider, tt.narrower, tt.expected)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package schema
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/DangVTNhan/goacl/api"
+	"google.golang.org/protobuf/encoding/protojson"
+	"gopkg.in/yaml.v3"
+)
+
+// SchemaExtension is the extension of files holding schema text
+const SchemaExtension = ".acl"
+
+// LoadDir reads the namespaces defined by the files of a directory, in file
+// name order, ignoring subdirectories and files of other types
+// A namespace may be defined by only one file
+func LoadDir(dir string) ([]*api.NamespaceConfig, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read namespace directory: %w", err)
+	}
+
+	var names []string
+	for _, entry := range entries {
+		if !entry.IsDir() && isNamespaceFile(entry.Name()) {
+			names = append(names, entry.Name())
+		}
+	}
+	sort.Strings(names)
+
+	var configs []*api.NamespaceConfig
+	definedIn := make(map[string]string)
+	for _, name := range names {
+		path := filepath.Join(dir, name)
+		loaded, err := LoadFile(path)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, config := range loaded {
+			if previous, ok := definedIn[config.GetName()]; ok {
+				return nil, fmt.Errorf("%s: namespace %s is already defined in %s", path, config.GetName(), previous)
+			}
+			definedIn[config.GetName()] = path
+		}
+		configs = append(configs, loaded...)
+	}
+
+	return configs, nil
+}
+
+// LoadFile reads the namespaces defined by a file
+// Files ending in .acl hold schema text; .json, .yaml and .yml files hold a
+// namespace configuration in its API form, or a list of them
+func LoadFile(path string) ([]*api.NamespaceConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read namespace file: %w", err)
+	}
+
+	var configs []*api.NamespaceConfig
+	if strings.EqualFold(filepath.Ext(path), SchemaExtension) {
+		configs, err = Compile(string(data))
+	} else {
+		configs, err = decodeConfigs(data)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return configs, nil
+}
+
+// isNamespaceFile reports whether LoadDir reads the named file
+func isNamespaceFile(name string) bool {
+	switch strings.ToLower(filepath.Ext(name)) {
+	case SchemaExtension, ".json", ".yaml", ".yml":
+		return true
+	}
+	return false
+}
+
+// decodeConfigs decodes YAML or JSON, which is YAML as well, holding one
+// namespace configuration or a list of them
+func decodeConfigs(data []byte) ([]*api.NamespaceConfig, error) {
+	var document interface{}
+	if err := yaml.Unmarshal(data, &document); err != nil {
+		return nil, fmt.Errorf("invalid namespace file: %w", err)
+	}
+
+	items, ok := document.([]interface{})
+	if !ok {
+		items = []interface{}{document}
+	}
+
+	configs := make([]*api.NamespaceConfig, len(items))
+	for i, item := range items {
+		// The API form is decoded with protojson, which accepts both the
+		// proto and the JSON names of fields
+		encoded, err := json.Marshal(item)
+		if err != nil {
+			return nil, fmt.Errorf("namespace %d: %w", i, err)
+		}
+		configs[i] = &api.NamespaceConfig{}
+		if err := protojson.Unmarshal(encoded, configs[i]); err != nil {
+			return nil, fmt.Errorf("namespace %d: %w", i, err)
+		}
+	}
+	return configs, nil
+}
@@ -0,0 +1,402 @@
+package schema
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/DangVTNhan/goacl/internal/rewrite"
+)
+
+// tokenKind classifies lexical tokens
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenKeyword
+	tokenPunct
+)
+
+// token is a lexical token with its position in the source
+type token struct {
+	kind tokenKind
+	text string
+	pos  Position
+}
+
+// String describes the token in error messages
+func (t token) String() string {
+	if t.kind == tokenEOF {
+		return "end of schema"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+// keywords are the reserved words of the language
+var keywords = map[string]bool{
+	"namespace": true,
+	"define":    true,
+	"or":        true,
+	"and":       true,
+	"but":       true,
+	"not":       true,
+	"from":      true,
+}
+
+// lex splits schema text into tokens, skipping whitespace and // comments
+func lex(source string) ([]token, error) {
+	var tokens []token
+	pos := Position{Line: 1, Column: 1}
+	i := 0
+
+	advance := func(n int) {
+		for _, r := range source[i : i+n] {
+			if r == '\n' {
+				pos.Line++
+				pos.Column = 1
+			} else {
+				pos.Column++
+			}
+		}
+		i += n
+	}
+
+	for i < len(source) {
+		r, size := utf8.DecodeRuneInString(source[i:])
+		switch {
+		case unicode.IsSpace(r):
+			advance(size)
+
+		case strings.HasPrefix(source[i:], "//"):
+			end := strings.IndexByte(source[i:], '\n')
+			if end < 0 {
+				end = len(source) - i
+			}
+			advance(end)
+
+		case strings.ContainsRune("{}[]():,#*", r):
+			tokens = append(tokens, token{tokenPunct, string(r), pos})
+			advance(size)
+
+		case isNameRune(r):
+			start, startPos := i, pos
+			for i < len(source) {
+				r, size := utf8.DecodeRuneInString(source[i:])
+				if !isNameRune(r) {
+					break
+				}
+				advance(size)
+			}
+			word := source[start:i]
+			if keywords[word] {
+				tokens = append(tokens, token{tokenKeyword, word, startPos})
+			} else {
+				tokens = append(tokens, token{tokenIdent, word, startPos})
+			}
+
+		default:
+			return nil, &SyntaxError{Position: pos, Message: fmt.Sprintf("unexpected character %q", r)}
+		}
+	}
+
+	return append(tokens, token{tokenEOF, "", pos}), nil
+}
+
+// isNameRune reports whether r may appear in a namespace, relation or type name
+func isNameRune(r rune) bool {
+	return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '-' || r == '.'
+}
+
+// isName reports whether a name can be written as a single name token
+func isName(name string) bool {
+	if name == "" || keywords[name] {
+		return false
+	}
+	for _, r := range name {
+		if !isNameRune(r) {
+			return false
+		}
+	}
+	return true
+}
+
+// parser is a recursive descent parser over a token stream
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// is reports whether the next token is the given keyword or punctuation
+func (p *parser) is(text string) bool {
+	tok := p.peek()
+	return (tok.kind == tokenKeyword || tok.kind == tokenPunct) && tok.text == text
+}
+
+// expect consumes the given keyword or punctuation
+func (p *parser) expect(text string) (token, error) {
+	if !p.is(text) {
+		return token{}, p.unexpected(fmt.Sprintf("%q", text))
+	}
+	return p.next(), nil
+}
+
+// name consumes a name, describing what it names in errors
+func (p *parser) name(what string) (token, error) {
+	if p.peek().kind != tokenIdent {
+		return token{}, p.unexpected(what)
+	}
+	return p.next(), nil
+}
+
+// unexpected reports the next token where something else was expected
+func (p *parser) unexpected(expected string) error {
+	tok := p.peek()
+	message := fmt.Sprintf("expected %s, got %s", expected, tok)
+	if tok.kind == tokenKeyword && strings.Contains(expected, "name") {
+		message += " (a reserved word)"
+	}
+	return &SyntaxError{Position: tok.pos, Message: message}
+}
+
+// parseFile parses: namespace*
+func (p *parser) parseFile() ([]*Namespace, error) {
+	var namespaces []*Namespace
+	for p.peek().kind != tokenEOF {
+		ns, err := p.parseNamespace()
+		if err != nil {
+			return nil, err
+		}
+		namespaces = append(namespaces, ns)
+	}
+	return namespaces, nil
+}
+
+// parseNamespace parses: 'namespace' name '{' define* '}'
+func (p *parser) parseNamespace() (*Namespace, error) {
+	keyword, err := p.expect("namespace")
+	if err != nil {
+		return nil, err
+	}
+	name, err := p.name("namespace name")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect("{"); err != nil {
+		return nil, err
+	}
+
+	ns := &Namespace{Name: name.text, Position: keyword.pos}
+	for !p.is("}") {
+		if p.peek().kind == tokenEOF {
+			return nil, p.unexpected(`"define" or "}"`)
+		}
+		rel, err := p.parseDefine()
+		if err != nil {
+			return nil, err
+		}
+		ns.Relations = append(ns.Relations, rel)
+	}
+	p.next()
+
+	return ns, nil
+}
+
+// parseDefine parses: 'define' name ':' expression
+// The expression is always compiled to a union at the root, matching how
+// rules are conventionally written
+func (p *parser) parseDefine() (*Relation, error) {
+	keyword, err := p.expect("define")
+	if err != nil {
+		return nil, err
+	}
+	name, err := p.name("relation name")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(":"); err != nil {
+		return nil, err
+	}
+
+	rel := &Relation{Name: name.text, Position: keyword.pos}
+	children, err := p.parseUnion(rel)
+	if err != nil {
+		return nil, err
+	}
+	rel.Rule = &rewrite.Rule{Union: &rewrite.SetOperation{Child: children}}
+
+	if p.is("but") {
+		exclude, err := p.parseExclude(rel)
+		if err != nil {
+			return nil, err
+		}
+		rel.Rule = &rewrite.Rule{Exclusion: &rewrite.Exclusion{Base: collapse(children), Exclude: exclude}}
+	}
+
+	return rel, nil
+}
+
+// parseExpression parses: union ('but' 'not' term)?
+func (p *parser) parseExpression(rel *Relation) (*rewrite.Rule, error) {
+	children, err := p.parseUnion(rel)
+	if err != nil {
+		return nil, err
+	}
+	rule := collapse(children)
+
+	if p.is("but") {
+		exclude, err := p.parseExclude(rel)
+		if err != nil {
+			return nil, err
+		}
+		rule = &rewrite.Rule{Exclusion: &rewrite.Exclusion{Base: rule, Exclude: exclude}}
+	}
+	return rule, nil
+}
+
+// parseExclude parses: 'but' 'not' term
+func (p *parser) parseExclude(rel *Relation) (*rewrite.Rule, error) {
+	p.next()
+	if _, err := p.expect("not"); err != nil {
+		return nil, err
+	}
+	return p.parseTerm(rel)
+}
+
+// parseUnion parses: intersection ('or' intersection)*
+func (p *parser) parseUnion(rel *Relation) ([]*rewrite.Rule, error) {
+	var children []*rewrite.Rule
+	for {
+		child, err := p.parseIntersection(rel)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+
+		if !p.is("or") {
+			return children, nil
+		}
+		p.next()
+	}
+}
+
+// parseIntersection parses: term ('and' term)*
+func (p *parser) parseIntersection(rel *Relation) (*rewrite.Rule, error) {
+	var children []*rewrite.Rule
+	for {
+		child, err := p.parseTerm(rel)
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+
+		if !p.is("and") {
+			break
+		}
+		p.next()
+	}
+
+	if len(children) == 1 {
+		return children[0], nil
+	}
+	return &rewrite.Rule{Intersection: &rewrite.SetOperation{Child: children}}, nil
+}
+
+// parseTerm parses: '[' types ']' | name 'from' name | name | '(' expression ')'
+func (p *parser) parseTerm(rel *Relation) (*rewrite.Rule, error) {
+	switch {
+	case p.is("["):
+		return p.parseDirect(rel)
+
+	case p.is("("):
+		p.next()
+		inner, err := p.parseExpression(rel)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(")"); err != nil {
+			return nil, err
+		}
+		return inner, nil
+
+	case p.peek().kind == tokenIdent:
+		relation := p.next().text
+		if !p.is("from") {
+			return &rewrite.Rule{ComputedUserset: &rewrite.ComputedUserset{Relation: relation}}, nil
+		}
+		p.next()
+
+		tupleset, err := p.name("tupleset relation name")
+		if err != nil {
+			return nil, err
+		}
+		return &rewrite.Rule{TupleToUserset: &rewrite.TupleToUserset{
+			Tupleset:        rewrite.Tupleset{Relation: tupleset.text},
+			ComputedUserset: rewrite.ComputedUserset{Relation: relation},
+		}}, nil
+	}
+
+	return nil, p.unexpected(`"[", "(" or a relation name`)
+}
+
+// parseDirect parses: '[' type (',' type)* ']' where type is
+// name (':' '*')? ('#' name)?
+// A type ending in :* allows wildcard subjects on the relation
+func (p *parser) parseDirect(rel *Relation) (*rewrite.Rule, error) {
+	p.next()
+	for {
+		typ, err := p.name("subject type")
+		if err != nil {
+			return nil, err
+		}
+		subject := SubjectType{Name: typ.text}
+
+		if p.is(":") {
+			p.next()
+			if _, err := p.expect("*"); err != nil {
+				return nil, err
+			}
+			subject.Wildcard = true
+			rel.AllowWildcard = true
+		}
+		if p.is("#") {
+			p.next()
+			relation, err := p.name("relation name")
+			if err != nil {
+				return nil, err
+			}
+			subject.Relation = relation.text
+		}
+		rel.SubjectTypes = append(rel.SubjectTypes, subject)
+
+		if p.is("]") {
+			p.next()
+			return &rewrite.Rule{This: &rewrite.This{}}, nil
+		}
+		if _, err := p.expect(","); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// collapse returns the rule of a union of children, which is the child
+// itself when there is only one
+func collapse(children []*rewrite.Rule) *rewrite.Rule {
+	if len(children) == 1 {
+		return children[0]
+	}
+	return &rewrite.Rule{Union: &rewrite.SetOperation{Child: children}}
+}
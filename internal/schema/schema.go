@@ -0,0 +1,255 @@
+// Package schema parses, compiles and decompiles the text language in which
+// namespace configurations can be written instead of JSON rewrite rules
+//
+// A schema defines namespaces and the relations on their objects, for example:
+//
+//	namespace documents {
+//	  define owner: [user]
+//	  define editor: [user] or owner
+//	  define viewer: [user, user:*] or editor or viewer from parent
+//	  define parent: [folders]
+//	}
+//
+// A bracketed list of subject types marks a relation that holds direct
+// tuples, and a type ending in :* allows wildcard subjects. A bare relation
+// name refers to another relation on the same object, and "rel from
+// tupleset" evaluates rel on every object the tupleset relation points to.
+// Terms combine with "and", which binds tighter than "or", and a whole
+// expression may end with "but not" and a term; parentheses group terms.
+// Comments start with // and run to the end of the line.
+//
+// Subject types are informational: they are not stored, so decompiling a
+// configuration writes every direct relation as [user], with user:* added
+// when the relation allows wildcards.
+package schema
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/DangVTNhan/goacl/api"
+	"github.com/DangVTNhan/goacl/internal/rewrite"
+)
+
+// Position is a line and column in schema text, both starting at 1
+type Position struct {
+	Line   int
+	Column int
+}
+
+// SyntaxError reports schema text that cannot be parsed
+type SyntaxError struct {
+	Position
+	Message string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Line, e.Column, e.Message)
+}
+
+// Namespace is a namespace defined by schema text
+type Namespace struct {
+	Name      string
+	Position  Position
+	Relations []*Relation
+}
+
+// Relation is a relation defined by schema text
+type Relation struct {
+	Name     string
+	Position Position
+	Rule     *rewrite.Rule
+
+	// SubjectTypes lists the subject types of its direct tuples, in order
+	SubjectTypes []SubjectType
+
+	// AllowWildcard is set when a subject type allows wildcard subjects
+	AllowWildcard bool
+}
+
+// SubjectType is an entry of the bracketed list marking a direct relation
+type SubjectType struct {
+	Name     string
+	Wildcard bool
+	Relation string
+}
+
+// Parse parses schema text into the namespaces it defines
+func Parse(source string) ([]*Namespace, error) {
+	tokens, err := lex(source)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	namespaces, err := p.parseFile()
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool, len(namespaces))
+	for _, ns := range namespaces {
+		if seen[ns.Name] {
+			return nil, &SyntaxError{Position: ns.Position, Message: fmt.Sprintf("namespace %s is defined more than once", ns.Name)}
+		}
+		seen[ns.Name] = true
+	}
+
+	return namespaces, nil
+}
+
+// Compile parses schema text and compiles every namespace it defines
+func Compile(source string) ([]*api.NamespaceConfig, error) {
+	namespaces, err := Parse(source)
+	if err != nil {
+		return nil, err
+	}
+
+	configs := make([]*api.NamespaceConfig, len(namespaces))
+	for i, ns := range namespaces {
+		configs[i] = ns.Config()
+	}
+	return configs, nil
+}
+
+// Config compiles the namespace to a configuration with JSON rewrite rules
+func (n *Namespace) Config() *api.NamespaceConfig {
+	config := &api.NamespaceConfig{
+		Name:      n.Name,
+		Relations: make([]*api.RelationConfig, len(n.Relations)),
+	}
+	for i, rel := range n.Relations {
+		config.Relations[i] = &api.RelationConfig{
+			Name:          rel.Name,
+			RewriteRules:  rel.Rule.String(),
+			AllowWildcard: rel.AllowWildcard,
+		}
+	}
+	return config
+}
+
+// Decompile writes namespace configurations as schema text
+// Compiling the text yields configurations with the same meaning, and the
+// same rewrite rules for rules written as a union at the root
+func Decompile(configs ...*api.NamespaceConfig) (string, error) {
+	var b strings.Builder
+	for i, config := range configs {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		if !isName(config.GetName()) {
+			return "", fmt.Errorf("namespace name %q cannot be written as schema text", config.GetName())
+		}
+		fmt.Fprintf(&b, "namespace %s {\n", config.GetName())
+
+		for _, rel := range config.GetRelations() {
+			rule, err := rewrite.Parse(rel.GetRewriteRules())
+			if err != nil {
+				return "", fmt.Errorf("relation %s#%s: %w", config.GetName(), rel.GetName(), err)
+			}
+
+			d := &decompiler{wildcard: rel.GetAllowWildcard()}
+			name := d.name(rel.GetName())
+			expression := d.root(rule)
+			if d.err != nil {
+				return "", fmt.Errorf("relation %s#%s: %w", config.GetName(), rel.GetName(), d.err)
+			}
+			fmt.Fprintf(&b, "  define %s: %s\n", name, expression)
+		}
+
+		b.WriteString("}\n")
+	}
+	return b.String(), nil
+}
+
+// placement is where a rule appears in the expression written for its parent
+type placement int
+
+const (
+	// inUnion is an operand of "or"
+	inUnion placement = iota
+
+	// inIntersection is an operand of "and"
+	inIntersection
+
+	// inTerm is a single term, such as the operand of "but not"
+	inTerm
+)
+
+// decompiler writes the rule of a relation as an expression
+type decompiler struct {
+	wildcard bool
+	err      error
+}
+
+// root writes the rule of a relation, which may end with "but not"
+func (d *decompiler) root(r *rewrite.Rule) string {
+	if r.Exclusion != nil {
+		return d.exclusion(r.Exclusion)
+	}
+	if r.Union != nil {
+		return d.union(r.Union)
+	}
+	return d.rule(r, inUnion)
+}
+
+// rule writes a rule, parenthesized when its parent would otherwise bind
+// its operands differently
+func (d *decompiler) rule(r *rewrite.Rule, at placement) string {
+	switch {
+	case r.This != nil:
+		if d.wildcard {
+			return "[user, user:*]"
+		}
+		return "[user]"
+	case r.ComputedUserset != nil:
+		return d.name(r.ComputedUserset.Relation)
+	case r.TupleToUserset != nil:
+		return d.name(r.TupleToUserset.ComputedUserset.Relation) + " from " + d.name(r.TupleToUserset.Tupleset.Relation)
+	case r.Union != nil:
+		return "(" + d.union(r.Union) + ")"
+	case r.Intersection != nil:
+		expression := d.join(r.Intersection.Child, " and ", inIntersection)
+		if at == inUnion {
+			return expression
+		}
+		return "(" + expression + ")"
+	case r.Exclusion != nil:
+		return "(" + d.exclusion(r.Exclusion) + ")"
+	}
+	return ""
+}
+
+// union writes the children of a union joined by "or"
+func (d *decompiler) union(op *rewrite.SetOperation) string {
+	if len(op.Child) == 0 {
+		d.err = fmt.Errorf("a union without children cannot be written as schema text")
+		return ""
+	}
+	return d.join(op.Child, " or ", inUnion)
+}
+
+// exclusion writes an exclusion, whose base is written like a union
+func (d *decompiler) exclusion(ex *rewrite.Exclusion) string {
+	base := d.rule(ex.Base, inUnion)
+	if ex.Base.Union != nil {
+		base = d.union(ex.Base.Union)
+	}
+	return base + " but not " + d.rule(ex.Exclude, inTerm)
+}
+
+// name writes a relation name, failing for names the language cannot express
+func (d *decompiler) name(name string) string {
+	if !isName(name) {
+		d.err = fmt.Errorf("relation name %q cannot be written as schema text", name)
+	}
+	return name
+}
+
+func (d *decompiler) join(children []*rewrite.Rule, separator string, at placement) string {
+	parts := make([]string, len(children))
+	for i, child := range children {
+		parts[i] = d.rule(child, at)
+	}
+	return strings.Join(parts, separator)
+}
@@ -0,0 +1,245 @@
+package schema
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/DangVTNhan/goacl/api"
+	"github.com/DangVTNhan/goacl/internal/rewrite"
+)
+
+const initialSchema = `
+// Documents can be shared directly or through their owners and editors
+namespace documents {
+  define owner: [user]
+  define editor: [user] or owner
+  define viewer: [user] or editor
+  define parent: [folders]
+}
+
+namespace folders {
+  define owner: [user]
+  define editor: [user] or owner
+  define viewer: [user] or editor or viewer from parent
+  define parent: [folders]
+}
+
+namespace groups {
+  define member: [user, groups#member] or member from parent
+  define admin: [user]
+  define parent: [groups]
+}
+
+namespace organizations {
+  define member: [user]
+  define admin: [user] or owner
+  define owner: [user]
+}
+`
+
+// initialConfigs returns the namespaces of initialSchema as API
+// configurations, as they were written before schema text existed
+func initialConfigs() []*api.NamespaceConfig {
+	direct := `{"union": {"child": [{"_this": {}}]}}`
+	return []*api.NamespaceConfig{
+		{Name: "documents", Relations: []*api.RelationConfig{
+			{Name: "owner", RewriteRules: direct},
+			{Name: "editor", RewriteRules: `{"union": {"child": [{"_this": {}}, {"computed_userset": {"relation": "owner"}}]}}`},
+			{Name: "viewer", RewriteRules: `{"union": {"child": [{"_this": {}}, {"computed_userset": {"relation": "editor"}}]}}`},
+			{Name: "parent", RewriteRules: direct},
+		}},
+		{Name: "folders", Relations: []*api.RelationConfig{
+			{Name: "owner", RewriteRules: direct},
+			{Name: "editor", RewriteRules: `{"union": {"child": [{"_this": {}}, {"computed_userset": {"relation": "owner"}}]}}`},
+			{Name: "viewer", RewriteRules: `{"union": {"child": [{"_this": {}}, {"computed_userset": {"relation": "editor"}}, {"tuple_to_userset": {"tupleset": {"relation": "parent"}, "computed_userset": {"relation": "viewer"}}}]}}`},
+			{Name: "parent", RewriteRules: direct},
+		}},
+		{Name: "groups", Relations: []*api.RelationConfig{
+			{Name: "member", RewriteRules: `{"union": {"child": [{"_this": {}}, {"tuple_to_userset": {"tupleset": {"relation": "parent"}, "computed_userset": {"relation": "member"}}}]}}`},
+			{Name: "admin", RewriteRules: direct},
+			{Name: "parent", RewriteRules: direct},
+		}},
+		{Name: "organizations", Relations: []*api.RelationConfig{
+			{Name: "member", RewriteRules: direct},
+			{Name: "admin", RewriteRules: `{"union": {"child": [{"_this": {}}, {"computed_userset": {"relation": "owner"}}]}}`},
+			{Name: "owner", RewriteRules: direct},
+		}},
+	}
+}
+
+// assertSameConfigs compares configurations by their parsed rewrite rules
+func assertSameConfigs(t *testing.T, got, want []*api.NamespaceConfig) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("Expected %d namespaces, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if got[i].GetName() != want[i].GetName() || len(got[i].GetRelations()) != len(want[i].GetRelations()) {
+			t.Errorf("Expected namespace %s, got %v", want[i].GetName(), got[i])
+			continue
+		}
+		for j, rel := range want[i].GetRelations() {
+			wantRule, err := rewrite.Parse(rel.GetRewriteRules())
+			if err != nil {
+				t.Fatalf("Failed to parse rewrite rules: %v", err)
+			}
+			gotRel := got[i].GetRelations()[j]
+			if gotRel.GetName() != rel.GetName() || gotRel.GetRewriteRules() != wantRule.String() || gotRel.GetAllowWildcard() != rel.GetAllowWildcard() {
+				t.Errorf("Expected %s#%s to compile to %s (wildcard %v), got %v", want[i].GetName(), rel.GetName(), wantRule, rel.GetAllowWildcard(), gotRel)
+			}
+		}
+	}
+}
+
+func TestCompileInitialNamespaces(t *testing.T) {
+	configs, err := Compile(initialSchema)
+	if err != nil {
+		t.Fatalf("Compile returned error: %v", err)
+	}
+	assertSameConfigs(t, configs, initialConfigs())
+}
+
+func TestDecompileRoundTrip(t *testing.T) {
+	text, err := Decompile(initialConfigs()...)
+	if err != nil {
+		t.Fatalf("Decompile returned error: %v", err)
+	}
+
+	configs, err := Compile(text)
+	if err != nil {
+		t.Fatalf("Failed to compile decompiled schema:\n%s\n%v", text, err)
+	}
+	assertSameConfigs(t, configs, initialConfigs())
+}
+
+func TestWildcardRoundTrip(t *testing.T) {
+	configs, err := Compile("namespace pages {\n  define viewer: [user, user:*]\n  define owner: [user]\n}")
+	if err != nil {
+		t.Fatalf("Compile returned error: %v", err)
+	}
+	if !configs[0].GetRelations()[0].GetAllowWildcard() || configs[0].GetRelations()[1].GetAllowWildcard() {
+		t.Fatalf("Expected only viewer to allow wildcards, got %v", configs[0].GetRelations())
+	}
+
+	text, err := Decompile(configs...)
+	if err != nil {
+		t.Fatalf("Decompile returned error: %v", err)
+	}
+	if !strings.Contains(text, "define viewer: [user, user:*]") {
+		t.Errorf("Expected the wildcard in the decompiled schema, got:\n%s", text)
+	}
+}
+
+func TestOperatorPrecedence(t *testing.T) {
+	tests := map[string]string{
+		`[user] or editor and member from org`:          `{"union":{"child":[{"_this":{}},{"intersection":{"child":[{"computed_userset":{"relation":"editor"}},{"tuple_to_userset":{"tupleset":{"relation":"org"},"computed_userset":{"relation":"member"}}}]}}]}}`,
+		`([user] or editor) and member`:                 `{"union":{"child":[{"intersection":{"child":[{"union":{"child":[{"_this":{}},{"computed_userset":{"relation":"editor"}}]}},{"computed_userset":{"relation":"member"}}]}}]}}`,
+		`[user] or editor but not blocked`:              `{"exclusion":{"base":{"union":{"child":[{"_this":{}},{"computed_userset":{"relation":"editor"}}]}},"exclude":{"computed_userset":{"relation":"blocked"}}}}`,
+		`editor and (member but not blocked) or [user]`: `{"union":{"child":[{"intersection":{"child":[{"computed_userset":{"relation":"editor"}},{"exclusion":{"base":{"computed_userset":{"relation":"member"}},"exclude":{"computed_userset":{"relation":"blocked"}}}}]}},{"_this":{}}]}}`,
+	}
+
+	for expression, want := range tests {
+		configs, err := Compile("namespace docs { define viewer: " + expression + " }")
+		if err != nil {
+			t.Fatalf("Compile(%s) returned error: %v", expression, err)
+		}
+		got := configs[0].GetRelations()[0].GetRewriteRules()
+		if got != want {
+			t.Errorf("Expected %s to compile to\n%s\ngot\n%s", expression, want, got)
+		}
+
+		// Decompiling writes the same rule back
+		text, err := Decompile(configs...)
+		if err != nil {
+			t.Fatalf("Decompile returned error: %v", err)
+		}
+		again, err := Compile(text)
+		if err != nil {
+			t.Fatalf("Failed to compile decompiled schema:\n%s\n%v", text, err)
+		}
+		if again[0].GetRelations()[0].GetRewriteRules() != want {
+			t.Errorf("Expected %s to survive decompiling, got\n%s", expression, text)
+		}
+	}
+}
+
+func TestSyntaxErrors(t *testing.T) {
+	tests := []struct {
+		source string
+		line   int
+		column int
+	}{
+		{"namespace docs {\n  define viewer [user]\n}", 2, 17},
+		{"namespace docs {\n  define viewer: [user] or\n}", 3, 1},
+		{"namespace docs {\n  define from: [user]\n}", 2, 10},
+		{"namespace docs {\n  define viewer: [user:]\n}", 2, 24},
+		{"namespace docs {\n  define viewer: editor ! owner\n}", 2, 25},
+		{"namespace docs {\n  define viewer: [user]\n", 3, 1},
+		{"namespace docs {}\nnamespace docs {}", 2, 1},
+	}
+
+	for _, tt := range tests {
+		_, err := Compile(tt.source)
+		var syntax *SyntaxError
+		if !errors.As(err, &syntax) {
+			t.Errorf("Expected a syntax error for %q, got %v", tt.source, err)
+			continue
+		}
+		if syntax.Line != tt.line || syntax.Column != tt.column {
+			t.Errorf("Expected the error for %q at %d:%d, got %v", tt.source, tt.line, tt.column, err)
+		}
+	}
+}
+
+func TestLoadDir(t *testing.T) {
+	// The shipped namespace directory defines the initial namespaces
+	configs, err := LoadDir("../../namespaces")
+	if err != nil {
+		t.Fatalf("LoadDir returned error: %v", err)
+	}
+	assertSameConfigs(t, configs, initialConfigs())
+	for _, config := range configs {
+		relations := make([]rewrite.Relation, len(config.GetRelations()))
+		for i, rel := range config.GetRelations() {
+			relations[i] = rewrite.Relation{Name: rel.GetName(), Rules: rel.GetRewriteRules()}
+		}
+		if problems := rewrite.Analyze(relations); len(problems) != 0 {
+			t.Errorf("Expected no problems in namespace %s, got %+v", config.GetName(), problems)
+		}
+	}
+
+	dir := t.TempDir()
+	files := map[string]string{
+		"teams.yaml": "name: teams\nrelations:\n  - name: member\n  - name: lead\n    rewrite:\n      union:\n        child:\n          - this: {}\n          - computed_userset: {relation: member}\n",
+		"more.json":  `[{"name": "projects", "relations": [{"name": "owner", "rewriteRules": "{\"_this\": {}}", "allowWildcard": true}]}]`,
+		"README.md":  "ignored",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+
+	configs, err = LoadDir(dir)
+	if err != nil {
+		t.Fatalf("LoadDir returned error: %v", err)
+	}
+	if len(configs) != 2 || configs[0].GetName() != "projects" || configs[1].GetName() != "teams" {
+		t.Fatalf("Expected projects and teams in file name order, got %v", configs)
+	}
+	if !configs[0].GetRelations()[0].GetAllowWildcard() || configs[1].GetRelations()[1].GetRewrite().GetUnion() == nil {
+		t.Errorf("Unexpected configurations %v", configs)
+	}
+
+	// A namespace may only be defined once
+	duplicate := filepath.Join(dir, "teams.acl")
+	if err := os.WriteFile(duplicate, []byte("namespace teams {}"), 0o600); err != nil {
+		t.Fatalf("Failed to write %s: %v", duplicate, err)
+	}
+	if _, err := LoadDir(dir); err == nil || !strings.Contains(err.Error(), "already defined") {
+		t.Errorf("Expected a duplicate namespace to fail, got %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package server
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/DangVTNhan/goacl/api"
+	"github.com/DangVTNhan/goacl/internal/service"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const (
+	// exportPath is the HTTP endpoint downloading an export
+	exportPath = "/v1/relations/export"
+
+	// exportTrailer is the HTTP trailer holding the consistency token of a
+	// completed export
+	exportTrailer = "Consistency-Token"
+)
+
+// exportHandler serves ExportRelations as a newline-delimited protobuf JSON
+// download
+// The status is decided by the first record, so a failure later on cuts the
+// download short without the Consistency-Token trailer
+func exportHandler(mux *runtime.ServeMux, client api.RelationshipServiceClient) runtime.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request, _ map[string]string) {
+		ctx := r.Context()
+		req := &api.ExportRelationsRequest{ConsistencyToken: r.URL.Query().Get("consistency_token")}
+
+		var trailer metadata.MD
+		stream, err := client.ExportRelations(ctx, req, grpc.Trailer(&trailer))
+		if err != nil {
+			runtime.HTTPError(ctx, mux, &runtime.JSONPb{}, w, r, err)
+			return
+		}
+
+		record, err := stream.Recv()
+		if err != nil && !errors.Is(err, io.EOF) {
+			runtime.HTTPError(ctx, mux, &runtime.JSONPb{}, w, r, err)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		w.Header().Set("Content-Disposition", `attachment; filename="goacl-export.ndjson"`)
+		w.Header().Set("Trailer", exportTrailer)
+		w.WriteHeader(http.StatusOK)
+
+		for ; err == nil; record, err = stream.Recv() {
+			line, marshalErr := protojson.Marshal(record)
+			if marshalErr != nil {
+				log.Printf("Export download failed: %v", marshalErr)
+				return
+			}
+			if _, writeErr := w.Write(append(line, '\n')); writeErr != nil {
+				return
+			}
+		}
+		if !errors.Is(err, io.EOF) {
+			log.Printf("Export download failed: %v", err)
+			return
+		}
+
+		if tokens := trailer.Get(service.ConsistencyTokenTrailer); len(tokens) > 0 {
+			w.Header().Set(exportTrailer, tokens[0])
+		}
+	}
+}
@@ -14,6 +14,7 @@ import (
 	"github.com/DangVTNhan/goacl/internal/config"
 	"github.com/DangVTNhan/goacl/internal/database"
 	"github.com/DangVTNhan/goacl/internal/handler"
+	"github.com/DangVTNhan/goacl/internal/service"
 	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
@@ -41,8 +42,17 @@ func (s *Server) Start(ctx context.Context) error {
 	// Create ping server
 	pingServer := handler.NewPingServer()
 
+	// Create authorization server
+	authorizationServer := handler.NewAuthorizationServer(service.NewAuthorizationService(s.db, s.config.Authorization))
+
+	// Create relationship server
+	relationshipServer := handler.NewRelationshipServer(service.NewRelationshipService(s.db, s.db))
+
+	// Create configuration server
+	configurationServer := handler.NewConfigurationServer(service.NewConfigurationService(s.db))
+
 	// Setup gRPC server
-	if err := s.setupGRPCServer(pingServer); err != nil {
+	if err := s.setupGRPCServer(pingServer, authorizationServer, relationshipServer, configurationServer); err != nil {
 		return fmt.Errorf("failed to setup gRPC server: %w", err)
 	}
 
@@ -105,9 +115,12 @@ func (s *Server) Stop(ctx context.Context) error {
 	}
 }
 
-func (s *Server) setupGRPCServer(pingServer *handler.PingServer) error {
+func (s *Server) setupGRPCServer(pingServer *handler.PingServer, authorizationServer *handler.AuthorizationServer, relationshipServer *handler.RelationshipServer, configurationServer *handler.ConfigurationServer) error {
 	s.grpcServer = grpc.NewServer()
 	api.RegisterPingServiceServer(s.grpcServer, pingServer)
+	api.RegisterAuthorizationServiceServer(s.grpcServer, authorizationServer)
+	api.RegisterRelationshipServiceServer(s.grpcServer, relationshipServer)
+	api.RegisterConfigurationServiceServer(s.grpcServer, configurationServer)
 	return nil
 }
 
@@ -151,6 +164,42 @@ func (s *Server) setupHTTPServer(ctx context.Context) error {
 		return fmt.Errorf("failed to register gateway: %w", err)
 	}
 
+	// Register the authorization service handler
+	if err := api.RegisterAuthorizationServiceHandler(ctx, mux, conn); err != nil {
+		err := conn.Close()
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("failed to register authorization gateway: %w", err)
+	}
+
+	// Register the relationship service handler
+	if err := api.RegisterRelationshipServiceHandler(ctx, mux, conn); err != nil {
+		err := conn.Close()
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("failed to register relationship gateway: %w", err)
+	}
+
+	// Register the configuration service handler
+	if err := api.RegisterConfigurationServiceHandler(ctx, mux, conn); err != nil {
+		err := conn.Close()
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("failed to register configuration gateway: %w", err)
+	}
+
+	// Register the export download, which streams records as they are read
+	if err := mux.HandlePath(http.MethodGet, exportPath, exportHandler(mux, api.NewRelationshipServiceClient(conn))); err != nil {
+		err := conn.Close()
+		if err != nil {
+			return err
+		}
+		return fmt.Errorf("failed to register export download: %w", err)
+	}
+
 	// Create HTTP server with the gateway
 	s.httpServer = &http.Server{
 		Addr:    localHttp,
@@ -0,0 +1,366 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/DangVTNhan/goacl/api"
+	"github.com/DangVTNhan/goacl/internal/config"
+	"github.com/DangVTNhan/goacl/internal/database"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// maxBatchChecks caps the number of checks accepted in a single batch
+const maxBatchChecks = 1000
+
+// snapshotter is implemented by readers able to serve reads at a fixed timestamp
+type snapshotter interface {
+	Snapshot(readTs uint64) *database.Snapshot
+	ReadTimestamp(ctx context.Context) (uint64, error)
+}
+
+// AuthorizationService answers authorization questions using the stored
+// relation tuples and namespace rewrite rules
+type AuthorizationService struct {
+	reader  TupleReader
+	config  config.AuthorizationConfig
+	lookups *lookupCache
+}
+
+// NewAuthorizationService creates a new authorization service
+func NewAuthorizationService(reader TupleReader, cfg config.AuthorizationConfig) *AuthorizationService {
+	return &AuthorizationService{
+		reader:  reader,
+		config:  cfg,
+		lookups: newLookupCache(),
+	}
+}
+
+// Check reports whether the user holds the requested relation on the object
+func (s *AuthorizationService) Check(ctx context.Context, req *api.CheckRequest) (*api.CheckResponse, error) {
+	if err := validateCheckRequest(req); err != nil {
+		return nil, err
+	}
+
+	checker, err := s.checkerAt(req.GetConsistencyToken())
+	if err != nil {
+		return nil, err
+	}
+
+	if req.GetDebug() || s.config.Debug {
+		return s.debugCheck(ctx, checker, req)
+	}
+
+	result, err := checker.Check(ctx, req.GetNamespace(), req.GetObjectId(), req.GetRelation(), req.GetUserId(), req.GetContext())
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &api.CheckResponse{
+		Allowed:          result.Allowed,
+		ConsistencyToken: req.GetConsistencyToken(),
+		CheckedAt:        timestamppb.Now(),
+		Conditional:      result.Conditional,
+		MissingContext:   result.MissingContext,
+	}, nil
+}
+
+// debugCheck runs a check while tracing its resolution and counting the
+// reads it issues, and reports both in the response debug info
+// The answer counts as served from cache when Redis answered every tuple read
+func (s *AuthorizationService) debugCheck(ctx context.Context, checker *Checker, req *api.CheckRequest) (*api.CheckResponse, error) {
+	ctx, stats := database.WithQueryStats(ctx)
+	start := time.Now()
+
+	result, trace, err := checker.CheckTraced(ctx, req.GetNamespace(), req.GetObjectId(), req.GetRelation(), req.GetUserId(), req.GetContext())
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	elapsed := time.Since(start)
+	queries := stats.DgraphQueries.Load()
+	cacheHits := stats.CacheHits.Load()
+	cacheMisses := stats.CacheMisses.Load()
+
+	return &api.CheckResponse{
+		Allowed:          result.Allowed,
+		ConsistencyToken: req.GetConsistencyToken(),
+		CheckedAt:        timestamppb.Now(),
+		Conditional:      result.Conditional,
+		MissingContext:   result.MissingContext,
+		DebugInfo: &api.DebugInfo{
+			ResolutionPath:   trace,
+			ResolutionTimeMs: elapsed.Milliseconds(),
+			FromCache:        cacheHits > 0 && cacheMisses == 0,
+			Metadata: map[string]string{
+				"dgraph_queries":     strconv.FormatInt(queries, 10),
+				"cache_hits":         strconv.FormatInt(cacheHits, 10),
+				"cache_misses":       strconv.FormatInt(cacheMisses, 10),
+				"resolution_time_us": strconv.FormatInt(elapsed.Microseconds(), 10),
+			},
+		},
+	}, nil
+}
+
+// BatchCheck evaluates many checks at once
+// Checks sharing a consistency token are evaluated together against a single
+// snapshot, reusing intermediate results between them
+func (s *AuthorizationService) BatchCheck(ctx context.Context, req *api.BatchCheckRequest) (*api.BatchCheckResponse, error) {
+	checks := req.GetChecks()
+	if len(checks) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "checks must not be empty")
+	}
+	if len(checks) > maxBatchChecks {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d checks may be batched, got %d", maxBatchChecks, len(checks))
+	}
+
+	// Group checks by the consistency token they are evaluated at
+	var tokens []string
+	groups := make(map[string][]int)
+	for i, check := range checks {
+		if err := validateCheckRequest(check); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "checks[%d]: %s", i, status.Convert(err).Message())
+		}
+
+		token := check.GetConsistencyToken()
+		if token == "" {
+			token = req.GetConsistencyToken()
+		}
+		if _, ok := groups[token]; !ok {
+			tokens = append(tokens, token)
+		}
+		groups[token] = append(groups[token], i)
+	}
+
+	results := make([]*api.CheckResponse, len(checks))
+	for _, token := range tokens {
+		checker, err := s.checkerAt(token)
+		if err != nil {
+			return nil, err
+		}
+
+		indexes := groups[token]
+		items := make([]CheckItem, len(indexes))
+		for i, index := range indexes {
+			check := checks[index]
+			items[i] = CheckItem{
+				Namespace: check.GetNamespace(),
+				ObjectID:  check.GetObjectId(),
+				Relation:  check.GetRelation(),
+				UserID:    check.GetUserId(),
+				Context:   check.GetContext(),
+			}
+		}
+
+		checked, err := checker.BatchCheck(ctx, items)
+		if err != nil {
+			return nil, toStatusError(err)
+		}
+
+		checkedAt := timestamppb.Now()
+		for i, index := range indexes {
+			results[index] = &api.CheckResponse{
+				Allowed:          checked[i].Allowed,
+				ConsistencyToken: token,
+				CheckedAt:        checkedAt,
+				Conditional:      checked[i].Conditional,
+				MissingContext:   checked[i].MissingContext,
+			}
+		}
+	}
+
+	return &api.BatchCheckResponse{
+		Results:          results,
+		ConsistencyToken: req.GetConsistencyToken(),
+	}, nil
+}
+
+// Expand returns the userset tree of the requested object relation
+func (s *AuthorizationService) Expand(ctx context.Context, req *api.ExpandRequest) (*api.ExpandResponse, error) {
+	switch {
+	case req.GetNamespace() == "":
+		return nil, status.Error(codes.InvalidArgument, "namespace is required")
+	case req.GetObjectId() == "":
+		return nil, status.Error(codes.InvalidArgument, "object_id is required")
+	case req.GetRelation() == "":
+		return nil, status.Error(codes.InvalidArgument, "relation is required")
+	case req.GetMaxDepth() < 0:
+		return nil, status.Error(codes.InvalidArgument, "max_depth must not be negative")
+	}
+
+	checker, err := s.checkerAt(req.GetConsistencyToken())
+	if err != nil {
+		return nil, err
+	}
+
+	userset, err := checker.Expand(ctx, req.GetNamespace(), req.GetObjectId(), req.GetRelation(), int(req.GetMaxDepth()))
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &api.ExpandResponse{
+		Userset:          userset,
+		ConsistencyToken: req.GetConsistencyToken(),
+		ExpandedAt:       timestamppb.Now(),
+	}, nil
+}
+
+// ListPermissions returns the object relations held by the user, one page at a time
+// Every page is looked up at the snapshot of the first, which the page token
+// carries, so pages neither miss nor repeat permissions changed meanwhile
+// A lookup finds every permission of the user at once, so its results are
+// cached per snapshot and the following pages are cut from them; readers
+// without snapshots repeat the lookup for every page
+func (s *AuthorizationService) ListPermissions(ctx context.Context, req *api.ListPermissionsRequest) (*api.ListPermissionsResponse, error) {
+	if req.GetUserId() == "" {
+		return nil, status.Error(codes.InvalidArgument, "user_id is required")
+	}
+	if isWildcard(req.GetUserId()) {
+		return nil, status.Error(codes.InvalidArgument, "user_id must not be a wildcard")
+	}
+	if req.GetPageSize() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "page_size must not be negative")
+	}
+	if req.GetObjectId() != "" && req.GetNamespace() == "" {
+		return nil, status.Error(codes.InvalidArgument, "namespace is required when filtering by object_id")
+	}
+
+	token, err := decodePageToken(req.GetPageToken())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	readTs := token.ReadTs
+	if readTs == 0 && req.GetConsistencyToken() != "" {
+		readTs, err = database.DecodeConsistencyToken(req.GetConsistencyToken())
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	checker, readTs, err := s.pinnedChecker(ctx, readTs)
+	if err != nil {
+		return nil, err
+	}
+
+	resources, err := s.lookupResources(ctx, checker, readTs, req.GetUserId())
+	if err != nil {
+		return nil, err
+	}
+	if token.After != "" {
+		resources = resources[sort.Search(len(resources), func(i int) bool {
+			return resources[i].String() > token.After
+		}):]
+	}
+
+	size := pageSize(req.GetPageSize())
+	resp := &api.ListPermissionsResponse{ConsistencyToken: req.GetConsistencyToken()}
+	if readTs != 0 {
+		resp.ConsistencyToken = database.EncodeConsistencyToken(readTs)
+	}
+	for _, ref := range resources {
+		if req.GetNamespace() != "" && ref.Namespace != req.GetNamespace() {
+			continue
+		}
+		if req.GetObjectId() != "" && ref.ObjectID != req.GetObjectId() {
+			continue
+		}
+		if len(resp.Permissions) == size {
+			last := resp.Permissions[size-1]
+			resp.NextPageToken = encodePageToken(pageToken{
+				After:  objectRef{Namespace: last.Namespace, ObjectID: last.ObjectId, Relation: last.Relation}.String(),
+				ReadTs: readTs,
+			})
+			break
+		}
+
+		resp.Permissions = append(resp.Permissions, &api.Permission{
+			Namespace: ref.Namespace,
+			ObjectId:  ref.ObjectID,
+			Relation:  ref.Relation,
+			Allowed:   true,
+			Wildcard:  ref.Wildcard,
+		})
+	}
+
+	return resp, nil
+}
+
+// lookupResources returns the resources of the user at readTs, ordered by
+// namespace, object and relation, reusing the lookup of an earlier page
+func (s *AuthorizationService) lookupResources(ctx context.Context, checker *Checker, readTs uint64, userID string) ([]resource, error) {
+	if readTs != 0 {
+		if resources, ok := s.lookups.get(readTs, userID); ok {
+			return resources, nil
+		}
+	}
+
+	resources, err := checker.LookupResources(ctx, userID)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	if readTs != 0 {
+		s.lookups.put(readTs, userID, resources)
+	}
+	return resources, nil
+}
+
+// checkerAt returns a checker reading at the snapshot identified by the
+// consistency token, or at the latest data when the token is empty
+func (s *AuthorizationService) checkerAt(token string) (*Checker, error) {
+	if token == "" {
+		return NewChecker(s.reader, s.config.MaxDepth), nil
+	}
+
+	readTs, err := database.DecodeConsistencyToken(token)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	if snapshots, ok := s.reader.(snapshotter); ok {
+		return NewChecker(snapshots.Snapshot(readTs), s.config.MaxDepth), nil
+	}
+
+	return NewChecker(s.reader, s.config.MaxDepth), nil
+}
+
+// pinnedChecker returns a checker reading at readTs, or at the latest
+// snapshot when readTs is zero, along with the timestamp it reads at
+// Readers without snapshots always read the latest data, at timestamp zero
+func (s *AuthorizationService) pinnedChecker(ctx context.Context, readTs uint64) (*Checker, uint64, error) {
+	snapshots, ok := s.reader.(snapshotter)
+	if !ok {
+		return NewChecker(s.reader, s.config.MaxDepth), 0, nil
+	}
+
+	if readTs == 0 {
+		var err error
+		readTs, err = snapshots.ReadTimestamp(ctx)
+		if err != nil {
+			return nil, 0, toStatusError(err)
+		}
+	}
+	return NewChecker(snapshots.Snapshot(readTs), s.config.MaxDepth), readTs, nil
+}
+
+// validateCheckRequest ensures all required fields of a check are present and
+// that the subject is a concrete user rather than a wildcard
+func validateCheckRequest(req *api.CheckRequest) error {
+	switch {
+	case req.GetNamespace() == "":
+		return status.Error(codes.InvalidArgument, "namespace is required")
+	case req.GetObjectId() == "":
+		return status.Error(codes.InvalidArgument, "object_id is required")
+	case req.GetRelation() == "":
+		return status.Error(codes.InvalidArgument, "relation is required")
+	case req.GetUserId() == "":
+		return status.Error(codes.InvalidArgument, "user_id is required")
+	case isWildcard(req.GetUserId()):
+		return status.Error(codes.InvalidArgument, "user_id must not be a wildcard")
+	}
+	return nil
+}
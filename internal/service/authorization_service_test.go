@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DangVTNhan/goacl/api"
+	"github.com/DangVTNhan/goacl/internal/config"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestListPermissionsPagination(t *testing.T) {
+	reader := newMemoryReader()
+	reader.add("documents", "doc1", "owner", "alice")
+	reader.add("documents", "doc2", "viewer", "alice")
+	reader.add("folders", "f1", "viewer", "alice")
+
+	svc := NewAuthorizationService(reader, config.AuthorizationConfig{})
+
+	var permissions []string
+	req := &api.ListPermissionsRequest{UserId: "alice", PageSize: 2}
+	for page := 0; ; page++ {
+		if page > 10 {
+			t.Fatal("Pagination did not terminate")
+		}
+
+		resp, err := svc.ListPermissions(context.Background(), req)
+		if err != nil {
+			t.Fatalf("ListPermissions returned error: %v", err)
+		}
+		if len(resp.GetPermissions()) > 2 {
+			t.Fatalf("Expected at most 2 permissions per page, got %d", len(resp.GetPermissions()))
+		}
+
+		for _, p := range resp.GetPermissions() {
+			permissions = append(permissions, p.GetNamespace()+":"+p.GetObjectId()+"#"+p.GetRelation())
+		}
+
+		if resp.GetNextPageToken() == "" {
+			break
+		}
+		req.PageToken = resp.GetNextPageToken()
+	}
+
+	expected := []string{
+		"documents:doc1#editor",
+		"documents:doc1#owner",
+		"documents:doc1#viewer",
+		"documents:doc2#viewer",
+		"folders:f1#viewer",
+	}
+
+	if len(permissions) != len(expected) {
+		t.Fatalf("Expected %v, got %v", expected, permissions)
+	}
+	for i := range expected {
+		if permissions[i] != expected[i] {
+			t.Errorf("Expected %s at position %d, got %s", expected[i], i, permissions[i])
+		}
+	}
+
+	filtered, err := svc.ListPermissions(context.Background(), &api.ListPermissionsRequest{UserId: "alice", Namespace: "folders"})
+	if err != nil {
+		t.Fatalf("ListPermissions returned error: %v", err)
+	}
+	if len(filtered.GetPermissions()) != 1 {
+		t.Errorf("Expected 1 folder permission, got %d", len(filtered.GetPermissions()))
+	}
+
+	if _, err := svc.ListPermissions(context.Background(), &api.ListPermissionsRequest{UserId: "alice", PageToken: "!!"}); err == nil {
+		t.Error("Expected error for malformed page token")
+	}
+}
+
+func TestWildcardSubjectsRejected(t *testing.T) {
+	svc := NewAuthorizationService(newMemoryReader(), config.AuthorizationConfig{})
+	ctx := context.Background()
+
+	for _, userID := range []string{"*", "user:*"} {
+		check := &api.CheckRequest{Namespace: "documents", ObjectId: "doc1", Relation: "viewer", UserId: userID}
+		if _, err := svc.Check(ctx, check); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Check(%s): expected InvalidArgument, got %v", userID, err)
+		}
+		if _, err := svc.BatchCheck(ctx, &api.BatchCheckRequest{Checks: []*api.CheckRequest{check}}); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("BatchCheck(%s): expected InvalidArgument, got %v", userID, err)
+		}
+		if _, err := svc.ListPermissions(ctx, &api.ListPermissionsRequest{UserId: userID}); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("ListPermissions(%s): expected InvalidArgument, got %v", userID, err)
+		}
+	}
+}
+
+func TestLookupCache(t *testing.T) {
+	cache := newLookupCache()
+	for readTs := uint64(1); readTs <= maxCachedLookups+1; readTs++ {
+		cache.put(readTs, "alice", []resource{{objectRef: objectRef{"documents", "doc1", "viewer"}}})
+	}
+
+	if _, ok := cache.get(1, "alice"); ok {
+		t.Error("Expected the oldest lookup to be evicted")
+	}
+	if resources, ok := cache.get(maxCachedLookups+1, "alice"); !ok || len(resources) != 1 {
+		t.Errorf("Expected the latest lookup to be cached, got %v", resources)
+	}
+	if _, ok := cache.get(maxCachedLookups+1, "bob"); ok {
+		t.Error("Expected lookups to be cached per user")
+	}
+}
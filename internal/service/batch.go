@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// batchConcurrency bounds how many checks of a batch are evaluated at once
+const batchConcurrency = 16
+
+// CheckItem is a single check within a batch
+type CheckItem struct {
+	Namespace string
+	ObjectID  string
+	Relation  string
+	UserID    string
+
+	// Context holds the values tuple conditions are evaluated against
+	Context map[string]string
+}
+
+// BatchCheck evaluates the checks concurrently against a shared evaluation,
+// so identical checks run once and intermediate results such as group
+// memberships are reused across the batch
+// Checks with different request contexts share reads but not results
+// Results are returned in the order of the checks
+func (c *Checker) BatchCheck(ctx context.Context, checks []CheckItem) ([]Result, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	base := c.newEvaluation()
+	evaluations := make(map[string]*evaluation)
+
+	// Deduplicate identical checks so each is evaluated once
+	keys := make([]batchKey, len(checks))
+	positions := make(map[batchKey]int, len(checks))
+	var (
+		unique     []batchKey
+		firstIndex []int
+	)
+	for index, item := range checks {
+		key := batchKey{
+			check: checkKey{
+				object: objectRef{Namespace: item.Namespace, ObjectID: item.ObjectID, Relation: item.Relation},
+				userID: item.UserID,
+			},
+			context: contextKey(item.Context),
+		}
+		keys[index] = key
+
+		if _, ok := evaluations[key.context]; !ok {
+			evaluations[key.context] = base.withContext(item.Context)
+		}
+		if _, ok := positions[key]; !ok {
+			positions[key] = len(unique)
+			unique = append(unique, key)
+			firstIndex = append(firstIndex, index)
+		}
+	}
+
+	results := make([]Result, len(unique))
+	var (
+		wg       sync.WaitGroup
+		errOnce  sync.Once
+		firstErr error
+	)
+
+	sem := make(chan struct{}, batchConcurrency)
+	for i, key := range unique {
+		wg.Add(1)
+		go func(i int, key batchKey) {
+			defer wg.Done()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				return
+			}
+
+			result, err := evaluations[key.context].check(ctx, key.check.object, key.check.userID, nil)
+			if err != nil {
+				errOnce.Do(func() {
+					firstErr = fmt.Errorf("checks[%d]: %w", firstIndex[i], err)
+					cancel()
+				})
+				return
+			}
+			results[i] = result
+		}(i, key)
+	}
+
+	wg.Wait()
+
+	if firstErr != nil {
+		return nil, firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	ordered := make([]Result, len(checks))
+	for i, key := range keys {
+		ordered[i] = results[positions[key]]
+	}
+
+	return ordered, nil
+}
+
+// batchKey identifies a check of a batch together with its request context
+type batchKey struct {
+	check   checkKey
+	context string
+}
+
+// contextKey returns a canonical form of a request context, treating a nil
+// and an empty context alike
+func contextKey(requestContext map[string]string) string {
+	if len(requestContext) == 0 {
+		return ""
+	}
+
+	// Maps are encoded with sorted keys, so equal contexts encode identically
+	encoded, _ := json.Marshal(requestContext)
+	return string(encoded)
+}
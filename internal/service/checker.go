@@ -0,0 +1,681 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/DangVTNhan/goacl/internal/condition"
+	"github.com/DangVTNhan/goacl/internal/database"
+	"github.com/DangVTNhan/goacl/internal/rewrite"
+)
+
+// defaultMaxDepth bounds how deep rewrite evaluation may recurse when no
+// other limit is configured
+const defaultMaxDepth = 50
+
+var (
+	// errUnknownRelation is returned when a relation is not defined in its namespace
+	errUnknownRelation = errors.New("relation not defined")
+
+	// errDepthExceeded is returned when evaluation recurses deeper than the maximum depth
+	errDepthExceeded = errors.New("maximum resolution depth exceeded")
+
+	// errCycleDetected is returned when evaluation reaches an object relation
+	// that is already being resolved on the current path
+	errCycleDetected = errors.New("cycle detected")
+)
+
+// resolutionError reports a resolution that was aborted because it could not
+// terminate, together with the path of object relations that led there
+type resolutionError struct {
+	cause error
+	path  []objectRef
+}
+
+func (e *resolutionError) Error() string {
+	return fmt.Sprintf("%v: %s", e.cause, formatPath(e.path))
+}
+
+func (e *resolutionError) Unwrap() error {
+	return e.cause
+}
+
+// formatPath joins object relations into a readable resolution path
+func formatPath(path []objectRef) string {
+	parts := make([]string, len(path))
+	for i, ref := range path {
+		parts[i] = ref.String()
+	}
+	return strings.Join(parts, " -> ")
+}
+
+// TupleReader provides the namespace configurations and relation tuples
+// needed to evaluate permissions
+type TupleReader interface {
+	GetNamespaceConfig(ctx context.Context, name string) (*database.NamespaceConfig, error)
+	ListNamespaceConfigs(ctx context.Context) ([]database.NamespaceConfig, error)
+	GetRelationTuples(ctx context.Context, namespace, objectID, relation string) ([]database.RelationTuple, error)
+	GetTuplesByUser(ctx context.Context, userID string) ([]database.RelationTuple, error)
+	GetTuplesByUsersetObject(ctx context.Context, namespace, objectID string) ([]database.RelationTuple, error)
+}
+
+// Checker evaluates userset rewrite rules against stored relation tuples
+type Checker struct {
+	reader   TupleReader
+	maxDepth int
+}
+
+// NewChecker creates a new checker reading from the given reader
+// Resolution paths longer than maxDepth fail; zero selects the default limit
+func NewChecker(reader TupleReader, maxDepth int) *Checker {
+	if maxDepth <= 0 {
+		maxDepth = defaultMaxDepth
+	}
+	return &Checker{reader: reader, maxDepth: maxDepth}
+}
+
+// Result is the outcome of a permission check
+type Result struct {
+	// Allowed is set when the user definitely holds the relation
+	Allowed bool
+
+	// Conditional is set when the outcome depends on tuple conditions that
+	// need context keys the request did not provide
+	Conditional bool
+
+	// MissingContext lists the context keys needed to decide a conditional result
+	MissingContext []string
+}
+
+var (
+	deniedResult  = Result{}
+	allowedResult = Result{Allowed: true}
+)
+
+// isDenied reports whether the result definitely denies access
+func (r Result) isDenied() bool {
+	return !r.Allowed && !r.Conditional
+}
+
+// or combines the results of alternatives that each grant access
+func (r Result) or(other Result) Result {
+	switch {
+	case r.Allowed || other.Allowed:
+		return allowedResult
+	case r.Conditional || other.Conditional:
+		return conditional(r.MissingContext, other.MissingContext)
+	}
+	return deniedResult
+}
+
+// and combines the results of requirements that must all grant access
+func (r Result) and(other Result) Result {
+	switch {
+	case r.isDenied() || other.isDenied():
+		return deniedResult
+	case r.Allowed && other.Allowed:
+		return allowedResult
+	}
+	return conditional(r.MissingContext, other.MissingContext)
+}
+
+// not inverts a result, leaving conditional results undecided
+func (r Result) not() Result {
+	switch {
+	case r.Allowed:
+		return deniedResult
+	case r.Conditional:
+		return r
+	}
+	return allowedResult
+}
+
+// String describes the result for resolution traces
+func (r Result) String() string {
+	switch {
+	case r.Allowed:
+		return "allowed"
+	case r.Conditional:
+		return "conditional on " + strings.Join(r.MissingContext, ", ")
+	}
+	return "denied"
+}
+
+// conditional builds a conditional result from the union of the missing keys
+func conditional(missing ...[]string) Result {
+	seen := make(map[string]bool)
+	var keys []string
+	for _, list := range missing {
+		for _, key := range list {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+
+	return Result{Conditional: true, MissingContext: keys}
+}
+
+// Check reports whether the user has the relation on the object
+// Conditions attached to tuples are evaluated against the request context
+func (c *Checker) Check(ctx context.Context, namespace, objectID, relation, userID string, requestContext map[string]string) (Result, error) {
+	e := c.newEvaluation().withContext(requestContext)
+	return e.check(ctx, objectRef{Namespace: namespace, ObjectID: objectID, Relation: relation}, userID, nil)
+}
+
+// CheckTraced is like Check but also returns the rewrite nodes visited and
+// the tuples that satisfied them, in the order they were evaluated
+func (c *Checker) CheckTraced(ctx context.Context, namespace, objectID, relation, userID string, requestContext map[string]string) (Result, []string, error) {
+	e := c.newEvaluation().withContext(requestContext)
+	e.trace = &resolutionTrace{}
+
+	result, err := e.check(ctx, objectRef{Namespace: namespace, ObjectID: objectID, Relation: relation}, userID, nil)
+	return result, e.trace.steps, err
+}
+
+// evaluation holds the state of a single permission evaluation
+// It is safe for concurrent use so that the checks of a batch can share
+// parsed namespaces and intermediate results
+type evaluation struct {
+	reader   TupleReader
+	maxDepth int
+	reads    *readCache
+
+	// context holds the request values tuple conditions are evaluated against
+	context map[string]string
+	now     time.Time
+
+	mu      sync.Mutex
+	results map[checkKey]Result
+
+	// trace records the resolution steps when set
+	trace *resolutionTrace
+}
+
+// resolutionTrace collects the steps of a traced evaluation
+type resolutionTrace struct {
+	mu    sync.Mutex
+	steps []string
+}
+
+// record appends a step to the trace, indented by the depth of the path
+func (e *evaluation) record(path []objectRef, format string, args ...any) {
+	if e.trace == nil {
+		return
+	}
+
+	step := strings.Repeat("  ", len(path)) + fmt.Sprintf(format, args...)
+	e.trace.mu.Lock()
+	e.trace.steps = append(e.trace.steps, step)
+	e.trace.mu.Unlock()
+}
+
+// readCache holds what an evaluation has read and parsed, which stays valid
+// regardless of the request context and may therefore be shared
+type readCache struct {
+	mu         sync.Mutex
+	namespaces map[string]*namespaceRules
+	tuples     map[objectRef]*tupleRead
+	conditions map[string]*condition.Expression
+}
+
+// tupleRead is a tuple read shared by every caller asking for the same
+// object relation during an evaluation
+type tupleRead struct {
+	done   chan struct{}
+	tuples []database.RelationTuple
+	err    error
+}
+
+// checkKey identifies a single object relation check for a user
+type checkKey struct {
+	object objectRef
+	userID string
+}
+
+// namespaceRules holds the parsed rewrite rules of a namespace
+type namespaceRules struct {
+	name  string
+	rules map[string]*rewrite.Rule
+
+	// wildcards holds the relations that may be granted to wildcard subjects
+	wildcards map[string]bool
+}
+
+func (c *Checker) newEvaluation() *evaluation {
+	return &evaluation{
+		reader:   c.reader,
+		maxDepth: c.maxDepth,
+		reads: &readCache{
+			namespaces: make(map[string]*namespaceRules),
+			tuples:     make(map[objectRef]*tupleRead),
+			conditions: make(map[string]*condition.Expression),
+		},
+		now:     time.Now(),
+		results: make(map[checkKey]Result),
+	}
+}
+
+// withContext returns an evaluation for another request context that shares
+// the reads of e but none of its results
+func (e *evaluation) withContext(requestContext map[string]string) *evaluation {
+	return &evaluation{
+		reader:   e.reader,
+		maxDepth: e.maxDepth,
+		reads:    e.reads,
+		context:  requestContext,
+		now:      e.now,
+		results:  make(map[checkKey]Result),
+	}
+}
+
+// relationTuples returns the unexpired tuples of the object relation,
+// reading them at most once per evaluation even when requested concurrently
+func (e *evaluation) relationTuples(ctx context.Context, object objectRef) ([]database.RelationTuple, error) {
+	e.reads.mu.Lock()
+	read, ok := e.reads.tuples[object]
+	if !ok {
+		read = &tupleRead{done: make(chan struct{})}
+		e.reads.tuples[object] = read
+	}
+	e.reads.mu.Unlock()
+
+	if ok {
+		select {
+		case <-read.done:
+			return read.tuples, read.err
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+
+	tuples, err := e.reader.GetRelationTuples(ctx, object.Namespace, object.ObjectID, object.Relation)
+	read.tuples, read.err = liveTuples(tuples, e.now), err
+	close(read.done)
+
+	return read.tuples, read.err
+}
+
+// rule returns the parsed rewrite rule of namespace#relation
+func (e *evaluation) rule(ctx context.Context, namespace, relation string) (*rewrite.Rule, error) {
+	ns, err := e.namespace(ctx, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	rule, ok := ns.rules[relation]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s#%s", errUnknownRelation, namespace, relation)
+	}
+
+	return rule, nil
+}
+
+// namespace returns the parsed configuration of a namespace, reading it at
+// most once per evaluation
+func (e *evaluation) namespace(ctx context.Context, namespace string) (*namespaceRules, error) {
+	e.reads.mu.Lock()
+	ns, ok := e.reads.namespaces[namespace]
+	e.reads.mu.Unlock()
+
+	if !ok {
+		config, err := e.reader.GetNamespaceConfig(ctx, namespace)
+		if err != nil {
+			return nil, err
+		}
+
+		ns = &namespaceRules{
+			name:      config.Name,
+			rules:     make(map[string]*rewrite.Rule, len(config.Relations)),
+			wildcards: make(map[string]bool),
+		}
+		for _, rel := range config.Relations {
+			rule, err := rewrite.Parse(rel.RewriteRules)
+			if err != nil {
+				return nil, fmt.Errorf("relation %s#%s: %w", namespace, rel.Name, err)
+			}
+			ns.rules[rel.Name] = rule
+			ns.wildcards[rel.Name] = rel.AllowWildcard
+		}
+
+		e.reads.mu.Lock()
+		e.reads.namespaces[namespace] = ns
+		e.reads.mu.Unlock()
+	}
+
+	return ns, nil
+}
+
+// check evaluates the rewrite rule of the object relation for the user
+// The path holds the object relations currently being resolved above this one
+func (e *evaluation) check(ctx context.Context, object objectRef, userID string, path []objectRef) (Result, error) {
+	for i, visited := range path {
+		if visited == object {
+			return deniedResult, &resolutionError{cause: errCycleDetected, path: appendPath(path[i:], object)}
+		}
+	}
+
+	if len(path) >= e.maxDepth {
+		return deniedResult, &resolutionError{cause: errDepthExceeded, path: appendPath(path, object)}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return deniedResult, err
+	}
+
+	key := checkKey{object: object, userID: userID}
+	e.mu.Lock()
+	result, ok := e.results[key]
+	e.mu.Unlock()
+	if ok {
+		e.record(path, "%s: %s (memoized)", object, result)
+		return result, nil
+	}
+
+	rule, err := e.rule(ctx, object.Namespace, object.Relation)
+	if err != nil {
+		return deniedResult, err
+	}
+
+	e.record(path, "%s", object)
+	result, err = e.evalRule(ctx, rule, object, userID, appendPath(path, object))
+	if err != nil {
+		return deniedResult, err
+	}
+	e.record(path, "%s: %s", object, result)
+
+	e.mu.Lock()
+	e.results[key] = result
+	e.mu.Unlock()
+
+	return result, nil
+}
+
+// evalRule evaluates a single rewrite node for the object relation
+func (e *evaluation) evalRule(ctx context.Context, rule *rewrite.Rule, object objectRef, userID string, path []objectRef) (Result, error) {
+	switch {
+	case rule.This != nil:
+		e.record(path, "_this")
+		return e.checkDirect(ctx, object, userID, path)
+
+	case rule.ComputedUserset != nil:
+		e.record(path, "computed_userset %s", rule.ComputedUserset.Relation)
+		return e.check(ctx, object.withRelation(rule.ComputedUserset.Relation), userID, path)
+
+	case rule.TupleToUserset != nil:
+		e.record(path, "tuple_to_userset %s -> %s", rule.TupleToUserset.Tupleset.Relation, rule.TupleToUserset.ComputedUserset.Relation)
+		return e.checkTupleToUserset(ctx, rule.TupleToUserset, object, userID, path)
+
+	case rule.Union != nil:
+		e.record(path, "union")
+		result := deniedResult
+		for _, child := range rule.Union.Child {
+			childResult, err := e.evalRule(ctx, child, object, userID, path)
+			if err != nil {
+				return deniedResult, err
+			}
+			if result = result.or(childResult); result.Allowed {
+				return result, nil
+			}
+		}
+		return result, nil
+
+	case rule.Intersection != nil:
+		e.record(path, "intersection")
+		result := allowedResult
+		for _, child := range rule.Intersection.Child {
+			childResult, err := e.evalRule(ctx, child, object, userID, path)
+			if err != nil {
+				return deniedResult, err
+			}
+			if result = result.and(childResult); result.isDenied() {
+				return result, nil
+			}
+		}
+		return result, nil
+
+	case rule.Exclusion != nil:
+		e.record(path, "exclusion")
+		base, err := e.evalRule(ctx, rule.Exclusion.Base, object, userID, path)
+		if err != nil || base.isDenied() {
+			return deniedResult, err
+		}
+		excluded, err := e.evalRule(ctx, rule.Exclusion.Exclude, object, userID, path)
+		if err != nil {
+			return deniedResult, err
+		}
+		return base.and(excluded.not()), nil
+	}
+
+	return deniedResult, fmt.Errorf("empty rewrite rule for %s", object)
+}
+
+// checkDirect evaluates the tuples stored directly on the object relation
+func (e *evaluation) checkDirect(ctx context.Context, object objectRef, userID string, path []objectRef) (Result, error) {
+	tuples, err := e.relationTuples(ctx, object)
+	if err != nil {
+		return deniedResult, err
+	}
+
+	ns, err := e.namespace(ctx, object.Namespace)
+	if err != nil {
+		return deniedResult, err
+	}
+	allowWildcard := ns.wildcards[object.Relation]
+
+	// Direct user matches need no further reads, so look for them first
+	result := deniedResult
+	for _, tuple := range tuples {
+		if tuple.Userset != "" {
+			continue
+		}
+		if tuple.UserID == userID || (allowWildcard && wildcardMatches(tuple.UserID, userID)) {
+			holds := e.tupleCondition(tuple)
+			e.record(path, "tuple %s: %s", database.TupleKey(tuple), holds)
+			if result = result.or(holds); result.Allowed {
+				return result, nil
+			}
+		}
+	}
+
+	for _, tuple := range tuples {
+		if tuple.Userset == "" {
+			continue
+		}
+
+		subject, err := parseUserset(tuple.Userset)
+		if err != nil || subject.Relation == "" {
+			continue
+		}
+
+		holds := e.tupleCondition(tuple)
+		if holds.isDenied() {
+			continue
+		}
+		e.record(path, "via tuple %s", database.TupleKey(tuple))
+
+		member, err := e.check(ctx, subject, userID, path)
+		if err != nil {
+			if isUndefined(err) {
+				continue
+			}
+			return deniedResult, err
+		}
+		if result = result.or(holds.and(member)); result.Allowed {
+			return result, nil
+		}
+	}
+
+	return result, nil
+}
+
+// checkTupleToUserset follows the objects referenced by the tupleset and
+// evaluates the computed relation on each of them
+func (e *evaluation) checkTupleToUserset(ctx context.Context, ttu *rewrite.TupleToUserset, object objectRef, userID string, path []objectRef) (Result, error) {
+	tuples, err := e.relationTuples(ctx, object.withRelation(ttu.Tupleset.Relation))
+	if err != nil {
+		return deniedResult, err
+	}
+
+	result := deniedResult
+	for _, tuple := range tuples {
+		if tuple.Userset == "" {
+			continue
+		}
+
+		target, err := parseUserset(tuple.Userset)
+		if err != nil {
+			continue
+		}
+
+		holds := e.tupleCondition(tuple)
+		if holds.isDenied() {
+			continue
+		}
+		e.record(path, "via tuple %s", database.TupleKey(tuple))
+
+		// Relations missing on the referenced namespace simply contribute no users
+		member, err := e.check(ctx, target.withRelation(ttu.ComputedUserset.Relation), userID, path)
+		if err != nil {
+			if isUndefined(err) {
+				continue
+			}
+			return deniedResult, err
+		}
+		if result = result.or(holds.and(member)); result.Allowed {
+			return result, nil
+		}
+	}
+
+	return result, nil
+}
+
+// tupleCondition evaluates the condition attached to a tuple against the
+// request context
+// Tuples without a condition always apply; conditions that fail to parse or
+// evaluate never do
+func (e *evaluation) tupleCondition(tuple database.RelationTuple) Result {
+	if tuple.Conditions == "" {
+		return allowedResult
+	}
+
+	e.reads.mu.Lock()
+	expr, ok := e.reads.conditions[tuple.Conditions]
+	e.reads.mu.Unlock()
+
+	if !ok {
+		// Invalid conditions are cached as nil so they are parsed only once
+		expr, _ = condition.Parse(tuple.Conditions)
+		e.reads.mu.Lock()
+		e.reads.conditions[tuple.Conditions] = expr
+		e.reads.mu.Unlock()
+	}
+
+	if expr == nil {
+		return deniedResult
+	}
+
+	outcome, missing, err := expr.Evaluate(e.context, e.now)
+	if err != nil {
+		return deniedResult
+	}
+
+	switch outcome {
+	case condition.True:
+		return allowedResult
+	case condition.Unknown:
+		return conditional(missing)
+	}
+	return deniedResult
+}
+
+// isWildcard reports whether a user ID is a wildcard subject, either "*" for
+// every user or "type:*" for every user of a type
+func isWildcard(userID string) bool {
+	return userID == "*" || strings.HasSuffix(userID, ":*")
+}
+
+// wildcardMatches reports whether the wildcard subject covers the user ID
+func wildcardMatches(wildcard, userID string) bool {
+	if wildcard == "*" {
+		return true
+	}
+	userType, ok := strings.CutSuffix(wildcard, ":*")
+	return ok && strings.HasPrefix(userID, userType+":") && len(userID) > len(userType)+1
+}
+
+// wildcardSubjects returns the wildcard subjects that cover the user ID
+func wildcardSubjects(userID string) []string {
+	subjects := []string{"*"}
+	if userType, _, ok := strings.Cut(userID, ":"); ok && userType != "" && !isWildcard(userID) {
+		subjects = append(subjects, userType+":*")
+	}
+	return subjects
+}
+
+// liveTuples returns the tuples that have not expired by now
+func liveTuples(tuples []database.RelationTuple, now time.Time) []database.RelationTuple {
+	live := tuples[:0:0]
+	for _, tuple := range tuples {
+		if !tuple.Expired(now) {
+			live = append(live, tuple)
+		}
+	}
+	return live
+}
+
+// appendPath returns a new path extended by object, leaving path untouched
+func appendPath(path []objectRef, object objectRef) []objectRef {
+	return append(path[:len(path):len(path)], object)
+}
+
+// isUndefined reports whether err was caused by a subject that references a
+// namespace or relation that does not exist
+func isUndefined(err error) bool {
+	return errors.Is(err, errUnknownRelation) || errors.Is(err, database.ErrNotFound)
+}
+
+// objectRef identifies an object, optionally narrowed to one of its relations
+type objectRef struct {
+	Namespace string
+	ObjectID  string
+	Relation  string
+}
+
+// withRelation returns a copy of the reference pointing at another relation
+func (o objectRef) withRelation(relation string) objectRef {
+	o.Relation = relation
+	return o
+}
+
+// String formats the reference as namespace:object#relation
+func (o objectRef) String() string {
+	if o.Relation == "" {
+		return o.Namespace + ":" + o.ObjectID
+	}
+	return o.Namespace + ":" + o.ObjectID + "#" + o.Relation
+}
+
+// parseUserset parses a subject of the form namespace:object#relation
+// The relation is optional so that tuplesets may point at plain objects
+func parseUserset(userset string) (objectRef, error) {
+	var ref objectRef
+
+	object, relation, _ := strings.Cut(userset, "#")
+	namespace, objectID, ok := strings.Cut(object, ":")
+	if !ok || namespace == "" || objectID == "" {
+		return ref, fmt.Errorf("invalid userset %q: expected namespace:object#relation", userset)
+	}
+
+	ref.Namespace = namespace
+	ref.ObjectID = objectID
+	ref.Relation = relation
+	return ref, nil
+}
@@ -0,0 +1,659 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DangVTNhan/goacl/api"
+	"github.com/DangVTNhan/goacl/internal/database"
+	"github.com/DangVTNhan/goacl/internal/schema"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// memoryReader is an in-memory TupleReader seeded with the shipped namespaces
+type memoryReader struct {
+	namespaces map[string]*database.NamespaceConfig
+	tuples     []database.RelationTuple
+}
+
+func newMemoryReader() *memoryReader {
+	r := &memoryReader{namespaces: make(map[string]*database.NamespaceConfig)}
+	for _, ns := range initialNamespaces() {
+		config := namespaceFromProto(ns)
+		r.namespaces[ns.GetName()] = &config
+	}
+	return r
+}
+
+// initialNamespaces loads the namespaces shipped in the namespaces directory
+func initialNamespaces() []*api.NamespaceConfig {
+	configs, err := schema.LoadDir("../../namespaces")
+	if err != nil {
+		panic(err)
+	}
+	return configs
+}
+
+// allowWildcard lets the relation hold wildcard subjects, which no shipped
+// namespace allows
+func (r *memoryReader) allowWildcard(namespace, relation string) {
+	relations := r.namespaces[namespace].Relations
+	for i := range relations {
+		if relations[i].Name == relation {
+			relations[i].AllowWildcard = true
+		}
+	}
+}
+
+// add stores a tuple written as namespace:object#relation@subject, where the
+// subject is a user ID, a wildcard or a userset containing ':'
+func (r *memoryReader) add(namespace, objectID, relation, subject string) {
+	tuple := database.RelationTuple{Namespace: namespace, ObjectID: objectID, Relation: relation}
+	if _, err := parseUserset(subject); err == nil && !isWildcard(subject) {
+		tuple.Userset = subject
+	} else {
+		tuple.UserID = subject
+	}
+	r.tuples = append(r.tuples, tuple)
+}
+
+// addUser stores a tuple granting the relation to a typed user ID such as
+// user:alice, which add would take for a userset
+func (r *memoryReader) addUser(namespace, objectID, relation, userID string) {
+	r.tuples = append(r.tuples, database.RelationTuple{Namespace: namespace, ObjectID: objectID, Relation: relation, UserID: userID})
+}
+
+// addConditional stores a tuple like add that only applies when the condition holds
+func (r *memoryReader) addConditional(namespace, objectID, relation, subject, condition string) {
+	r.add(namespace, objectID, relation, subject)
+	r.tuples[len(r.tuples)-1].Conditions = condition
+}
+
+func (r *memoryReader) GetNamespaceConfig(_ context.Context, name string) (*database.NamespaceConfig, error) {
+	config, ok := r.namespaces[name]
+	if !ok {
+		return nil, fmt.Errorf("namespace %s %w", name, database.ErrNotFound)
+	}
+	return config, nil
+}
+
+func (r *memoryReader) GetRelationTuples(_ context.Context, namespace, objectID, relation string) ([]database.RelationTuple, error) {
+	var result []database.RelationTuple
+	for _, tuple := range r.tuples {
+		if tuple.Namespace == namespace && tuple.ObjectID == objectID && tuple.Relation == relation {
+			result = append(result, tuple)
+		}
+	}
+	return result, nil
+}
+
+func (r *memoryReader) ListNamespaceConfigs(_ context.Context) ([]database.NamespaceConfig, error) {
+	var result []database.NamespaceConfig
+	for _, config := range r.namespaces {
+		result = append(result, *config)
+	}
+	return result, nil
+}
+
+func (r *memoryReader) GetTuplesByUser(_ context.Context, userID string) ([]database.RelationTuple, error) {
+	var result []database.RelationTuple
+	for _, tuple := range r.tuples {
+		if tuple.Userset == "" && tuple.UserID == userID {
+			result = append(result, tuple)
+		}
+	}
+	return result, nil
+}
+
+func (r *memoryReader) GetTuplesByUsersetObject(_ context.Context, namespace, objectID string) ([]database.RelationTuple, error) {
+	var result []database.RelationTuple
+	for _, tuple := range r.tuples {
+		if subject, err := parseUserset(tuple.Userset); err == nil && subject.Namespace == namespace && subject.ObjectID == objectID {
+			result = append(result, tuple)
+		}
+	}
+	return result, nil
+}
+
+func TestCheckerCheck(t *testing.T) {
+	reader := newMemoryReader()
+	reader.add("documents", "doc1", "owner", "alice")
+	reader.add("documents", "doc1", "viewer", "groups:eng#member")
+	reader.add("groups", "eng", "member", "bob")
+	reader.add("groups", "eng", "parent", "groups:platform")
+	reader.add("groups", "platform", "member", "carol")
+	reader.add("folders", "root", "viewer", "dave")
+	reader.add("folders", "child", "parent", "folders:root")
+
+	tests := []struct {
+		name    string
+		object  objectRef
+		userID  string
+		allowed bool
+	}{
+		{"direct tuple", objectRef{"documents", "doc1", "owner"}, "alice", true},
+		{"computed userset chain", objectRef{"documents", "doc1", "viewer"}, "alice", true},
+		{"userset subject", objectRef{"documents", "doc1", "viewer"}, "bob", true},
+		{"nested group through parent", objectRef{"groups", "eng", "member"}, "carol", true},
+		{"userset subject through nested group", objectRef{"documents", "doc1", "viewer"}, "carol", true},
+		{"tuple to userset", objectRef{"folders", "child", "viewer"}, "dave", true},
+		{"computed userset does not widen", objectRef{"documents", "doc1", "owner"}, "bob", false},
+		{"unrelated user", objectRef{"documents", "doc1", "viewer"}, "eve", false},
+	}
+
+	checker := NewChecker(reader, 0)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := checker.Check(context.Background(), tt.object.Namespace, tt.object.ObjectID, tt.object.Relation, tt.userID, nil)
+			if err != nil {
+				t.Fatalf("Check returned error: %v", err)
+			}
+			if result.Allowed != tt.allowed {
+				t.Errorf("Expected allowed=%v, got %v", tt.allowed, result.Allowed)
+			}
+		})
+	}
+}
+
+func TestCheckerUnknownRelation(t *testing.T) {
+	checker := NewChecker(newMemoryReader(), 0)
+
+	if _, err := checker.Check(context.Background(), "documents", "doc1", "viwer", "alice", nil); err == nil {
+		t.Error("Expected error for undefined relation")
+	}
+
+	if _, err := checker.Check(context.Background(), "missing", "doc1", "viewer", "alice", nil); err == nil {
+		t.Error("Expected error for undefined namespace")
+	}
+}
+
+func TestCheckerExpand(t *testing.T) {
+	reader := newMemoryReader()
+	reader.add("documents", "doc1", "owner", "alice")
+	reader.add("documents", "doc1", "viewer", "groups:eng#member")
+	reader.add("groups", "eng", "member", "bob")
+
+	checker := NewChecker(reader, 0)
+
+	tree, err := checker.Expand(context.Background(), "documents", "doc1", "viewer", 0)
+	if err != nil {
+		t.Fatalf("Expand returned error: %v", err)
+	}
+
+	users := collectUsers(tree)
+	for _, user := range []string{"alice", "bob"} {
+		if !users[user] {
+			t.Errorf("Expected %s in expanded tree", user)
+		}
+	}
+
+	shallow, err := checker.Expand(context.Background(), "documents", "doc1", "viewer", 1)
+	if err != nil {
+		t.Fatalf("Expand returned error: %v", err)
+	}
+
+	if users := collectUsers(shallow); users["alice"] || users["bob"] {
+		t.Errorf("Expected depth 1 to stop before nested users, got %v", users)
+	}
+}
+
+// collectUsers returns the user IDs found in the leaves of a userset tree
+func collectUsers(userset *api.UserSet) map[string]bool {
+	users := make(map[string]bool)
+	var walk func(*api.UserSet)
+	walk = func(u *api.UserSet) {
+		switch node := u.GetUserset().(type) {
+		case *api.UserSet_UserId:
+			users[node.UserId] = true
+		case *api.UserSet_Union:
+			for _, child := range node.Union.GetChildren() {
+				walk(child)
+			}
+		}
+	}
+	walk(userset)
+	return users
+}
+
+func TestCheckerLookupResources(t *testing.T) {
+	reader := newMemoryReader()
+	reader.add("groups", "platform", "member", "carol")
+	reader.add("groups", "eng", "parent", "groups:platform")
+	reader.add("folders", "root", "viewer", "groups:eng#member")
+	reader.add("folders", "child", "parent", "folders:root")
+	reader.add("documents", "doc1", "owner", "carol")
+	reader.add("documents", "doc2", "viewer", "dave")
+
+	checker := NewChecker(reader, 0)
+
+	resources, err := checker.LookupResources(context.Background(), "carol")
+	if err != nil {
+		t.Fatalf("LookupResources returned error: %v", err)
+	}
+
+	got := make(map[string]bool)
+	for _, ref := range resources {
+		got[ref.String()] = true
+	}
+
+	expected := []string{
+		"groups:platform#member",
+		"groups:eng#member",
+		"folders:root#viewer",
+		"folders:child#viewer",
+		"documents:doc1#owner",
+		"documents:doc1#editor",
+		"documents:doc1#viewer",
+	}
+
+	for _, key := range expected {
+		if !got[key] {
+			t.Errorf("Expected %s in lookup results", key)
+		}
+	}
+
+	if len(resources) != len(expected) {
+		t.Errorf("Expected %d results, got %d: %v", len(expected), len(resources), resources)
+	}
+
+	// Every reverse result must agree with a forward check
+	for _, ref := range resources {
+		result, err := checker.Check(context.Background(), ref.Namespace, ref.ObjectID, ref.Relation, "carol", nil)
+		if err != nil || !result.Allowed {
+			t.Errorf("Forward check of %s disagrees with lookup: %v, %v", ref, result.Allowed, err)
+		}
+	}
+}
+
+// countingReader counts the tuple reads issued per object relation
+type countingReader struct {
+	*memoryReader
+
+	mu    sync.Mutex
+	reads map[string]int
+}
+
+func (r *countingReader) GetRelationTuples(ctx context.Context, namespace, objectID, relation string) ([]database.RelationTuple, error) {
+	r.mu.Lock()
+	r.reads[objectRef{namespace, objectID, relation}.String()]++
+	r.mu.Unlock()
+	return r.memoryReader.GetRelationTuples(ctx, namespace, objectID, relation)
+}
+
+func TestCheckerBatchCheck(t *testing.T) {
+	memory := newMemoryReader()
+	memory.add("groups", "eng", "member", "bob")
+	for i := 0; i < 20; i++ {
+		memory.add("documents", fmt.Sprintf("doc%d", i), "viewer", "groups:eng#member")
+	}
+	reader := &countingReader{memoryReader: memory, reads: make(map[string]int)}
+
+	var checks []CheckItem
+	var expected []bool
+	for i := 0; i < 20; i++ {
+		doc := fmt.Sprintf("doc%d", i)
+		checks = append(checks,
+			CheckItem{Namespace: "documents", ObjectID: doc, Relation: "viewer", UserID: "bob"},
+			CheckItem{Namespace: "documents", ObjectID: doc, Relation: "viewer", UserID: "eve"},
+			CheckItem{Namespace: "documents", ObjectID: doc, Relation: "viewer", UserID: "bob"},
+		)
+		expected = append(expected, true, false, true)
+	}
+
+	results, err := NewChecker(reader, 0).BatchCheck(context.Background(), checks)
+	if err != nil {
+		t.Fatalf("BatchCheck returned error: %v", err)
+	}
+
+	if len(results) != len(expected) {
+		t.Fatalf("Expected %d results, got %d", len(expected), len(results))
+	}
+	for i := range expected {
+		if results[i].Allowed != expected[i] {
+			t.Errorf("Check %d: expected %v, got %v", i, expected[i], results[i].Allowed)
+		}
+	}
+
+	// The shared group membership is read once for the whole batch
+	if reads := reader.reads["groups:eng#member"]; reads != 1 {
+		t.Errorf("Expected groups:eng#member to be read once, got %d", reads)
+	}
+
+	if _, err := NewChecker(reader, 0).BatchCheck(context.Background(), []CheckItem{
+		{Namespace: "documents", ObjectID: "doc1", Relation: "viewer", UserID: "bob"},
+		{Namespace: "documents", ObjectID: "doc1", Relation: "viwer", UserID: "bob"},
+	}); err == nil {
+		t.Error("Expected error for undefined relation in batch")
+	}
+}
+
+// addReportsNamespace registers a namespace exercising intersection and exclusion
+func (r *memoryReader) addReportsNamespace() {
+	r.namespaces["reports"] = &database.NamespaceConfig{
+		Name: "reports",
+		Relations: []database.RelationConfig{
+			{Name: "reader", RewriteRules: `{"_this": {}}`},
+			{Name: "banned", RewriteRules: `{"_this": {}}`},
+			{Name: "org", RewriteRules: `{"_this": {}}`},
+			{Name: "viewer", RewriteRules: `{"intersection": {"child": [{"computed_userset": {"relation": "reader"}}, {"tuple_to_userset": {"tupleset": {"relation": "org"}, "computed_userset": {"relation": "member"}}}]}}`},
+			{Name: "commenter", RewriteRules: `{"exclusion": {"base": {"computed_userset": {"relation": "reader"}}, "exclude": {"computed_userset": {"relation": "banned"}}}}`},
+		},
+	}
+}
+
+func TestCheckerSetOperations(t *testing.T) {
+	reader := newMemoryReader()
+	reader.addReportsNamespace()
+	reader.add("reports", "q1", "org", "organizations:acme")
+	reader.add("reports", "q1", "reader", "alice")
+	reader.add("reports", "q1", "reader", "bob")
+	reader.add("reports", "q1", "reader", "mallory")
+	reader.add("reports", "q1", "banned", "mallory")
+	reader.add("organizations", "acme", "member", "alice")
+	reader.add("organizations", "acme", "member", "carol")
+
+	tests := []struct {
+		relation string
+		userID   string
+		allowed  bool
+	}{
+		{"viewer", "alice", true},
+		{"viewer", "bob", false},
+		{"viewer", "carol", false},
+		{"commenter", "alice", true},
+		{"commenter", "mallory", false},
+		{"commenter", "carol", false},
+	}
+
+	checker := NewChecker(reader, 0)
+	for _, tt := range tests {
+		result, err := checker.Check(context.Background(), "reports", "q1", tt.relation, tt.userID, nil)
+		if err != nil {
+			t.Fatalf("Check returned error: %v", err)
+		}
+		if result.Allowed != tt.allowed {
+			t.Errorf("%s#%s: expected allowed=%v, got %v", tt.relation, tt.userID, tt.allowed, result.Allowed)
+		}
+	}
+
+	for user, expected := range map[string][]string{
+		"alice":   {"organizations:acme#member", "reports:q1#commenter", "reports:q1#reader", "reports:q1#viewer"},
+		"carol":   {"organizations:acme#member"},
+		"mallory": {"reports:q1#banned", "reports:q1#reader"},
+	} {
+		resources, err := checker.LookupResources(context.Background(), user)
+		if err != nil {
+			t.Fatalf("LookupResources returned error: %v", err)
+		}
+
+		var got []string
+		for _, ref := range resources {
+			got = append(got, ref.String())
+		}
+		if fmt.Sprint(got) != fmt.Sprint(expected) {
+			t.Errorf("LookupResources(%s): expected %v, got %v", user, expected, got)
+		}
+	}
+
+	tree, err := checker.Expand(context.Background(), "reports", "q1", "commenter", 0)
+	if err != nil {
+		t.Fatalf("Expand returned error: %v", err)
+	}
+	if tree.GetExclusion() == nil {
+		t.Errorf("Expected exclusion at the root of the expanded tree, got %v", tree)
+	}
+}
+
+func TestCheckerCycleDetection(t *testing.T) {
+	reader := newMemoryReader()
+	reader.add("groups", "a", "parent", "groups:b")
+	reader.add("groups", "b", "parent", "groups:a")
+	reader.add("groups", "b", "member", "bob")
+
+	checker := NewChecker(reader, 0)
+
+	result, err := checker.Check(context.Background(), "groups", "a", "member", "bob", nil)
+	if err != nil || !result.Allowed {
+		t.Errorf("Expected bob to be a member through the parent, got %v, %v", result.Allowed, err)
+	}
+
+	_, err = checker.Check(context.Background(), "groups", "a", "member", "eve", nil)
+	if !errors.Is(err, errCycleDetected) {
+		t.Fatalf("Expected cycle error, got %v", err)
+	}
+
+	expectedPath := "groups:a#member -> groups:b#member -> groups:a#member"
+	if !strings.Contains(err.Error(), expectedPath) {
+		t.Errorf("Expected error to name the cycle %q, got %q", expectedPath, err.Error())
+	}
+
+	st := status.Convert(toStatusError(err))
+	if st.Code() != codes.FailedPrecondition {
+		t.Errorf("Expected FAILED_PRECONDITION, got %s", st.Code())
+	}
+	if details := st.Details(); len(details) != 1 || details[0].(*errdetails.ErrorInfo).GetReason() != "CYCLE_DETECTED" {
+		t.Errorf("Expected CYCLE_DETECTED error info, got %v", details)
+	}
+
+	// Expand stops at the repeated node instead of failing
+	if _, err := checker.Expand(context.Background(), "groups", "a", "member", 0); err != nil {
+		t.Errorf("Expected expand to tolerate the cycle, got %v", err)
+	}
+}
+
+func TestCheckerMaxDepth(t *testing.T) {
+	reader := newMemoryReader()
+	reader.add("groups", "g0", "member", "bob")
+	for i := 1; i <= 5; i++ {
+		reader.add("groups", fmt.Sprintf("g%d", i), "parent", fmt.Sprintf("groups:g%d", i-1))
+	}
+
+	if result, err := NewChecker(reader, 0).Check(context.Background(), "groups", "g5", "member", "bob", nil); err != nil || !result.Allowed {
+		t.Errorf("Expected bob to be a member of g5, got %v, %v", result.Allowed, err)
+	}
+
+	_, err := NewChecker(reader, 3).Check(context.Background(), "groups", "g5", "member", "bob", nil)
+	if !errors.Is(err, errDepthExceeded) {
+		t.Errorf("Expected depth error, got %v", err)
+	}
+}
+
+func TestCheckerConditionalTuples(t *testing.T) {
+	reader := newMemoryReader()
+	reader.addReportsNamespace()
+	reader.addConditional("documents", "doc1", "viewer", "alice", "request.ip in 10.0.0.0/8")
+	reader.addConditional("documents", "doc1", "viewer", "groups:eng#member", "now < 2000-01-01")
+	reader.add("groups", "eng", "member", "bob")
+	reader.add("reports", "q1", "reader", "carol")
+	reader.addConditional("reports", "q1", "banned", "carol", "request.region == \"us\"")
+
+	tests := []struct {
+		name        string
+		object      objectRef
+		userID      string
+		context     map[string]string
+		allowed     bool
+		conditional bool
+		missing     []string
+	}{
+		{"condition holds", objectRef{"documents", "doc1", "viewer"}, "alice", map[string]string{"ip": "10.0.0.7"}, true, false, nil},
+		{"condition fails", objectRef{"documents", "doc1", "viewer"}, "alice", map[string]string{"ip": "8.8.8.8"}, false, false, nil},
+		{"missing context", objectRef{"documents", "doc1", "viewer"}, "alice", nil, false, true, []string{"ip"}},
+		{"expired userset tuple", objectRef{"documents", "doc1", "viewer"}, "bob", nil, false, false, nil},
+		{"conditional exclusion", objectRef{"reports", "q1", "commenter"}, "carol", nil, false, true, []string{"region"}},
+		{"exclusion condition fails", objectRef{"reports", "q1", "commenter"}, "carol", map[string]string{"region": "eu"}, true, false, nil},
+	}
+
+	checker := NewChecker(reader, 0)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := checker.Check(context.Background(), tt.object.Namespace, tt.object.ObjectID, tt.object.Relation, tt.userID, tt.context)
+			if err != nil {
+				t.Fatalf("Check returned error: %v", err)
+			}
+			if result.Allowed != tt.allowed || result.Conditional != tt.conditional {
+				t.Errorf("Expected allowed=%v conditional=%v, got %+v", tt.allowed, tt.conditional, result)
+			}
+			if fmt.Sprint(result.MissingContext) != fmt.Sprint(tt.missing) {
+				t.Errorf("Expected missing context %v, got %v", tt.missing, result.MissingContext)
+			}
+		})
+	}
+
+	// Contexts are evaluated separately within a batch
+	results, err := checker.BatchCheck(context.Background(), []CheckItem{
+		{Namespace: "documents", ObjectID: "doc1", Relation: "viewer", UserID: "alice", Context: map[string]string{"ip": "10.0.0.7"}},
+		{Namespace: "documents", ObjectID: "doc1", Relation: "viewer", UserID: "alice"},
+	})
+	if err != nil {
+		t.Fatalf("BatchCheck returned error: %v", err)
+	}
+	if !results[0].Allowed || results[1].Allowed || !results[1].Conditional {
+		t.Errorf("Expected allowed then conditional, got %+v", results)
+	}
+
+	// Lookups have no request context, so only unconditional grants are listed
+	resources, err := checker.LookupResources(context.Background(), "alice")
+	if err != nil {
+		t.Fatalf("LookupResources returned error: %v", err)
+	}
+	if len(resources) != 0 {
+		t.Errorf("Expected no resources for alice, got %v", resources)
+	}
+}
+
+func TestCheckerIgnoresExpiredTuples(t *testing.T) {
+	reader := newMemoryReader()
+	past := time.Now().Add(-time.Hour).UTC().Format(time.RFC3339)
+	future := time.Now().Add(time.Hour).UTC().Format(time.RFC3339)
+
+	reader.add("documents", "doc1", "viewer", "alice")
+	reader.tuples[len(reader.tuples)-1].ExpiresAt = past
+	reader.add("documents", "doc1", "viewer", "bob")
+	reader.tuples[len(reader.tuples)-1].ExpiresAt = future
+	reader.add("documents", "doc1", "viewer", "groups:eng#member")
+	reader.add("groups", "eng", "member", "carol")
+	reader.tuples[len(reader.tuples)-1].ExpiresAt = past
+
+	checker := NewChecker(reader, 0)
+	for userID, expected := range map[string]bool{"alice": false, "bob": true, "carol": false} {
+		result, err := checker.Check(context.Background(), "documents", "doc1", "viewer", userID, nil)
+		if err != nil {
+			t.Fatalf("Check returned error: %v", err)
+		}
+		if result.Allowed != expected {
+			t.Errorf("%s: expected allowed=%v, got %v", userID, expected, result.Allowed)
+		}
+	}
+
+	for _, userID := range []string{"alice", "carol"} {
+		resources, err := checker.LookupResources(context.Background(), userID)
+		if err != nil {
+			t.Fatalf("LookupResources returned error: %v", err)
+		}
+		if len(resources) != 0 {
+			t.Errorf("%s: expected no resources, got %v", userID, resources)
+		}
+	}
+
+	userset, err := checker.Expand(context.Background(), "documents", "doc1", "viewer", 0)
+	if err != nil {
+		t.Fatalf("Expand returned error: %v", err)
+	}
+	if tree := fmt.Sprint(userset); strings.Contains(tree, "alice") || !strings.Contains(tree, "bob") {
+		t.Errorf("Expected only unexpired users in expansion, got %s", tree)
+	}
+}
+
+func TestCheckerWildcardSubjects(t *testing.T) {
+	reader := newMemoryReader()
+	reader.allowWildcard("documents", "viewer")
+	reader.allowWildcard("folders", "viewer")
+	reader.add("documents", "public", "viewer", "user:*")
+	reader.add("documents", "public", "owner", "*")
+	reader.add("folders", "shared", "viewer", "*")
+	reader.addUser("documents", "mine", "viewer", "user:alice")
+
+	tests := []struct {
+		object  objectRef
+		userID  string
+		allowed bool
+	}{
+		{objectRef{"documents", "public", "viewer"}, "user:alice", true},
+		{objectRef{"documents", "public", "viewer"}, "service:ci", false},
+		{objectRef{"documents", "public", "viewer"}, "alice", false},
+		{objectRef{"folders", "shared", "viewer"}, "service:ci", true},
+		// owner does not allow wildcards, so the stored wildcard is ignored
+		{objectRef{"documents", "public", "owner"}, "user:alice", false},
+	}
+
+	checker := NewChecker(reader, 0)
+	for _, tt := range tests {
+		result, err := checker.Check(context.Background(), tt.object.Namespace, tt.object.ObjectID, tt.object.Relation, tt.userID, nil)
+		if err != nil {
+			t.Fatalf("Check returned error: %v", err)
+		}
+		if result.Allowed != tt.allowed {
+			t.Errorf("%s@%s: expected allowed=%v, got %v", tt.object, tt.userID, tt.allowed, result.Allowed)
+		}
+	}
+
+	resources, err := checker.LookupResources(context.Background(), "user:alice")
+	if err != nil {
+		t.Fatalf("LookupResources returned error: %v", err)
+	}
+	got := make(map[string]bool)
+	for _, ref := range resources {
+		got[ref.String()] = ref.Wildcard
+	}
+	expected := map[string]bool{
+		"documents:mine#viewer":   false,
+		"documents:public#viewer": true,
+		"folders:shared#viewer":   true,
+	}
+	if fmt.Sprint(got) != fmt.Sprint(expected) {
+		t.Errorf("Expected resources %v, got %v", expected, got)
+	}
+
+	userset, err := checker.Expand(context.Background(), "documents", "public", "viewer", 0)
+	if err != nil {
+		t.Fatalf("Expand returned error: %v", err)
+	}
+	if !strings.Contains(fmt.Sprint(userset), "user:*") {
+		t.Errorf("Expected the wildcard in the expansion, got %v", userset)
+	}
+}
+
+func TestCheckerCheckTraced(t *testing.T) {
+	reader := newMemoryReader()
+	reader.add("documents", "doc1", "viewer", "groups:eng#member")
+	reader.add("groups", "eng", "member", "bob")
+
+	result, trace, err := NewChecker(reader, 0).CheckTraced(context.Background(), "documents", "doc1", "viewer", "bob", nil)
+	if err != nil {
+		t.Fatalf("CheckTraced returned error: %v", err)
+	}
+	if !result.Allowed {
+		t.Fatal("Expected bob to be allowed")
+	}
+
+	joined := strings.Join(trace, "\n")
+	for _, step := range []string{
+		"documents:doc1#viewer",
+		"union",
+		"via tuple documents:doc1#viewer@groups:eng#member",
+		"tuple groups:eng#member@bob: allowed",
+		"documents:doc1#viewer: allowed",
+	} {
+		if !strings.Contains(joined, step) {
+			t.Errorf("Expected trace to contain %q, got:\n%s", step, joined)
+		}
+	}
+}
@@ -0,0 +1,141 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/DangVTNhan/goacl/api"
+	"github.com/DangVTNhan/goacl/internal/database"
+	"github.com/DangVTNhan/goacl/internal/rewrite"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// compatibility compares a namespace configuration with the stored one,
+// returning the stored configuration, the changes that can take access away
+// and the changes that only may break clients
+// Nothing is compared when the namespace does not exist yet
+func (s *ConfigurationService) compatibility(ctx context.Context, field string, config *api.NamespaceConfig) (*database.NamespaceConfig, []*api.ValidationError, []*api.ValidationWarning, error) {
+	if config.GetName() == "" {
+		return nil, nil, nil, nil
+	}
+
+	stored, err := s.store.GetNamespaceConfig(ctx, config.GetName())
+	if errors.Is(err, database.ErrNotFound) {
+		return nil, nil, nil, nil
+	}
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	tuples, err := s.store.CountNamespaceTuples(ctx, config.GetName())
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	breaking, warnings := breakingChanges(field, *stored, config, tuples)
+	return stored, breaking, warnings, nil
+}
+
+// breakingChanges lists how an update of a namespace configuration can
+// break the stored tuples and the checks made against them, naming fields
+// relative to field
+// Removing a relation that has tuples, no longer granting some of the users
+// the stored rewrite rules grant, and disallowing wildcards on a relation
+// with tuples are breaking; removing an unused relation is only a warning
+// Relations are compared one at a time, so a relation that changes only
+// because a relation it refers to changed is not reported itself
+func breakingChanges(field string, stored database.NamespaceConfig, updated *api.NamespaceConfig, tuples map[string]int) ([]*api.ValidationError, []*api.ValidationWarning) {
+	var (
+		breaking []*api.ValidationError
+		warnings []*api.ValidationWarning
+	)
+
+	kept := make(map[string]int, len(updated.GetRelations()))
+	for i, rel := range updated.GetRelations() {
+		kept[rel.GetName()] = i
+	}
+
+	for _, rel := range stored.Relations {
+		i, ok := kept[rel.Name]
+		if !ok {
+			if count := tuples[rel.Name]; count > 0 {
+				breaking = append(breaking, &api.ValidationError{
+					Field:   field + ".relations",
+					Message: fmt.Sprintf("relation %s is removed but still has %d tuples", rel.Name, count),
+					Code:    "RELATION_IN_USE",
+				})
+			} else {
+				warnings = append(warnings, &api.ValidationWarning{
+					Field:   field + ".relations",
+					Message: fmt.Sprintf("relation %s is removed; checks of it will fail", rel.Name),
+					Code:    "RELATION_REMOVED",
+				})
+			}
+			continue
+		}
+		changed := updated.GetRelations()[i]
+
+		// Rules that do not parse are reported by checkNamespace
+		before, beforeErr := rewrite.Parse(rel.RewriteRules)
+		after, afterErr := rewrite.Parse(changed.GetRewriteRules())
+		if beforeErr == nil && afterErr == nil && !rewrite.Subsumes(after, before) {
+			breaking = append(breaking, &api.ValidationError{
+				Field:   fmt.Sprintf("%s.relations[%d].rewrite_rules", field, i),
+				Message: fmt.Sprintf("relation %s may no longer grant users its stored rewrite rules grant", rel.Name),
+				Code:    "ACCESS_NARROWED",
+			})
+		}
+
+		if rel.AllowWildcard && !changed.GetAllowWildcard() && tuples[rel.Name] > 0 {
+			breaking = append(breaking, &api.ValidationError{
+				Field:   fmt.Sprintf("%s.relations[%d].allow_wildcard", field, i),
+				Message: fmt.Sprintf("relation %s no longer allows wildcards, so any wildcard tuples it has stop granting access", rel.Name),
+				Code:    "WILDCARD_DISALLOWED",
+			})
+		}
+	}
+
+	return breaking, warnings
+}
+
+// breakingChangeError reports an update blocked by breaking changes
+type breakingChangeError struct {
+	name     string
+	breaking []*api.ValidationError
+}
+
+func (e *breakingChangeError) Error() string {
+	return fmt.Sprintf("update of namespace %s has %d breaking changes; set allow_breaking_changes to apply it", e.name, len(e.breaking))
+}
+
+// status returns the FailedPrecondition status listing the breaking changes
+func (e *breakingChangeError) status() *status.Status {
+	violations := make([]*errdetails.PreconditionFailure_Violation, len(e.breaking))
+	for i, change := range e.breaking {
+		violations[i] = &errdetails.PreconditionFailure_Violation{
+			Type:        change.GetCode(),
+			Subject:     change.GetField(),
+			Description: change.GetMessage(),
+		}
+	}
+
+	st := status.New(codes.FailedPrecondition, e.Error())
+	if detailed, err := st.WithDetails(&errdetails.PreconditionFailure{Violations: violations}); err == nil {
+		st = detailed
+	}
+	return st
+}
+
+// breakingCheck returns the check a namespace write runs in its transaction
+// to refuse an update to config that has breaking changes
+func breakingCheck(field string, config *api.NamespaceConfig) func(database.NamespaceConfig, map[string]int) error {
+	return func(stored database.NamespaceConfig, tuples map[string]int) error {
+		if breaking, _ := breakingChanges(field, stored, config, tuples); len(breaking) > 0 {
+			return &breakingChangeError{name: stored.Name, breaking: breaking}
+		}
+		return nil
+	}
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DangVTNhan/goacl/api"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// documentsSchema is the initial documents namespace without its parent
+// relation and with editors no longer including owners
+const documentsSchema = `namespace documents {
+  define owner: [user]
+  define editor: [user]
+  define viewer: [user, user:*] or editor
+}`
+
+func TestWriteNamespaceBreakingChanges(t *testing.T) {
+	store := &namespaceStore{memoryReader: newMemoryReader(), readTs: 10}
+	store.namespaces["documents"].Version = 1
+	store.add("documents", "readme", "parent", "folders:docs#viewer")
+	service := NewConfigurationService(store)
+
+	req := &api.WriteNamespaceRequest{Schema: documentsSchema, AllowUpdate: true, ExpectedVersion: 1}
+	_, err := service.WriteNamespace(context.Background(), req)
+
+	st := status.Convert(err)
+	if st.Code() != codes.FailedPrecondition {
+		t.Fatalf("Expected FailedPrecondition, got %v", err)
+	}
+	var violations []string
+	for _, detail := range st.Details() {
+		if failure, ok := detail.(*errdetails.PreconditionFailure); ok {
+			for _, violation := range failure.GetViolations() {
+				violations = append(violations, violation.GetType()+" "+violation.GetSubject())
+			}
+		}
+	}
+	expected := []string{"ACCESS_NARROWED schema.relations[1].rewrite_rules", "RELATION_IN_USE schema.relations"}
+	if fmt.Sprint(violations) != fmt.Sprint(expected) {
+		t.Errorf("Expected violations %v, got %v", expected, violations)
+	}
+	if len(store.writes) != 0 {
+		t.Fatalf("Expected the breaking update not to be written")
+	}
+
+	req.AllowBreakingChanges = true
+	if _, err := service.WriteNamespace(context.Background(), req); err != nil {
+		t.Fatalf("Expected the override to apply the update, got %v", err)
+	}
+
+	// Adding relations and granting more users is never breaking
+	_, err = service.WriteNamespace(context.Background(), &api.WriteNamespaceRequest{
+		Schema: `namespace documents {
+  define owner: [user]
+  define editor: [user] or owner
+  define viewer: [user, user:*] or editor or viewer from parent
+  define parent: [folders]
+  define commenter: [user] or viewer
+}`,
+		AllowUpdate:     true,
+		ExpectedVersion: 1,
+	})
+	if err != nil {
+		t.Errorf("Expected a compatible update to be written, got %v", err)
+	}
+}
+
+func TestValidateNamespaceBreakingChanges(t *testing.T) {
+	store := &namespaceStore{memoryReader: newMemoryReader()}
+	store.add("documents", "readme", "parent", "folders:docs#viewer")
+	service := NewConfigurationService(store)
+
+	resp, err := service.ValidateNamespace(context.Background(), &api.ValidateNamespaceRequest{Schema: documentsSchema})
+	if err != nil {
+		t.Fatalf("ValidateNamespace returned error: %v", err)
+	}
+	if resp.GetValid() || len(resp.GetErrors()) != 2 {
+		t.Fatalf("Expected two breaking changes, got %v", resp)
+	}
+	if got := resp.GetErrors()[0]; got.GetCode() != "ACCESS_NARROWED" || got.GetLine() != 3 {
+		t.Errorf("Expected the narrowed editor relation on line 3, got %v", got)
+	}
+
+	resp, err = service.ValidateNamespace(context.Background(), &api.ValidateNamespaceRequest{Schema: documentsSchema, AllowBreakingChanges: true})
+	if err != nil {
+		t.Fatalf("ValidateNamespace returned error: %v", err)
+	}
+	if !resp.GetValid() || len(resp.GetWarnings()) != 2 {
+		t.Errorf("Expected the breaking changes as warnings, got %v", resp)
+	}
+}
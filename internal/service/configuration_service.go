@@ -0,0 +1,232 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/DangVTNhan/goacl/api"
+	"github.com/DangVTNhan/goacl/internal/database"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// NamespaceStore reads and changes the stored namespace configurations
+type NamespaceStore interface {
+	NamespaceReader
+	ReadNamespaceConfigs(ctx context.Context, after string, limit int, readTs uint64) (*database.NamespacePage, error)
+	WriteNamespaceConfig(ctx context.Context, write database.NamespaceWrite) (*database.NamespaceConfig, uint64, error)
+	DeleteNamespaceConfig(ctx context.Context, name string, force bool, expectedVersion int64) (*database.NamespaceDeletion, error)
+	ReadNamespaceRevisions(ctx context.Context, name string, before int64, limit int) (*database.RevisionPage, error)
+	ReadNamespaceRevision(ctx context.Context, name string, version int64) (*database.NamespaceRevision, error)
+	CountNamespaceTuples(ctx context.Context, name string) (map[string]int, error)
+}
+
+// ConfigurationService manages the namespace configurations
+type ConfigurationService struct {
+	store NamespaceStore
+}
+
+// NewConfigurationService creates a new configuration service
+func NewConfigurationService(store NamespaceStore) *ConfigurationService {
+	return &ConfigurationService{store: store}
+}
+
+// WriteNamespace creates a namespace configuration, or replaces the stored
+// one when allow_update is set and expected_version matches its version
+// The configuration may be given as schema text instead
+// Updates with breaking changes are refused unless allow_breaking_changes
+// is set
+func (s *ConfigurationService) WriteNamespace(ctx context.Context, req *api.WriteNamespaceRequest) (*api.WriteNamespaceResponse, error) {
+	if err := validateConsistencyToken(req.GetConsistencyToken()); err != nil {
+		return nil, err
+	}
+	if req.GetExpectedVersion() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "expected_version must not be negative")
+	}
+
+	source, problem := newNamespaceSource(req.GetConfig(), req.GetSchema())
+	if problem != nil {
+		return nil, badRequest(problemViolations([]*api.ValidationError{problem}))
+	}
+	errs, _ := source.check()
+	if err := badRequest(problemViolations(errs)); err != nil {
+		return nil, err
+	}
+
+	write := database.NamespaceWrite{
+		Config:          namespaceFromProto(source.config),
+		AllowUpdate:     req.GetAllowUpdate(),
+		ExpectedVersion: req.GetExpectedVersion(),
+		Author:          req.GetAuthor(),
+	}
+	if !req.GetAllowBreakingChanges() {
+		write.Check = breakingCheck(source.field, source.config)
+	}
+
+	written, commitTs, err := s.store.WriteNamespaceConfig(ctx, write)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &api.WriteNamespaceResponse{
+		ConsistencyToken: database.EncodeConsistencyToken(commitTs),
+		WrittenAt:        timestamppb.Now(),
+		Config:           namespaceToProto(*written),
+	}, nil
+}
+
+// ReadNamespace returns a namespace configuration, read at the consistency
+// token when one is given
+func (s *ConfigurationService) ReadNamespace(ctx context.Context, req *api.ReadNamespaceRequest) (*api.ReadNamespaceResponse, error) {
+	if req.GetNamespace() == "" {
+		return nil, status.Error(codes.InvalidArgument, "namespace is required")
+	}
+
+	var reader NamespaceReader = s.store
+	if req.GetConsistencyToken() != "" {
+		readTs, err := database.DecodeConsistencyToken(req.GetConsistencyToken())
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+		if snapshots, ok := s.store.(snapshotter); ok {
+			reader = snapshots.Snapshot(readTs)
+		}
+	}
+
+	config, err := reader.GetNamespaceConfig(ctx, req.GetNamespace())
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &api.ReadNamespaceResponse{
+		Config:           namespaceToProto(*config),
+		ConsistencyToken: req.GetConsistencyToken(),
+	}, nil
+}
+
+// ListNamespaces returns the namespace configurations ordered by name, one
+// page at a time
+// Every page after the first is read from the snapshot of the first
+func (s *ConfigurationService) ListNamespaces(ctx context.Context, req *api.ListNamespacesRequest) (*api.ListNamespacesResponse, error) {
+	if req.GetPageSize() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "page_size must not be negative")
+	}
+
+	token, err := decodePageToken(req.GetPageToken())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	readTs := token.ReadTs
+	if readTs == 0 && req.GetConsistencyToken() != "" {
+		readTs, err = database.DecodeConsistencyToken(req.GetConsistencyToken())
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	page, err := s.store.ReadNamespaceConfigs(ctx, token.After, pageSize(req.GetPageSize()), readTs)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	resp := &api.ListNamespacesResponse{
+		Configs:          make([]*api.NamespaceConfig, len(page.Configs)),
+		ConsistencyToken: database.EncodeConsistencyToken(page.ReadTs),
+	}
+	for i, config := range page.Configs {
+		resp.Configs[i] = namespaceToProto(config)
+	}
+	if page.After != "" {
+		resp.NextPageToken = encodePageToken(pageToken{After: page.After, ReadTs: page.ReadTs})
+	}
+
+	return resp, nil
+}
+
+// DeleteNamespace deletes a namespace configuration
+// A namespace that still has tuples is only deleted when force is set, and
+// its tuples are then deleted with it
+func (s *ConfigurationService) DeleteNamespace(ctx context.Context, req *api.DeleteNamespaceRequest) (*api.DeleteNamespaceResponse, error) {
+	if req.GetNamespace() == "" {
+		return nil, status.Error(codes.InvalidArgument, "namespace is required")
+	}
+	if err := validateConsistencyToken(req.GetConsistencyToken()); err != nil {
+		return nil, err
+	}
+	if req.GetExpectedVersion() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "expected_version must not be negative")
+	}
+
+	deletion, err := s.store.DeleteNamespaceConfig(ctx, req.GetNamespace(), req.GetForce(), req.GetExpectedVersion())
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &api.DeleteNamespaceResponse{
+		ConsistencyToken: database.EncodeConsistencyToken(deletion.CommitTs),
+		DeletedAt:        timestamppb.Now(),
+		TuplesDeleted:    int64(deletion.DeletedTuples),
+	}, nil
+}
+
+// ValidateNamespace statically analyzes a namespace configuration, given
+// directly or as schema text, without writing it
+// The configuration is named by the request when it does not name itself,
+// and compared with the stored configuration of that name as WriteNamespace
+// would compare an update
+func (s *ConfigurationService) ValidateNamespace(ctx context.Context, req *api.ValidateNamespaceRequest) (*api.ValidateNamespaceResponse, error) {
+	source, problem := newNamespaceSource(req.GetConfig(), req.GetSchema())
+	if problem != nil {
+		return &api.ValidateNamespaceResponse{Errors: []*api.ValidationError{problem}}, nil
+	}
+
+	if source.config != nil && source.config.GetName() == "" && req.GetNamespace() != "" {
+		source.config = proto.Clone(source.config).(*api.NamespaceConfig)
+		source.config.Name = req.GetNamespace()
+	}
+	config := source.config
+
+	errs, warnings := source.check()
+
+	// Updates of a stored configuration are also checked for breaking changes
+	_, breaking, removed, err := s.compatibility(ctx, source.field, config)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+	source.locate(breaking, removed)
+	if req.GetAllowBreakingChanges() {
+		for _, change := range breaking {
+			warnings = append(warnings, &api.ValidationWarning{
+				Field:   change.GetField(),
+				Message: change.GetMessage(),
+				Code:    change.GetCode(),
+				Line:    change.GetLine(),
+				Column:  change.GetColumn(),
+			})
+		}
+	} else {
+		errs = append(errs, breaking...)
+	}
+	warnings = append(warnings, removed...)
+
+	if config != nil && req.GetNamespace() != "" && config.GetName() != req.GetNamespace() {
+		mismatch := &api.ValidationError{
+			Field:   source.field + ".name",
+			Message: fmt.Sprintf("configuration is named %s but is validated as %s", config.GetName(), req.GetNamespace()),
+			Code:    "NAME_MISMATCH",
+		}
+		if source.parsed != nil {
+			mismatch.Line, mismatch.Column = int32(source.parsed.Position.Line), int32(source.parsed.Position.Column)
+		}
+		errs = append(errs, mismatch)
+	}
+
+	return &api.ValidateNamespaceResponse{
+		Valid:    len(errs) == 0,
+		Errors:   errs,
+		Warnings: warnings,
+	}, nil
+}
@@ -0,0 +1,288 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+
+	"github.com/DangVTNhan/goacl/api"
+	"github.com/DangVTNhan/goacl/internal/database"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// namespaceStore is an in-memory NamespaceStore over the initial namespaces
+type namespaceStore struct {
+	*memoryReader
+	readTs uint64
+	writes []database.NamespaceWrite
+	err    error
+
+	// deletions names the deleted namespaces in order
+	deletions []string
+
+	// revisions holds the revisions of every namespace, newest first
+	revisions []database.NamespaceRevision
+}
+
+func (s *namespaceStore) ReadNamespaceConfigs(_ context.Context, after string, limit int, readTs uint64) (*database.NamespacePage, error) {
+	if readTs == 0 {
+		readTs = s.readTs
+	}
+
+	var names []string
+	for name := range s.namespaces {
+		if name > after {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	page := &database.NamespacePage{ReadTs: readTs}
+	if len(names) > limit {
+		names = names[:limit]
+		page.After = names[limit-1]
+	}
+	for _, name := range names {
+		page.Configs = append(page.Configs, *s.namespaces[name])
+	}
+	return page, nil
+}
+
+func (s *namespaceStore) WriteNamespaceConfig(_ context.Context, write database.NamespaceWrite) (*database.NamespaceConfig, uint64, error) {
+	if s.err != nil {
+		return nil, 0, s.err
+	}
+	if stored, ok := s.namespaces[write.Config.Name]; ok && write.Check != nil {
+		tuples, _ := s.CountNamespaceTuples(context.Background(), stored.Name)
+		if err := write.Check(*stored, tuples); err != nil {
+			return nil, 0, err
+		}
+	}
+	s.writes = append(s.writes, write)
+	written := write.Config
+	written.Version = write.ExpectedVersion + 1
+	return &written, s.readTs + 1, nil
+}
+
+func (s *namespaceStore) DeleteNamespaceConfig(_ context.Context, name string, _ bool, _ int64) (*database.NamespaceDeletion, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	s.deletions = append(s.deletions, name)
+	return &database.NamespaceDeletion{CommitTs: s.readTs + 1}, nil
+}
+
+func (s *namespaceStore) ReadNamespaceRevisions(_ context.Context, name string, before int64, limit int) (*database.RevisionPage, error) {
+	page := &database.RevisionPage{}
+	for _, revision := range s.revisions {
+		if revision.Namespace != name || (before != 0 && revision.Version >= before) {
+			continue
+		}
+		if len(page.Revisions) == limit {
+			page.Before = page.Revisions[limit-1].Version
+			break
+		}
+		page.Revisions = append(page.Revisions, revision)
+	}
+	return page, nil
+}
+
+func (s *namespaceStore) ReadNamespaceRevision(_ context.Context, name string, version int64) (*database.NamespaceRevision, error) {
+	for _, revision := range s.revisions {
+		if revision.Namespace == name && revision.Version == version {
+			return &revision, nil
+		}
+	}
+	return nil, fmt.Errorf("revision %d of namespace %s %w", version, name, database.ErrNotFound)
+}
+
+func (s *namespaceStore) CountNamespaceTuples(_ context.Context, name string) (map[string]int, error) {
+	counts := make(map[string]int)
+	for _, tuple := range s.tuples {
+		if tuple.Namespace == name {
+			counts[tuple.Relation]++
+		}
+	}
+	return counts, nil
+}
+
+func TestWriteNamespaceValidation(t *testing.T) {
+	store := &namespaceStore{memoryReader: newMemoryReader(), readTs: 10}
+	service := NewConfigurationService(store)
+
+	_, err := service.WriteNamespace(context.Background(), &api.WriteNamespaceRequest{
+		Config: &api.NamespaceConfig{
+			Name: "teams:eng",
+			Relations: []*api.RelationConfig{
+				{Name: "member"},
+				{Name: "member"},
+				{Name: "lead", RewriteRules: `{"union": `},
+				{Name: ""},
+			},
+		},
+	})
+
+	st := status.Convert(err)
+	if st.Code() != codes.InvalidArgument {
+		t.Fatalf("Expected InvalidArgument, got %v", err)
+	}
+	var fields []string
+	for _, detail := range st.Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			for _, violation := range badRequest.GetFieldViolations() {
+				fields = append(fields, violation.GetField())
+			}
+		}
+	}
+	expected := []string{"config.name", "config.relations[1].name", "config.relations[3].name", "config.relations[2].rewrite_rules"}
+	if fmt.Sprint(fields) != fmt.Sprint(expected) {
+		t.Errorf("Expected violations for %v, got %v", expected, fields)
+	}
+	if len(store.writes) != 0 {
+		t.Errorf("Expected an invalid configuration not to be written")
+	}
+
+	resp, err := service.WriteNamespace(context.Background(), &api.WriteNamespaceRequest{
+		Config:          &api.NamespaceConfig{Name: "teams", Relations: []*api.RelationConfig{{Name: "member"}}},
+		AllowUpdate:     true,
+		ExpectedVersion: 3,
+	})
+	if err != nil {
+		t.Fatalf("WriteNamespace returned error: %v", err)
+	}
+	if resp.GetConfig().GetVersion() != 4 || resp.GetConsistencyToken() != database.EncodeConsistencyToken(11) {
+		t.Errorf("Expected version 4 committed at 11, got %v", resp)
+	}
+	if len(store.writes) != 1 || !store.writes[0].AllowUpdate || store.writes[0].ExpectedVersion != 3 {
+		t.Errorf("Expected the update to expect version 3, got %+v", store.writes)
+	}
+}
+
+func TestListNamespacesPagination(t *testing.T) {
+	store := &namespaceStore{memoryReader: newMemoryReader(), readTs: 42}
+	service := NewConfigurationService(store)
+
+	var names []string
+	req := &api.ListNamespacesRequest{PageSize: 3}
+	for {
+		resp, err := service.ListNamespaces(context.Background(), req)
+		if err != nil {
+			t.Fatalf("ListNamespaces returned error: %v", err)
+		}
+		if resp.GetConsistencyToken() != database.EncodeConsistencyToken(42) {
+			t.Errorf("Expected every page to be read at 42, got token %s", resp.GetConsistencyToken())
+		}
+		for _, config := range resp.GetConfigs() {
+			names = append(names, config.GetName())
+		}
+		if resp.GetNextPageToken() == "" {
+			break
+		}
+		store.readTs++
+		req.PageToken = resp.GetNextPageToken()
+	}
+
+	if fmt.Sprint(names) != "[documents folders groups organizations]" {
+		t.Errorf("Expected every namespace in name order, got %v", names)
+	}
+}
+
+func TestNamespaceErrors(t *testing.T) {
+	tests := []struct {
+		err  error
+		code codes.Code
+	}{
+		{fmt.Errorf("namespace teams %w", database.ErrAlreadyExists), codes.AlreadyExists},
+		{fmt.Errorf("%w: namespace teams is at version 3, expected 2", database.ErrVersionMismatch), codes.Aborted},
+		{fmt.Errorf("%w: namespace teams still has relation tuples", database.ErrNamespaceInUse), codes.FailedPrecondition},
+	}
+
+	for _, tt := range tests {
+		store := &namespaceStore{memoryReader: newMemoryReader(), err: tt.err}
+		_, err := NewConfigurationService(store).DeleteNamespace(context.Background(), &api.DeleteNamespaceRequest{Namespace: "teams"})
+		if status.Code(err) != tt.code {
+			t.Errorf("Expected %v for %v, got %v", tt.code, tt.err, err)
+		}
+	}
+}
+
+func TestValidateNamespace(t *testing.T) {
+	service := NewConfigurationService(&namespaceStore{memoryReader: newMemoryReader()})
+
+	resp, err := service.ValidateNamespace(context.Background(), &api.ValidateNamespaceRequest{
+		Namespace: "teams",
+		Config: &api.NamespaceConfig{
+			Relations: []*api.RelationConfig{
+				{Name: "member"},
+				{Name: "lead", RewriteRules: `{"union": {"child": [{"_this": {}}, {"computed_userset": {"relation": "mamber"}}]}}`},
+				{Name: "viewer", RewriteRules: `{"union": {"child": [{"computed_userset": {"relation": "member"}}, {"computed_userset": {"relation": "member"}}]}}`},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ValidateNamespace returned error: %v", err)
+	}
+
+	if resp.GetValid() || len(resp.GetErrors()) != 1 {
+		t.Fatalf("Expected a single error, got %v", resp)
+	}
+	if got := resp.GetErrors()[0]; got.GetField() != "config.relations[1].rewrite_rules" || got.GetPath() != "$.union.child[1].computed_userset.relation" || got.GetCode() != "UNDEFINED_RELATION" {
+		t.Errorf("Unexpected error %v", got)
+	}
+
+	if len(resp.GetWarnings()) != 1 {
+		t.Fatalf("Expected a single warning, got %v", resp.GetWarnings())
+	}
+	if got := resp.GetWarnings()[0]; got.GetField() != "config.relations[2].rewrite_rules" || got.GetPath() != "$.union.child[1]" || got.GetCode() != "REDUNDANT_BRANCH" {
+		t.Errorf("Unexpected warning %v", got)
+	}
+}
+
+func TestNamespaceSchema(t *testing.T) {
+	store := &namespaceStore{memoryReader: newMemoryReader(), readTs: 10}
+	service := NewConfigurationService(store)
+
+	_, err := service.WriteNamespace(context.Background(), &api.WriteNamespaceRequest{
+		Schema: "namespace teams {\n  define member: [user, user:*]\n  define lead: [user] or member\n}",
+	})
+	if err != nil {
+		t.Fatalf("WriteNamespace returned error: %v", err)
+	}
+	written := store.writes[0].Config
+	if written.Name != "teams" || len(written.Relations) != 2 || !written.Relations[0].AllowWildcard || written.Relations[1].RewriteRules != `{"union":{"child":[{"_this":{}},{"computed_userset":{"relation":"member"}}]}}` {
+		t.Errorf("Unexpected compiled configuration %+v", written)
+	}
+
+	_, err = service.WriteNamespace(context.Background(), &api.WriteNamespaceRequest{
+		Schema: "namespace teams {\n  define member [user]\n}",
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for a syntax error, got %v", err)
+	}
+
+	resp, err := service.ValidateNamespace(context.Background(), &api.ValidateNamespaceRequest{
+		Schema: "namespace teams {\n  define member: [user]\n  define lead: [user] or mamber\n}",
+	})
+	if err != nil {
+		t.Fatalf("ValidateNamespace returned error: %v", err)
+	}
+	if resp.GetValid() || len(resp.GetErrors()) != 1 {
+		t.Fatalf("Expected a single error, got %v", resp)
+	}
+	if got := resp.GetErrors()[0]; got.GetCode() != "UNDEFINED_RELATION" || got.GetLine() != 3 || got.GetColumn() != 3 {
+		t.Errorf("Expected the undefined relation at 3:3, got %v", got)
+	}
+
+	resp, err = service.ValidateNamespace(context.Background(), &api.ValidateNamespaceRequest{
+		Schema: "namespace teams {\n  define member: [user] or\n}",
+	})
+	if err != nil {
+		t.Fatalf("ValidateNamespace returned error: %v", err)
+	}
+	if got := resp.GetErrors(); len(got) != 1 || got[0].GetCode() != "SYNTAX_ERROR" || got[0].GetLine() != 3 || got[0].GetColumn() != 1 {
+		t.Errorf("Expected a syntax error at 3:1, got %v", got)
+	}
+}
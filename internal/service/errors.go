@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/DangVTNhan/goacl/internal/database"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// errorDomain identifies this service in gRPC error details
+const errorDomain = "goacl"
+
+// multipleMatchError is returned when a delete filter matches more than one
+// tuple and the request does not allow deleting several
+type multipleMatchError struct {
+	count int
+}
+
+func (e *multipleMatchError) Error() string {
+	return fmt.Sprintf("filter matches %d tuples; set allow_multiple to delete all of them", e.count)
+}
+
+// isOperationError reports whether an error was caused by the operation
+// itself rather than by the storage it was applied to
+func isOperationError(err error) bool {
+	var (
+		precondition *database.PreconditionError
+		multiple     *multipleMatchError
+	)
+	return errors.As(err, &precondition) || errors.As(err, &multiple)
+}
+
+// toStatusError maps service errors to gRPC status errors
+func toStatusError(err error) error {
+	var resolution *resolutionError
+	if errors.As(err, &resolution) {
+		reason := "MAX_DEPTH_EXCEEDED"
+		if errors.Is(resolution.cause, errCycleDetected) {
+			reason = "CYCLE_DETECTED"
+		}
+
+		st := status.New(codes.FailedPrecondition, err.Error())
+		if detailed, detailErr := st.WithDetails(&errdetails.ErrorInfo{
+			Reason:   reason,
+			Domain:   errorDomain,
+			Metadata: map[string]string{"path": formatPath(resolution.path)},
+		}); detailErr == nil {
+			st = detailed
+		}
+		return st.Err()
+	}
+
+	var precondition *database.PreconditionError
+	if errors.As(err, &precondition) {
+		violation := "MUST_NOT_EXIST"
+		if precondition.Precondition.MustExist {
+			violation = "MUST_EXIST"
+		}
+
+		st := status.New(codes.FailedPrecondition, err.Error())
+		if detailed, detailErr := st.WithDetails(&errdetails.PreconditionFailure{
+			Violations: []*errdetails.PreconditionFailure_Violation{{
+				Type:        violation,
+				Subject:     database.TupleKey(precondition.Precondition.Tuple),
+				Description: err.Error(),
+			}},
+		}); detailErr == nil {
+			st = detailed
+		}
+		return st.Err()
+	}
+
+	var breaking *breakingChangeError
+	if errors.As(err, &breaking) {
+		return breaking.status().Err()
+	}
+
+	var multiple *multipleMatchError
+	if errors.As(err, &multiple) {
+		return status.Error(codes.FailedPrecondition, err.Error())
+	}
+
+	switch {
+	case errors.Is(err, database.ErrNotFound):
+		return status.Error(codes.NotFound, err.Error())
+	case errors.Is(err, errUnknownRelation):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, database.ErrAlreadyExists):
+		return status.Error(codes.AlreadyExists, err.Error())
+	case errors.Is(err, database.ErrConflict), errors.Is(err, database.ErrVersionMismatch):
+		return status.Error(codes.Aborted, err.Error())
+	case errors.Is(err, database.ErrNamespaceInUse):
+		return status.Error(codes.FailedPrecondition, err.Error()+"; set force to delete them with the namespace")
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, err.Error())
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, err.Error())
+	}
+	return status.Error(codes.Internal, err.Error())
+}
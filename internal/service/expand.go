@@ -0,0 +1,211 @@
+package service
+
+import (
+	"context"
+
+	"github.com/DangVTNhan/goacl/api"
+	"github.com/DangVTNhan/goacl/internal/rewrite"
+)
+
+// defaultExpandDepth is used when an expand request does not set a depth
+const defaultExpandDepth = 10
+
+// Expand builds the userset tree of the object relation following the same
+// rewrite rules as Check
+// Branches deeper than maxDepth are returned as unexpanded object relations
+func (c *Checker) Expand(ctx context.Context, namespace, objectID, relation string, maxDepth int) (*api.UserSet, error) {
+	if maxDepth <= 0 {
+		maxDepth = defaultExpandDepth
+	}
+	if maxDepth > c.maxDepth {
+		maxDepth = c.maxDepth
+	}
+
+	e := c.newEvaluation()
+	object := objectRef{Namespace: namespace, ObjectID: objectID, Relation: relation}
+
+	// The requested relation itself must exist even though nested ones may not
+	if _, err := e.rule(ctx, namespace, relation); err != nil {
+		return nil, err
+	}
+
+	return e.expand(ctx, object, nil, maxDepth)
+}
+
+// expand returns the userset tree of the object relation
+// Object relations already being expanded on the current path are returned
+// as leaves so that cycles in the data end the branch instead of repeating it
+func (e *evaluation) expand(ctx context.Context, object objectRef, path []objectRef, maxDepth int) (*api.UserSet, error) {
+	if len(path) >= maxDepth {
+		return objectRelationLeaf(object), nil
+	}
+
+	for _, visited := range path {
+		if visited == object {
+			return objectRelationLeaf(object), nil
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	rule, err := e.rule(ctx, object.Namespace, object.Relation)
+	if err != nil {
+		return nil, err
+	}
+
+	return e.expandRule(ctx, rule, object, appendPath(path, object), maxDepth)
+}
+
+// expandRule returns the userset tree of a single rewrite node
+func (e *evaluation) expandRule(ctx context.Context, rule *rewrite.Rule, object objectRef, path []objectRef, maxDepth int) (*api.UserSet, error) {
+	switch {
+	case rule.This != nil:
+		return e.expandDirect(ctx, object, path, maxDepth)
+
+	case rule.ComputedUserset != nil:
+		return e.expand(ctx, object.withRelation(rule.ComputedUserset.Relation), path, maxDepth)
+
+	case rule.TupleToUserset != nil:
+		return e.expandTupleToUserset(ctx, rule.TupleToUserset, object, path, maxDepth)
+
+	case rule.Union != nil:
+		children := make([]*api.UserSet, 0, len(rule.Union.Child))
+		for _, child := range rule.Union.Child {
+			userset, err := e.expandRule(ctx, child, object, path, maxDepth)
+			if err != nil {
+				return nil, err
+			}
+			children = append(children, userset)
+		}
+		return unionOf(children), nil
+
+	case rule.Intersection != nil:
+		children := make([]*api.UserSet, 0, len(rule.Intersection.Child))
+		for _, child := range rule.Intersection.Child {
+			userset, err := e.expandRule(ctx, child, object, path, maxDepth)
+			if err != nil {
+				return nil, err
+			}
+			children = append(children, userset)
+		}
+		return &api.UserSet{
+			Userset: &api.UserSet_Intersection{
+				Intersection: &api.UserSetIntersection{Children: children},
+			},
+		}, nil
+
+	case rule.Exclusion != nil:
+		base, err := e.expandRule(ctx, rule.Exclusion.Base, object, path, maxDepth)
+		if err != nil {
+			return nil, err
+		}
+		exclude, err := e.expandRule(ctx, rule.Exclusion.Exclude, object, path, maxDepth)
+		if err != nil {
+			return nil, err
+		}
+		return &api.UserSet{
+			Userset: &api.UserSet_Exclusion{
+				Exclusion: &api.UserSetExclusion{Base: base, Exclude: exclude},
+			},
+		}, nil
+	}
+
+	return unionOf(nil), nil
+}
+
+// expandDirect returns the users and usersets stored directly on the object relation
+func (e *evaluation) expandDirect(ctx context.Context, object objectRef, path []objectRef, maxDepth int) (*api.UserSet, error) {
+	tuples, err := e.relationTuples(ctx, object)
+	if err != nil {
+		return nil, err
+	}
+
+	ns, err := e.namespace(ctx, object.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	children := make([]*api.UserSet, 0, len(tuples))
+	for _, tuple := range tuples {
+		if tuple.Userset == "" {
+			// Wildcards are kept as user leaves where the relation allows them
+			if isWildcard(tuple.UserID) && !ns.wildcards[object.Relation] {
+				continue
+			}
+			children = append(children, &api.UserSet{Userset: &api.UserSet_UserId{UserId: tuple.UserID}})
+			continue
+		}
+
+		subject, err := parseUserset(tuple.Userset)
+		if err != nil || subject.Relation == "" {
+			continue
+		}
+
+		userset, err := e.expand(ctx, subject, path, maxDepth)
+		if err != nil {
+			if isUndefined(err) {
+				continue
+			}
+			return nil, err
+		}
+		children = append(children, userset)
+	}
+
+	return unionOf(children), nil
+}
+
+// expandTupleToUserset returns the computed relation of every object
+// referenced by the tupleset
+func (e *evaluation) expandTupleToUserset(ctx context.Context, ttu *rewrite.TupleToUserset, object objectRef, path []objectRef, maxDepth int) (*api.UserSet, error) {
+	tuples, err := e.relationTuples(ctx, object.withRelation(ttu.Tupleset.Relation))
+	if err != nil {
+		return nil, err
+	}
+
+	children := make([]*api.UserSet, 0, len(tuples))
+	for _, tuple := range tuples {
+		if tuple.Userset == "" {
+			continue
+		}
+
+		target, err := parseUserset(tuple.Userset)
+		if err != nil {
+			continue
+		}
+
+		userset, err := e.expand(ctx, target.withRelation(ttu.ComputedUserset.Relation), path, maxDepth)
+		if err != nil {
+			if isUndefined(err) {
+				continue
+			}
+			return nil, err
+		}
+		children = append(children, userset)
+	}
+
+	return unionOf(children), nil
+}
+
+// objectRelationLeaf returns an unexpanded reference to an object relation
+func objectRelationLeaf(object objectRef) *api.UserSet {
+	return &api.UserSet{
+		Userset: &api.UserSet_ObjectRelation{
+			ObjectRelation: &api.ObjectRelation{
+				Namespace: object.Namespace,
+				ObjectId:  object.ObjectID,
+				Relation:  object.Relation,
+			},
+		},
+	}
+}
+
+// unionOf wraps the children in a union node
+func unionOf(children []*api.UserSet) *api.UserSet {
+	return &api.UserSet{
+		Userset: &api.UserSet_Union{
+			Union: &api.UserSetUnion{Children: children},
+		},
+	}
+}
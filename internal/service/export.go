@@ -0,0 +1,81 @@
+package service
+
+import (
+	"time"
+
+	"github.com/DangVTNhan/goacl/api"
+	"github.com/DangVTNhan/goacl/internal/database"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// exportPageSize is the number of tuples read at a time by an export
+	exportPageSize = 1000
+
+	// ConsistencyTokenTrailer is the trailer holding the consistency token of
+	// an export's snapshot
+	ConsistencyTokenTrailer = "consistency-token"
+)
+
+// ExportRelations streams every namespace configuration and live relation
+// tuple as of a single Dgraph read timestamp
+// The consistency token of that snapshot is sent in a trailer, so a stream
+// without it did not complete
+func (s *RelationshipService) ExportRelations(req *api.ExportRelationsRequest, stream grpc.ServerStreamingServer[api.ExportRelationsResponse]) error {
+	ctx := stream.Context()
+
+	var readTs uint64
+	if req.GetConsistencyToken() != "" {
+		ts, err := database.DecodeConsistencyToken(req.GetConsistencyToken())
+		if err != nil {
+			return status.Error(codes.InvalidArgument, err.Error())
+		}
+		readTs = ts
+	}
+
+	// The first page fixes the read timestamp when none was requested, so
+	// the namespaces sent before it are read from the same snapshot
+	page, err := s.store.ReadTuples(ctx, database.TupleFilter{}, "", exportPageSize, readTs)
+	if err != nil {
+		return toStatusError(err)
+	}
+	readTs = page.ReadTs
+
+	namespaces, err := s.store.ListNamespaceConfigsAt(ctx, readTs)
+	if err != nil {
+		return toStatusError(err)
+	}
+	for _, config := range namespaces {
+		resp := &api.ExportRelationsResponse{Record: &api.ExportRelationsResponse_Namespace{Namespace: namespaceToProto(config)}}
+		if err := stream.Send(resp); err != nil {
+			return err
+		}
+	}
+
+	now := time.Now()
+	for {
+		for _, tuple := range page.Tuples {
+			if tuple.Expired(now) {
+				continue
+			}
+			resp := &api.ExportRelationsResponse{Record: &api.ExportRelationsResponse_Tuple{Tuple: tupleToProto(tuple)}}
+			if err := stream.Send(resp); err != nil {
+				return err
+			}
+		}
+
+		if page.After == "" {
+			break
+		}
+		page, err = s.store.ReadTuples(ctx, database.TupleFilter{}, page.After, exportPageSize, readTs)
+		if err != nil {
+			return toStatusError(err)
+		}
+	}
+
+	stream.SetTrailer(metadata.Pairs(ConsistencyTokenTrailer, database.EncodeConsistencyToken(readTs)))
+	return nil
+}
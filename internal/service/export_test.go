@@ -0,0 +1,71 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DangVTNhan/goacl/api"
+	"github.com/DangVTNhan/goacl/internal/database"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// exportStream collects the records and trailer of an export
+type exportStream struct {
+	grpc.ServerStream
+	sent    []*api.ExportRelationsResponse
+	trailer metadata.MD
+}
+
+func (s *exportStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *exportStream) Send(resp *api.ExportRelationsResponse) error {
+	s.sent = append(s.sent, resp)
+	return nil
+}
+
+func (s *exportStream) SetTrailer(md metadata.MD) {
+	s.trailer = metadata.Join(s.trailer, md)
+}
+
+func TestExportRelations(t *testing.T) {
+	store := &pagedStore{
+		readTs:     7,
+		namespaces: []database.NamespaceConfig{{Name: "documents"}, {Name: "folders"}},
+	}
+	for i := 0; i < exportPageSize+1; i++ {
+		store.tuples = append(store.tuples, database.RelationTuple{
+			UID:       fmt.Sprintf("0x%05x", i+1),
+			Namespace: "documents",
+			ObjectID:  fmt.Sprintf("doc%d", i),
+			Relation:  "viewer",
+			UserID:    "alice",
+		})
+	}
+	store.tuples[0].ExpiresAt = "2000-01-01T00:00:00Z"
+
+	stream := &exportStream{}
+	if err := NewRelationshipService(store, nil).ExportRelations(&api.ExportRelationsRequest{}, stream); err != nil {
+		t.Fatalf("ExportRelations returned error: %v", err)
+	}
+
+	if len(stream.sent) != 2+exportPageSize {
+		t.Fatalf("Expected 2 namespaces and %d live tuples, got %d records", exportPageSize, len(stream.sent))
+	}
+	if stream.sent[0].GetNamespace().GetName() != "documents" || stream.sent[1].GetNamespace().GetName() != "folders" {
+		t.Errorf("Expected namespaces to be exported first, got %v", stream.sent[:2])
+	}
+	if stream.sent[2].GetTuple().GetObjectId() != "doc1" {
+		t.Errorf("Expected the expired tuple to be left out, got %v", stream.sent[2])
+	}
+
+	if got := stream.trailer.Get(ConsistencyTokenTrailer); len(got) != 1 || got[0] != database.EncodeConsistencyToken(7) {
+		t.Errorf("Expected the trailer to hold the export's consistency token, got %v", got)
+	}
+	if fmt.Sprint(store.requestedTs) != "[0 7]" {
+		t.Errorf("Expected every page after the first to be read at its timestamp, got %v", store.requestedTs)
+	}
+}
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+
+	"github.com/DangVTNhan/goacl/api"
+	"github.com/DangVTNhan/goacl/internal/database"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// importBatchSize is the number of tuples stored by each import mutation
+	importBatchSize = 1000
+
+	// importAttempts is how often a batch is tried when it conflicts with
+	// concurrent writes to the same tuples
+	importAttempts = 3
+
+	// maxImportErrors caps the number of row errors returned by an import
+	maxImportErrors = 1000
+
+	// importLogInterval is the number of rows between progress logs
+	importLogInterval = 100000
+)
+
+// ImportRelations stores the tuples streamed by the client in large batches,
+// skipping tuples that already exist, and reports progress after each batch
+// Failing rows are reported in the final response; unless the import runs in
+// best effort mode it stops at the first one, keeping the rows imported before it
+func (s *RelationshipService) ImportRelations(stream grpc.BidiStreamingServer[api.ImportRelationsRequest, api.ImportRelationsResponse]) error {
+	ctx := stream.Context()
+	imp := &tupleImport{
+		store:     s.store,
+		validator: newSchemaValidator(s.store),
+		send:      stream.Send,
+		resp:      &api.ImportRelationsResponse{},
+	}
+
+	for first := true; !imp.resp.Aborted; first = false {
+		req, err := stream.Recv()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		if first {
+			imp.bestEffort = req.GetMode() == api.ImportMode_IMPORT_MODE_BEST_EFFORT
+		}
+
+		for _, tuple := range req.GetTuples() {
+			if err := imp.add(ctx, tuple); err != nil {
+				return err
+			}
+			if imp.resp.Aborted {
+				break
+			}
+		}
+	}
+
+	if err := imp.flush(ctx); err != nil {
+		return err
+	}
+
+	if imp.commitTs != 0 {
+		imp.resp.ConsistencyToken = database.EncodeConsistencyToken(imp.commitTs)
+	}
+	imp.resp.CompletedAt = timestamppb.Now()
+	imp.logProgress("Import finished")
+
+	return stream.Send(imp.resp)
+}
+
+// tupleImport accumulates the rows of an import into batches
+type tupleImport struct {
+	store      TupleStore
+	validator  *schemaValidator
+	bestEffort bool
+	send       func(*api.ImportRelationsResponse) error
+	resp       *api.ImportRelationsResponse
+
+	// pending holds the validated rows not stored yet
+	pending []importRow
+
+	// commitTs is the latest commit timestamp of the stored batches
+	commitTs uint64
+
+	// reported is the number of rows received at the last progress log
+	reported int64
+}
+
+// importRow is a validated row waiting to be stored
+type importRow struct {
+	row   int64
+	proto *api.RelationTuple
+	tuple database.RelationTuple
+}
+
+// add validates a row and queues it, storing the batch once it is full
+func (i *tupleImport) add(ctx context.Context, tuple *api.RelationTuple) error {
+	row := i.resp.TuplesReceived
+	i.resp.TuplesReceived++
+
+	converted, err := tupleFromProto(tuple)
+	if err != nil {
+		i.fail(row, tuple, err.Error())
+		return nil
+	}
+
+	if err := i.validator.validate(ctx, "tuple", converted); err != nil {
+		return err
+	}
+	if err := i.validator.err(); err != nil {
+		i.validator.reset()
+		i.fail(row, tuple, status.Convert(err).Message())
+		return nil
+	}
+
+	i.pending = append(i.pending, importRow{row: row, proto: tuple, tuple: converted})
+	if len(i.pending) < importBatchSize {
+		return nil
+	}
+	if err := i.flush(ctx); err != nil {
+		return err
+	}
+	return i.reportProgress()
+}
+
+// reportProgress sends the counts so far to the client, leaving the row
+// errors for the final response
+func (i *tupleImport) reportProgress() error {
+	progress := &api.ImportRelationsResponse{
+		TuplesReceived: i.resp.TuplesReceived,
+		TuplesImported: i.resp.TuplesImported,
+		TuplesSkipped:  i.resp.TuplesSkipped,
+		TuplesFailed:   i.resp.TuplesFailed,
+	}
+	if i.commitTs != 0 {
+		progress.ConsistencyToken = database.EncodeConsistencyToken(i.commitTs)
+	}
+	return i.send(progress)
+}
+
+// flush stores the pending rows, retrying batches that conflict with
+// concurrent writes
+// A batch that cannot be stored fails each of its rows; one that keeps
+// conflicting reports its rows without aborting the import
+func (i *tupleImport) flush(ctx context.Context) error {
+	if len(i.pending) == 0 {
+		return nil
+	}
+
+	tuples := make([]database.RelationTuple, len(i.pending))
+	for k, row := range i.pending {
+		tuples[k] = row.tuple
+	}
+
+	var (
+		result *database.ImportResult
+		err    error
+	)
+	for attempt := 0; attempt < importAttempts; attempt++ {
+		result, err = i.store.ImportTuples(ctx, tuples)
+		if !errors.Is(err, database.ErrConflict) {
+			break
+		}
+	}
+	if ctx.Err() != nil {
+		return toStatusError(ctx.Err())
+	}
+
+	switch {
+	case errors.Is(err, database.ErrConflict):
+		log.Printf("Warning: batch of %d tuples conflicted after %d attempts", len(tuples), importAttempts)
+		message := fmt.Sprintf("conflict with concurrent writes after %d attempts", importAttempts)
+		for _, row := range i.pending {
+			i.record(row.row, row.proto, message)
+		}
+	case err != nil:
+		log.Printf("Warning: failed to import batch of %d tuples: %v", len(tuples), err)
+		for _, row := range i.pending {
+			i.fail(row.row, row.proto, "failed to store tuple: "+err.Error())
+		}
+	default:
+		i.resp.TuplesImported += int64(len(result.Imported))
+		i.resp.TuplesSkipped += int64(result.Skipped)
+		i.commitTs = max(i.commitTs, result.CommitTs)
+	}
+	i.pending = i.pending[:0]
+
+	if i.resp.TuplesReceived-i.reported >= importLogInterval {
+		i.logProgress("Import progress")
+	}
+	return nil
+}
+
+// fail records a failing row, aborting the import unless it is best effort
+func (i *tupleImport) fail(row int64, tuple *api.RelationTuple, message string) {
+	i.record(row, tuple, message)
+	if !i.bestEffort {
+		i.resp.Aborted = true
+	}
+}
+
+// record reports a row that was not imported
+func (i *tupleImport) record(row int64, tuple *api.RelationTuple, message string) {
+	i.resp.TuplesFailed++
+	if len(i.resp.Errors) < maxImportErrors {
+		i.resp.Errors = append(i.resp.Errors, &api.ImportError{Row: row, Tuple: tuple, Error: message})
+	}
+}
+
+func (i *tupleImport) logProgress(prefix string) {
+	i.reported = i.resp.TuplesReceived
+	log.Printf("%s: %d received, %d imported, %d skipped, %d failed",
+		prefix, i.resp.TuplesReceived, i.resp.TuplesImported, i.resp.TuplesSkipped, i.resp.TuplesFailed)
+}
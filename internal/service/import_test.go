@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/DangVTNhan/goacl/api"
+	"github.com/DangVTNhan/goacl/internal/database"
+	"google.golang.org/grpc"
+)
+
+// importStore records imported tuples in memory
+type importStore struct {
+	*memoryReader
+	stored  map[string]bool
+	batches int
+
+	// failBatch is the one-based batch that fails, zero when none does
+	failBatch int
+
+	// conflicts is the number of attempts that conflict
+	conflicts int
+}
+
+func (s *importStore) NewTupleTxn() *database.TupleTxn {
+	return nil
+}
+
+func (s *importStore) ReadTuples(context.Context, database.TupleFilter, string, int, uint64) (*database.TuplePage, error) {
+	return nil, errors.New("not supported")
+}
+
+func (s *importStore) ListNamespaceConfigsAt(ctx context.Context, _ uint64) ([]database.NamespaceConfig, error) {
+	return s.ListNamespaceConfigs(ctx)
+}
+
+func (s *importStore) ImportTuples(_ context.Context, tuples []database.RelationTuple) (*database.ImportResult, error) {
+	s.batches++
+	if s.conflicts > 0 {
+		s.conflicts--
+		return nil, database.ErrConflict
+	}
+	if s.batches == s.failBatch {
+		return nil, errors.New("dgraph unavailable")
+	}
+
+	result := &database.ImportResult{CommitTs: uint64(s.batches)}
+	for _, tuple := range tuples {
+		key := database.TupleKey(tuple)
+		if s.stored[key] {
+			result.Skipped++
+			continue
+		}
+		s.stored[key] = true
+		result.Imported = append(result.Imported, tuple)
+	}
+	return result, nil
+}
+
+// importStream replays requests to an import and keeps its progress and
+// final responses
+type importStream struct {
+	grpc.ServerStream
+	requests []*api.ImportRelationsRequest
+	progress []*api.ImportRelationsResponse
+	resp     *api.ImportRelationsResponse
+}
+
+func (s *importStream) Context() context.Context {
+	return context.Background()
+}
+
+func (s *importStream) Recv() (*api.ImportRelationsRequest, error) {
+	if len(s.requests) == 0 {
+		return nil, io.EOF
+	}
+	req := s.requests[0]
+	s.requests = s.requests[1:]
+	return req, nil
+}
+
+func (s *importStream) Send(resp *api.ImportRelationsResponse) error {
+	if s.resp != nil {
+		s.progress = append(s.progress, s.resp)
+	}
+	s.resp = resp
+	return nil
+}
+
+func TestImportRelations(t *testing.T) {
+	viewer := func(user string) *api.RelationTuple {
+		return &api.RelationTuple{Namespace: "documents", ObjectId: "doc1", Relation: "viewer", UserId: user}
+	}
+	tuples := []*api.RelationTuple{
+		viewer("alice"),
+		viewer("bob"),
+		viewer("alice"),
+		{Namespace: "documents", ObjectId: "doc1", Relation: "viwer", UserId: "carol"},
+		viewer("dave"),
+	}
+
+	run := func(t *testing.T, mode api.ImportMode) *api.ImportRelationsResponse {
+		store := &importStore{memoryReader: newMemoryReader(), stored: map[string]bool{"documents:doc1#viewer@bob": true}}
+		stream := &importStream{requests: []*api.ImportRelationsRequest{
+			{Tuples: tuples[:2], Mode: mode},
+			{Tuples: tuples[2:]},
+		}}
+
+		if err := NewRelationshipService(store, nil).ImportRelations(stream); err != nil {
+			t.Fatalf("ImportRelations returned error: %v", err)
+		}
+		return stream.resp
+	}
+
+	t.Run("best effort", func(t *testing.T) {
+		resp := run(t, api.ImportMode_IMPORT_MODE_BEST_EFFORT)
+		if resp.GetAborted() {
+			t.Error("Expected a best effort import not to abort")
+		}
+		if resp.GetTuplesReceived() != 5 || resp.GetTuplesImported() != 2 || resp.GetTuplesSkipped() != 2 || resp.GetTuplesFailed() != 1 {
+			t.Errorf("Unexpected counts: %v", resp)
+		}
+		if len(resp.GetErrors()) != 1 || resp.GetErrors()[0].GetRow() != 3 {
+			t.Errorf("Expected row 3 to fail, got %v", resp.GetErrors())
+		}
+		if resp.GetConsistencyToken() == "" {
+			t.Error("Expected a consistency token for the imported tuples")
+		}
+	})
+
+	t.Run("abort on error", func(t *testing.T) {
+		resp := run(t, api.ImportMode_IMPORT_MODE_UNSPECIFIED)
+		if !resp.GetAborted() {
+			t.Error("Expected the import to abort")
+		}
+		if resp.GetTuplesReceived() != 4 || resp.GetTuplesImported() != 1 || resp.GetTuplesSkipped() != 2 {
+			t.Errorf("Expected the rows before the failure to be imported, got %v", resp)
+		}
+	})
+
+	t.Run("failed batch", func(t *testing.T) {
+		store := &importStore{memoryReader: newMemoryReader(), stored: map[string]bool{}, failBatch: 1}
+		stream := &importStream{requests: []*api.ImportRelationsRequest{
+			{Tuples: []*api.RelationTuple{viewer("alice"), viewer("bob")}, Mode: api.ImportMode_IMPORT_MODE_BEST_EFFORT},
+		}}
+
+		if err := NewRelationshipService(store, nil).ImportRelations(stream); err != nil {
+			t.Fatalf("ImportRelations returned error: %v", err)
+		}
+		if stream.resp.GetTuplesFailed() != 2 || len(stream.resp.GetErrors()) != 2 {
+			t.Errorf("Expected every row of the failed batch to be reported, got %v", stream.resp)
+		}
+	})
+
+	t.Run("conflicting batch", func(t *testing.T) {
+		store := &importStore{memoryReader: newMemoryReader(), stored: map[string]bool{}, conflicts: importAttempts}
+		stream := &importStream{requests: []*api.ImportRelationsRequest{
+			{Tuples: []*api.RelationTuple{viewer("alice")}},
+		}}
+
+		if err := NewRelationshipService(store, nil).ImportRelations(stream); err != nil {
+			t.Fatalf("ImportRelations returned error: %v", err)
+		}
+		if stream.resp.GetAborted() || stream.resp.GetTuplesFailed() != 1 || len(stream.resp.GetErrors()) != 1 {
+			t.Errorf("Expected the conflicting row to be reported without aborting, got %v", stream.resp)
+		}
+	})
+
+	t.Run("progress", func(t *testing.T) {
+		batch := make([]*api.RelationTuple, importBatchSize+1)
+		for i := range batch {
+			batch[i] = viewer(fmt.Sprintf("user%d", i))
+		}
+		store := &importStore{memoryReader: newMemoryReader(), stored: map[string]bool{}}
+		stream := &importStream{requests: []*api.ImportRelationsRequest{{Tuples: batch}}}
+
+		if err := NewRelationshipService(store, nil).ImportRelations(stream); err != nil {
+			t.Fatalf("ImportRelations returned error: %v", err)
+		}
+		if len(stream.progress) != 1 {
+			t.Fatalf("Expected progress after the first batch, got %v", stream.progress)
+		}
+		if progress := stream.progress[0]; progress.GetTuplesImported() != importBatchSize || progress.GetCompletedAt() != nil {
+			t.Errorf("Unexpected progress: %v", progress)
+		}
+		if stream.resp.GetTuplesImported() != importBatchSize+1 || stream.resp.GetCompletedAt() == nil {
+			t.Errorf("Unexpected final response: %v", stream.resp)
+		}
+	})
+}
@@ -0,0 +1,315 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/DangVTNhan/goacl/internal/database"
+	"github.com/DangVTNhan/goacl/internal/rewrite"
+)
+
+// reverseIndex inverts the rewrite rules of all namespaces so that
+// permissions can be derived starting from a user instead of an object
+type reverseIndex struct {
+	// direct holds the namespace#relation pairs whose rules include _this
+	direct map[string]bool
+
+	// computed maps namespace#relation to the relations of the same object
+	// that include it through computed_userset
+	computed map[string][]string
+
+	// tupleToUserset maps a computed relation name to the relations that
+	// reach it through tuple_to_userset
+	tupleToUserset map[string][]reverseTupleToUserset
+
+	// wildcards holds the namespace#relation pairs that may be granted to
+	// wildcard subjects
+	wildcards map[string]bool
+
+	// filtered holds the namespace#relation pairs whose rules use
+	// intersection or exclusion, so reaching one of their branches only
+	// makes them a candidate that must be confirmed with a forward check
+	filtered map[string]bool
+}
+
+// reverseTupleToUserset is a tuple_to_userset edge seen from its target
+type reverseTupleToUserset struct {
+	namespace string
+	tupleset  string
+	relation  string
+}
+
+// resource is an object relation found by a lookup
+type resource struct {
+	objectRef
+
+	// Wildcard is set when the relation is only held through a wildcard subject
+	Wildcard bool
+}
+
+// LookupResources returns every object relation the user holds, derived by
+// walking the rewrite rules backwards from the user's tuples
+// Relations reached through the user's own tuples are found first, so those
+// reached only through wildcard subjects can be told apart
+// Tuples whose conditions do not hold without request context are ignored
+// Results are ordered by namespace, object and relation
+func (c *Checker) LookupResources(ctx context.Context, userID string) ([]resource, error) {
+	e := c.newEvaluation()
+
+	index, err := e.reverseIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		seen              = make(map[objectRef]bool)
+		referencingTuples = make(map[objectRef][]database.RelationTuple)
+		queue             []objectRef
+		results           []resource
+		wildcard          bool
+	)
+
+	enqueue := func(ref objectRef) error {
+		if seen[ref] {
+			return nil
+		}
+		seen[ref] = true
+
+		if index.filtered[relationKey(ref.Namespace, ref.Relation)] {
+			result, err := e.check(ctx, ref, userID, nil)
+			if err != nil {
+				return err
+			}
+			if !result.Allowed {
+				return nil
+			}
+		}
+
+		results = append(results, resource{objectRef: ref, Wildcard: wildcard})
+		queue = append(queue, ref)
+		return nil
+	}
+
+	// seed enqueues the relations granted directly by the subject's tuples
+	seed := func(subject string) error {
+		tuples, err := c.reader.GetTuplesByUser(ctx, subject)
+		if err != nil {
+			return err
+		}
+
+		for _, tuple := range liveTuples(tuples, e.now) {
+			if tuple.Userset != "" || tuple.UserID != subject || !e.tupleCondition(tuple).Allowed {
+				continue
+			}
+			key := relationKey(tuple.Namespace, tuple.Relation)
+			if !index.direct[key] || (wildcard && !index.wildcards[key]) {
+				continue
+			}
+			if err := enqueue(objectRef{Namespace: tuple.Namespace, ObjectID: tuple.ObjectID, Relation: tuple.Relation}); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	// walk follows the reverse rewrite edges until the queue is empty
+	walk := func() error {
+		for len(queue) > 0 {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+
+			current := queue[0]
+			queue = queue[1:]
+
+			// Other relations of the same object that include this one
+			for _, relation := range index.computed[relationKey(current.Namespace, current.Relation)] {
+				if err := enqueue(current.withRelation(relation)); err != nil {
+					return err
+				}
+			}
+
+			// Every relation of an object shares the same referencing tuples
+			object := current.withRelation("")
+			referencing, ok := referencingTuples[object]
+			if !ok {
+				tuples, err := c.reader.GetTuplesByUsersetObject(ctx, current.Namespace, current.ObjectID)
+				if err != nil {
+					return err
+				}
+				referencing = liveTuples(tuples, e.now)
+				referencingTuples[object] = referencing
+			}
+
+			for _, tuple := range referencing {
+				subject, err := parseUserset(tuple.Userset)
+				if err != nil || !e.tupleCondition(tuple).Allowed {
+					continue
+				}
+
+				// Tuples granting a relation to current as a userset subject
+				if subject.Relation == current.Relation && index.direct[relationKey(tuple.Namespace, tuple.Relation)] {
+					if err := enqueue(objectRef{Namespace: tuple.Namespace, ObjectID: tuple.ObjectID, Relation: tuple.Relation}); err != nil {
+						return err
+					}
+				}
+
+				// Objects pointing at current through a tupleset
+				for _, edge := range index.tupleToUserset[current.Relation] {
+					if edge.namespace == tuple.Namespace && edge.tupleset == tuple.Relation {
+						if err := enqueue(objectRef{Namespace: tuple.Namespace, ObjectID: tuple.ObjectID, Relation: edge.relation}); err != nil {
+							return err
+						}
+					}
+				}
+			}
+		}
+		return nil
+	}
+
+	if err := seed(userID); err != nil {
+		return nil, err
+	}
+	if err := walk(); err != nil {
+		return nil, err
+	}
+
+	wildcard = true
+	for _, subject := range wildcardSubjects(userID) {
+		if err := seed(subject); err != nil {
+			return nil, err
+		}
+	}
+	if err := walk(); err != nil {
+		return nil, err
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].String() < results[j].String()
+	})
+
+	return results, nil
+}
+
+// reverseIndex builds the inverted rewrite rules of every namespace
+func (e *evaluation) reverseIndex(ctx context.Context) (*reverseIndex, error) {
+	configs, err := e.reader.ListNamespaceConfigs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	index := &reverseIndex{
+		direct:         make(map[string]bool),
+		computed:       make(map[string][]string),
+		tupleToUserset: make(map[string][]reverseTupleToUserset),
+		wildcards:      make(map[string]bool),
+		filtered:       make(map[string]bool),
+	}
+
+	for _, config := range configs {
+		for _, rel := range config.Relations {
+			rule, err := rewrite.Parse(rel.RewriteRules)
+			if err != nil {
+				return nil, fmt.Errorf("relation %s#%s: %w", config.Name, rel.Name, err)
+			}
+			index.add(config.Name, rel.Name, rule)
+			index.wildcards[relationKey(config.Name, rel.Name)] = rel.AllowWildcard
+			if !rule.Monotonic() {
+				index.filtered[relationKey(config.Name, rel.Name)] = true
+			}
+		}
+	}
+
+	return index, nil
+}
+
+// add records the edges contributed by a rewrite node of namespace#relation
+func (idx *reverseIndex) add(namespace, relation string, rule *rewrite.Rule) {
+	switch {
+	case rule.This != nil:
+		idx.direct[relationKey(namespace, relation)] = true
+
+	case rule.ComputedUserset != nil:
+		key := relationKey(namespace, rule.ComputedUserset.Relation)
+		idx.computed[key] = append(idx.computed[key], relation)
+
+	case rule.TupleToUserset != nil:
+		target := rule.TupleToUserset.ComputedUserset.Relation
+		idx.tupleToUserset[target] = append(idx.tupleToUserset[target], reverseTupleToUserset{
+			namespace: namespace,
+			tupleset:  rule.TupleToUserset.Tupleset.Relation,
+			relation:  relation,
+		})
+
+	case rule.Union != nil:
+		for _, child := range rule.Union.Child {
+			idx.add(namespace, relation, child)
+		}
+
+	case rule.Intersection != nil:
+		for _, child := range rule.Intersection.Child {
+			idx.add(namespace, relation, child)
+		}
+
+	case rule.Exclusion != nil:
+		// Only the base can grant the relation; the excluded branch is
+		// enforced by the forward check of the candidate
+		idx.add(namespace, relation, rule.Exclusion.Base)
+	}
+}
+
+// relationKey formats a namespace#relation pair used as a map key
+func relationKey(namespace, relation string) string {
+	return namespace + "#" + relation
+}
+
+// maxCachedLookups caps the number of lookups kept for paging
+const maxCachedLookups = 64
+
+// lookupCache keeps the results of recent lookups made at a snapshot, so the
+// following pages of a listing read them instead of repeating the lookup
+// Results at a snapshot never change, so entries are only evicted, oldest
+// first, to stay within maxCachedLookups
+type lookupCache struct {
+	mu      sync.Mutex
+	entries map[lookupKey][]resource
+	order   []lookupKey
+}
+
+// lookupKey identifies the lookup of a user's resources at a snapshot
+type lookupKey struct {
+	readTs uint64
+	userID string
+}
+
+func newLookupCache() *lookupCache {
+	return &lookupCache{entries: make(map[lookupKey][]resource)}
+}
+
+// get returns the resources found for the user at readTs, if still cached
+func (c *lookupCache) get(readTs uint64, userID string) ([]resource, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	resources, ok := c.entries[lookupKey{readTs, userID}]
+	return resources, ok
+}
+
+// put caches the resources found for the user at readTs
+func (c *lookupCache) put(readTs uint64, userID string, resources []resource) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	key := lookupKey{readTs, userID}
+	if _, ok := c.entries[key]; ok {
+		return
+	}
+	if len(c.order) == maxCachedLookups {
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+	}
+	c.entries[key] = resources
+	c.order = append(c.order, key)
+}
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"github.com/DangVTNhan/goacl/api"
+	"github.com/DangVTNhan/goacl/internal/database"
+	"github.com/DangVTNhan/goacl/internal/rewrite"
+	"github.com/DangVTNhan/goacl/internal/schema"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+)
+
+// reservedNameChars delimit the parts of tuples and usersets, so namespace
+// and relation names cannot contain them
+const reservedNameChars = ":#@*"
+
+// namespaceToProto converts a stored namespace configuration to its API form
+func namespaceToProto(config database.NamespaceConfig) *api.NamespaceConfig {
+	result := &api.NamespaceConfig{
+		Name:      config.Name,
+		Relations: make([]*api.RelationConfig, len(config.Relations)),
+		CreatedAt: timestampFromString(config.CreatedAt),
+		UpdatedAt: timestampFromString(config.UpdatedAt),
+		Version:   config.Version,
+	}
+	for i, rel := range config.Relations {
+		result.Relations[i] = relationToProto(rel)
+	}
+	return result
+}
+
+// relationToProto converts a stored relation configuration to its API form,
+// with its rewrite rules both as JSON and as a typed tree
+func relationToProto(rel database.RelationConfig) *api.RelationConfig {
+	result := &api.RelationConfig{
+		Name:          rel.Name,
+		RewriteRules:  rel.RewriteRules,
+		AllowWildcard: rel.AllowWildcard,
+	}
+	if rule, err := rewrite.Parse(rel.RewriteRules); err == nil {
+		result.Rewrite = rewriteToProto(rule)
+	}
+	return result
+}
+
+// namespaceSource is a namespace configuration given by a request, either
+// as a configuration or as schema text
+type namespaceSource struct {
+	// field names the request field holding the configuration
+	field  string
+	config *api.NamespaceConfig
+
+	// parsed is the namespace defined by schema text, used to locate problems
+	parsed *schema.Namespace
+
+	// typed marks the relations whose rewrite rules were given as a tree
+	typed map[int]bool
+}
+
+// newNamespaceSource resolves the configuration given by a request, which
+// sets at most one of config and schema text
+// Schema text must define exactly one namespace, and rewrite rules given
+// as typed trees are converted to JSON
+func newNamespaceSource(config *api.NamespaceConfig, text string) (*namespaceSource, *api.ValidationError) {
+	if text == "" {
+		config, typed, problem := typedRewrites("config", config)
+		if problem != nil {
+			return nil, problem
+		}
+		return &namespaceSource{field: "config", config: config, typed: typed}, nil
+	}
+	if config != nil {
+		return nil, &api.ValidationError{Field: "schema", Message: "only one of config and schema may be set", Code: "CONFLICTING_FIELDS"}
+	}
+
+	namespaces, err := schema.Parse(text)
+	var syntax *schema.SyntaxError
+	switch {
+	case errors.As(err, &syntax):
+		return nil, &api.ValidationError{
+			Field:   "schema",
+			Message: syntax.Message,
+			Code:    "SYNTAX_ERROR",
+			Line:    int32(syntax.Line),
+			Column:  int32(syntax.Column),
+		}
+	case err != nil:
+		return nil, &api.ValidationError{Field: "schema", Message: err.Error(), Code: "SYNTAX_ERROR"}
+	case len(namespaces) == 0:
+		return nil, &api.ValidationError{Field: "schema", Message: "schema must define a namespace", Code: "INVALID_SCHEMA"}
+	case len(namespaces) > 1:
+		return nil, &api.ValidationError{
+			Field:   "schema",
+			Message: fmt.Sprintf("schema must define exactly one namespace, found %d", len(namespaces)),
+			Code:    "INVALID_SCHEMA",
+			Line:    int32(namespaces[1].Position.Line),
+			Column:  int32(namespaces[1].Position.Column),
+		}
+	}
+
+	return &namespaceSource{field: "schema", config: namespaces[0].Config(), parsed: namespaces[0]}, nil
+}
+
+// check runs checkNamespace on the configuration
+func (s *namespaceSource) check() ([]*api.ValidationError, []*api.ValidationWarning) {
+	errs, warnings := checkNamespace(s.field, s.config)
+	s.locate(errs, warnings)
+	return errs, warnings
+}
+
+// locate points every problem at where the request wrote what it concerns:
+// the definition in schema text, or the rewrite field of typed rules
+func (s *namespaceSource) locate(errs []*api.ValidationError, warnings []*api.ValidationWarning) {
+	for _, problem := range errs {
+		problem.Field = typedField(s.field, s.typed, problem.GetField())
+		if s.parsed != nil {
+			pos := s.position(problem.GetField())
+			problem.Line, problem.Column = int32(pos.Line), int32(pos.Column)
+		}
+	}
+	for _, problem := range warnings {
+		problem.Field = typedField(s.field, s.typed, problem.GetField())
+		if s.parsed != nil {
+			pos := s.position(problem.GetField())
+			problem.Line, problem.Column = int32(pos.Line), int32(pos.Column)
+		}
+	}
+}
+
+// position returns where the schema text defines the relation a problem
+// field names, or the namespace for every other field
+func (s *namespaceSource) position(field string) schema.Position {
+	var i int
+	if _, err := fmt.Sscanf(strings.TrimPrefix(field, s.field), ".relations[%d]", &i); err == nil && i < len(s.parsed.Relations) {
+		return s.parsed.Relations[i].Position
+	}
+	return s.parsed.Position
+}
+
+// problemViolations converts validation errors to field violations
+func problemViolations(problems []*api.ValidationError) []*errdetails.BadRequest_FieldViolation {
+	violations := make([]*errdetails.BadRequest_FieldViolation, len(problems))
+	for i, problem := range problems {
+		message := problem.GetMessage()
+		if problem.GetPath() != "" {
+			message = problem.GetPath() + ": " + message
+		}
+		if problem.GetLine() != 0 {
+			message = fmt.Sprintf("line %d, column %d: %s", problem.GetLine(), problem.GetColumn(), message)
+		}
+		violations[i] = fieldViolation(problem.GetField(), "%s", message)
+	}
+	return violations
+}
+
+// namespaceFromProto converts an API namespace configuration to its stored
+// form
+// Timestamps and the version are assigned when the configuration is written
+func namespaceFromProto(config *api.NamespaceConfig) database.NamespaceConfig {
+	result := database.NamespaceConfig{
+		Name:      config.GetName(),
+		Relations: make([]database.RelationConfig, len(config.GetRelations())),
+	}
+	for i, rel := range config.GetRelations() {
+		result.Relations[i] = database.RelationConfig{
+			Name:          rel.GetName(),
+			RewriteRules:  rel.GetRewriteRules(),
+			AllowWildcard: rel.GetAllowWildcard(),
+		}
+	}
+	return result
+}
+
+// checkNamespace statically analyzes a namespace configuration, naming
+// fields relative to field
+// Errors make the configuration impossible to store; warnings point out
+// rules that likely do not do what was meant
+func checkNamespace(field string, config *api.NamespaceConfig) ([]*api.ValidationError, []*api.ValidationWarning) {
+	if config == nil {
+		return []*api.ValidationError{{Field: field, Message: "namespace configuration is required", Code: "MISSING_CONFIG"}}, nil
+	}
+
+	var (
+		errs     []*api.ValidationError
+		warnings []*api.ValidationWarning
+	)
+	if problem := nameProblem(config.GetName()); problem != "" {
+		errs = append(errs, &api.ValidationError{Field: field + ".name", Message: "namespace name " + problem, Code: "INVALID_NAME"})
+	}
+
+	relations := make([]rewrite.Relation, len(config.GetRelations()))
+	seen := make(map[string]bool, len(config.GetRelations()))
+	for i, rel := range config.GetRelations() {
+		relField := fmt.Sprintf("%s.relations[%d].name", field, i)
+		switch problem := nameProblem(rel.GetName()); {
+		case problem != "":
+			errs = append(errs, &api.ValidationError{Field: relField, Message: "relation name " + problem, Code: "INVALID_NAME"})
+		case seen[rel.GetName()]:
+			errs = append(errs, &api.ValidationError{Field: relField, Message: fmt.Sprintf("relation %s is defined more than once", rel.GetName()), Code: "DUPLICATE_RELATION"})
+		}
+		seen[rel.GetName()] = true
+
+		relations[i] = rewrite.Relation{Name: rel.GetName(), Rules: rel.GetRewriteRules()}
+	}
+
+	for _, problem := range rewrite.Analyze(relations) {
+		ruleField := fmt.Sprintf("%s.relations[%d].rewrite_rules", field, problem.Relation)
+		if problem.Severity == rewrite.SeverityError {
+			errs = append(errs, &api.ValidationError{Field: ruleField, Message: problem.Message, Code: problem.Code, Path: problem.Path})
+		} else {
+			warnings = append(warnings, &api.ValidationWarning{Field: ruleField, Message: problem.Message, Code: problem.Code, Path: problem.Path})
+		}
+	}
+
+	return errs, warnings
+}
+
+// nameProblem describes why a namespace or relation name is invalid, or
+// returns an empty string when it is valid
+func nameProblem(name string) string {
+	switch {
+	case name == "":
+		return "is required"
+	case strings.ContainsAny(name, reservedNameChars):
+		return "must not contain any of " + reservedNameChars
+	case strings.IndexFunc(name, unicode.IsSpace) >= 0:
+		return "must not contain whitespace"
+	}
+	return ""
+}
@@ -0,0 +1,65 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+)
+
+const (
+	// defaultPageSize is used when a request does not set a page size
+	defaultPageSize = 100
+
+	// maxPageSize caps the page size a request may ask for
+	maxPageSize = 1000
+)
+
+// pageToken is the decoded form of the opaque page tokens handed to clients
+type pageToken struct {
+	// After is the sort key of the last item returned on the previous page
+	After string `json:"after"`
+
+	// ReadTs pins later pages to the Dgraph snapshot the first page was read
+	// at, for listings that read stored data directly
+	ReadTs uint64 `json:"read_ts,omitempty"`
+}
+
+// encodePageToken returns the opaque string form of the token
+func encodePageToken(token pageToken) string {
+	data, err := json.Marshal(token)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodePageToken parses an opaque page token
+// An empty string decodes to the zero token, which starts at the first page
+func decodePageToken(value string) (pageToken, error) {
+	var token pageToken
+	if value == "" {
+		return token, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return token, fmt.Errorf("invalid page token")
+	}
+
+	if err := json.Unmarshal(data, &token); err != nil {
+		return token, fmt.Errorf("invalid page token")
+	}
+
+	return token, nil
+}
+
+// pageSize normalizes a requested page size
+func pageSize(requested int32) int {
+	switch {
+	case requested <= 0:
+		return defaultPageSize
+	case requested > maxPageSize:
+		return maxPageSize
+	}
+	return int(requested)
+}
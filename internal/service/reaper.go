@@ -0,0 +1,75 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/DangVTNhan/goacl/internal/database"
+)
+
+// reapBatchSize bounds how many expired tuples are deleted per request
+const reapBatchSize = 500
+
+// ExpiredTupleDeleter deletes relation tuples past their expiry
+type ExpiredTupleDeleter interface {
+	DeleteExpiredTuples(ctx context.Context, now time.Time, limit int) ([]database.RelationTuple, error)
+}
+
+// TupleReaper periodically deletes expired relation tuples
+// Reads already ignore expired tuples, so the reaper only reclaims storage
+type TupleReaper struct {
+	store    ExpiredTupleDeleter
+	interval time.Duration
+}
+
+// NewTupleReaper creates a reaper sweeping the store at the given interval
+func NewTupleReaper(store ExpiredTupleDeleter, interval time.Duration) *TupleReaper {
+	return &TupleReaper{store: store, interval: interval}
+}
+
+// Run sweeps expired tuples until the context is cancelled
+// A non-positive interval disables the reaper
+func (r *TupleReaper) Run(ctx context.Context) {
+	if r.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			deleted, err := r.Sweep(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Printf("Warning: failed to delete expired tuples: %v", err)
+				}
+				continue
+			}
+			if deleted > 0 {
+				log.Printf("Deleted %d expired relation tuples", deleted)
+			}
+		}
+	}
+}
+
+// Sweep deletes every tuple that has expired and returns how many were deleted
+func (r *TupleReaper) Sweep(ctx context.Context) (int, error) {
+	now := time.Now()
+	total := 0
+
+	for {
+		deleted, err := r.store.DeleteExpiredTuples(ctx, now, reapBatchSize)
+		total += len(deleted)
+		if err != nil {
+			return total, err
+		}
+		if len(deleted) < reapBatchSize {
+			return total, nil
+		}
+	}
+}
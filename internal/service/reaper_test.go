@@ -0,0 +1,37 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DangVTNhan/goacl/internal/database"
+)
+
+// fakeDeleter hands out a fixed number of expired tuples in batches
+type fakeDeleter struct {
+	remaining int
+	calls     int
+}
+
+func (d *fakeDeleter) DeleteExpiredTuples(_ context.Context, _ time.Time, limit int) ([]database.RelationTuple, error) {
+	d.calls++
+	n := min(limit, d.remaining)
+	d.remaining -= n
+	return make([]database.RelationTuple, n), nil
+}
+
+func TestTupleReaperSweep(t *testing.T) {
+	deleter := &fakeDeleter{remaining: 2*reapBatchSize + 3}
+
+	deleted, err := NewTupleReaper(deleter, time.Minute).Sweep(context.Background())
+	if err != nil {
+		t.Fatalf("Sweep returned error: %v", err)
+	}
+	if deleted != 2*reapBatchSize+3 {
+		t.Errorf("Expected %d deleted tuples, got %d", 2*reapBatchSize+3, deleted)
+	}
+	if deleter.calls != 3 {
+		t.Errorf("Expected 3 delete batches, got %d", deleter.calls)
+	}
+}
@@ -0,0 +1,316 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/DangVTNhan/goacl/api"
+	"github.com/DangVTNhan/goacl/internal/database"
+)
+
+// ReconcileOptions controls how declared namespaces are applied
+type ReconcileOptions struct {
+	// Prune deletes stored namespaces that are not declared; namespaces that
+	// still have tuples are kept
+	Prune bool
+
+	// DryRun plans the changes without applying them
+	DryRun bool
+
+	// AllowBreakingChanges applies updates that can take access away
+	AllowBreakingChanges bool
+
+	// Author is recorded in the revisions the changes create
+	Author string
+}
+
+// ReconcileAction is what reconciling does to a namespace
+type ReconcileAction string
+
+const (
+	// ReconcileCreate creates a declared namespace that is not stored
+	ReconcileCreate ReconcileAction = "create"
+
+	// ReconcileUpdate replaces a stored namespace that differs from its declaration
+	ReconcileUpdate ReconcileAction = "update"
+
+	// ReconcilePrune deletes a stored namespace that is not declared
+	ReconcilePrune ReconcileAction = "prune"
+)
+
+// NamespaceChange is the change reconciling makes to one namespace
+type NamespaceChange struct {
+	Namespace string
+	Action    ReconcileAction
+
+	// Relations lists the relations added, removed or modified
+	Relations []*api.RelationDiff
+
+	// Breaking lists why the change can take access away
+	Breaking []*api.ValidationError
+
+	field  string
+	config *api.NamespaceConfig
+	stored *database.NamespaceConfig
+}
+
+// ReconcilePlan lists the changes that make the stored namespaces match the
+// declared ones
+type ReconcilePlan struct {
+	Changes []NamespaceChange
+
+	// Unchanged names the declared namespaces that are already stored as declared
+	Unchanged []string
+}
+
+// Breaking returns the changes that can take access away
+func (p *ReconcilePlan) Breaking() []NamespaceChange {
+	var breaking []NamespaceChange
+	for _, change := range p.Changes {
+		if len(change.Breaking) > 0 {
+			breaking = append(breaking, change)
+		}
+	}
+	return breaking
+}
+
+// String describes the plan as a diff, one line per namespace and relation
+func (p *ReconcilePlan) String() string {
+	if len(p.Changes) == 0 {
+		return fmt.Sprintf("%d namespaces up to date", len(p.Unchanged))
+	}
+
+	var b strings.Builder
+	for _, change := range p.Changes {
+		switch change.Action {
+		case ReconcileCreate:
+			fmt.Fprintf(&b, "+ namespace %s\n", change.Namespace)
+		case ReconcileUpdate:
+			fmt.Fprintf(&b, "~ namespace %s\n", change.Namespace)
+		case ReconcilePrune:
+			fmt.Fprintf(&b, "- namespace %s\n", change.Namespace)
+		}
+
+		for _, diff := range change.Relations {
+			switch diff.GetType() {
+			case api.RelationDiff_CHANGE_TYPE_ADDED:
+				fmt.Fprintf(&b, "    + %s: %s\n", diff.GetRelation(), describeRelation(diff.GetTo()))
+			case api.RelationDiff_CHANGE_TYPE_REMOVED:
+				fmt.Fprintf(&b, "    - %s: %s\n", diff.GetRelation(), describeRelation(diff.GetFrom()))
+			case api.RelationDiff_CHANGE_TYPE_MODIFIED:
+				fmt.Fprintf(&b, "    ~ %s: %s -> %s\n", diff.GetRelation(), describeRelation(diff.GetFrom()), describeRelation(diff.GetTo()))
+			}
+		}
+		for _, problem := range change.Breaking {
+			fmt.Fprintf(&b, "    ! %s: %s\n", problem.GetCode(), problem.GetMessage())
+		}
+	}
+	fmt.Fprintf(&b, "%d namespaces unchanged", len(p.Unchanged))
+
+	return b.String()
+}
+
+// describeRelation returns the normalized rewrite rules of a relation,
+// marking relations that allow wildcards
+func describeRelation(rel *api.RelationConfig) string {
+	description := normalizedRules(rel.GetRewriteRules())
+	if rel.GetAllowWildcard() {
+		description += " (wildcards allowed)"
+	}
+	return description
+}
+
+// Reconcile makes the stored namespace configurations match the declared
+// ones, creating and updating namespaces, and deleting undeclared ones when
+// pruning
+// The plan is returned even when it is not applied; breaking changes keep
+// the whole plan from being applied unless they are allowed
+// A namespace that another writer changes while reconciling is skipped
+func (s *ConfigurationService) Reconcile(ctx context.Context, declared []*api.NamespaceConfig, opts ReconcileOptions) (*ReconcilePlan, error) {
+	configs, err := checkDeclared(declared)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := s.storedNamespaces(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &ReconcilePlan{}
+	for i, config := range configs {
+		current, ok := stored[config.GetName()]
+		delete(stored, config.GetName())
+		if !ok {
+			plan.Changes = append(plan.Changes, NamespaceChange{
+				Namespace: config.GetName(),
+				Action:    ReconcileCreate,
+				Relations: diffRelations(nil, namespaceFromProto(config).Relations),
+				config:    config,
+			})
+			continue
+		}
+
+		diff := diffRelations(current.Relations, namespaceFromProto(config).Relations)
+		if len(diff) == 0 {
+			plan.Unchanged = append(plan.Unchanged, config.GetName())
+			continue
+		}
+
+		tuples, err := s.store.CountNamespaceTuples(ctx, config.GetName())
+		if err != nil {
+			return nil, err
+		}
+		field := fmt.Sprintf("namespaces[%d]", i)
+		breaking, _ := breakingChanges(field, current, config, tuples)
+		plan.Changes = append(plan.Changes, NamespaceChange{
+			Namespace: config.GetName(),
+			Action:    ReconcileUpdate,
+			Relations: diff,
+			Breaking:  breaking,
+			field:     field,
+			config:    config,
+			stored:    &current,
+		})
+	}
+
+	if opts.Prune {
+		names := make([]string, 0, len(stored))
+		for name := range stored {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			tuples, err := s.store.CountNamespaceTuples(ctx, name)
+			if err != nil {
+				return nil, err
+			}
+			if count := totalTuples(tuples); count > 0 {
+				log.Printf("Warning: namespace %s is not declared but still has %d tuples, not pruning it", name, count)
+				continue
+			}
+
+			current := stored[name]
+			plan.Changes = append(plan.Changes, NamespaceChange{
+				Namespace: current.Name,
+				Action:    ReconcilePrune,
+				Relations: diffRelations(current.Relations, nil),
+				stored:    &current,
+			})
+		}
+	}
+
+	if opts.DryRun {
+		return plan, nil
+	}
+	if breaking := plan.Breaking(); len(breaking) > 0 && !opts.AllowBreakingChanges {
+		names := make([]string, len(breaking))
+		for i, change := range breaking {
+			names[i] = change.Namespace
+		}
+		return plan, fmt.Errorf("updates of namespaces %s have breaking changes; allow breaking changes to apply them", strings.Join(names, ", "))
+	}
+
+	for _, change := range plan.Changes {
+		if err := s.applyChange(ctx, change, opts); err != nil {
+			if errors.Is(err, database.ErrAlreadyExists) || errors.Is(err, database.ErrVersionMismatch) {
+				log.Printf("Warning: namespace %s changed while reconciling, skipping: %v", change.Namespace, err)
+				continue
+			}
+			return plan, fmt.Errorf("failed to %s namespace %s: %w", change.Action, change.Namespace, err)
+		}
+	}
+
+	return plan, nil
+}
+
+// applyChange writes or deletes the namespace of a planned change
+// Updates are checked for breaking changes again as they are written, in
+// case tuples were written since planning
+func (s *ConfigurationService) applyChange(ctx context.Context, change NamespaceChange, opts ReconcileOptions) error {
+	switch change.Action {
+	case ReconcileCreate:
+		_, _, err := s.store.WriteNamespaceConfig(ctx, database.NamespaceWrite{
+			Config: namespaceFromProto(change.config),
+			Author: opts.Author,
+		})
+		return err
+	case ReconcileUpdate:
+		write := database.NamespaceWrite{
+			Config:          namespaceFromProto(change.config),
+			AllowUpdate:     true,
+			ExpectedVersion: change.stored.Version,
+			Author:          opts.Author,
+		}
+		if !opts.AllowBreakingChanges {
+			write.Check = breakingCheck(change.field, change.config)
+		}
+		_, _, err := s.store.WriteNamespaceConfig(ctx, write)
+		return err
+	case ReconcilePrune:
+		_, err := s.store.DeleteNamespaceConfig(ctx, change.Namespace, false, change.stored.Version)
+		return err
+	}
+	return fmt.Errorf("unknown reconcile action %q", change.Action)
+}
+
+// checkDeclared validates declared namespace configurations as WriteNamespace
+// would, returning them with typed rewrite trees converted to JSON
+func checkDeclared(declared []*api.NamespaceConfig) ([]*api.NamespaceConfig, error) {
+	configs := make([]*api.NamespaceConfig, len(declared))
+	seen := make(map[string]bool, len(declared))
+	for i, config := range declared {
+		field := fmt.Sprintf("namespaces[%d]", i)
+		converted, typed, problem := typedRewrites(field, config)
+		if problem != nil {
+			return nil, fmt.Errorf("namespace %s: %s: %s", config.GetName(), problem.GetField(), problem.GetMessage())
+		}
+
+		source := &namespaceSource{field: field, config: converted, typed: typed}
+		if errs, _ := source.check(); len(errs) > 0 {
+			violation := problemViolations(errs)[0]
+			return nil, fmt.Errorf("namespace %s: %s: %s", config.GetName(), violation.GetField(), violation.GetDescription())
+		}
+		if seen[converted.GetName()] {
+			return nil, fmt.Errorf("namespace %s is declared more than once", converted.GetName())
+		}
+		seen[converted.GetName()] = true
+		configs[i] = converted
+	}
+	return configs, nil
+}
+
+// totalTuples sums tuple counts per relation
+func totalTuples(tuples map[string]int) int {
+	total := 0
+	for _, count := range tuples {
+		total += count
+	}
+	return total
+}
+
+// storedNamespaces reads every stored namespace configuration by name
+func (s *ConfigurationService) storedNamespaces(ctx context.Context) (map[string]database.NamespaceConfig, error) {
+	stored := make(map[string]database.NamespaceConfig)
+	var (
+		after  string
+		readTs uint64
+	)
+	for {
+		page, err := s.store.ReadNamespaceConfigs(ctx, after, maxPageSize, readTs)
+		if err != nil {
+			return nil, err
+		}
+		for _, config := range page.Configs {
+			stored[config.Name] = config
+		}
+		if page.After == "" {
+			return stored, nil
+		}
+		after, readTs = page.After, page.ReadTs
+	}
+}
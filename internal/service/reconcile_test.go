@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/DangVTNhan/goacl/api"
+)
+
+// declaredNamespaces returns stored namespaces as a definition file would
+// declare them, with JSON rewrite rules only
+func declaredNamespaces(store *namespaceStore, names ...string) []*api.NamespaceConfig {
+	configs := make([]*api.NamespaceConfig, len(names))
+	for i, name := range names {
+		configs[i] = namespaceToProto(*store.namespaces[name])
+		for _, rel := range configs[i].Relations {
+			rel.Rewrite = nil
+		}
+	}
+	return configs
+}
+
+func TestReconcileUnchanged(t *testing.T) {
+	store := &namespaceStore{memoryReader: newMemoryReader(), readTs: 10}
+	service := NewConfigurationService(store)
+
+	declared := declaredNamespaces(store, "documents", "folders", "groups", "organizations")
+	plan, err := service.Reconcile(context.Background(), declared, ReconcileOptions{Prune: true})
+	if err != nil {
+		t.Fatalf("Reconcile returned error: %v", err)
+	}
+	if len(plan.Changes) != 0 || len(plan.Unchanged) != 4 || len(store.writes) != 0 || len(store.deletions) != 0 {
+		t.Errorf("Expected nothing to change, got %s", plan)
+	}
+}
+
+func TestReconcile(t *testing.T) {
+	store := &namespaceStore{memoryReader: newMemoryReader(), readTs: 10}
+	store.add("groups", "eng", "member", "alice")
+	service := NewConfigurationService(store)
+
+	// documents viewers no longer include editors, teams is new, and
+	// neither groups, which has tuples, nor organizations is declared
+	declared := declaredNamespaces(store, "documents", "folders")
+	declared[0].Relations[2] = &api.RelationConfig{Name: "viewer", Rewrite: &api.Rewrite{Rewrite: &api.Rewrite_This{This: &api.RewriteThis{}}}, AllowWildcard: true}
+	declared = append(declared, &api.NamespaceConfig{
+		Name:      "teams",
+		Relations: []*api.RelationConfig{{Name: "member"}},
+	})
+
+	opts := ReconcileOptions{Prune: true, DryRun: true, Author: "bootstrap"}
+	plan, err := service.Reconcile(context.Background(), declared, opts)
+	if err != nil {
+		t.Fatalf("Reconcile returned error: %v", err)
+	}
+	if len(store.writes) != 0 || len(store.deletions) != 0 {
+		t.Fatalf("Expected a dry run to change nothing, got %d writes and %d deletions", len(store.writes), len(store.deletions))
+	}
+	diff := plan.String()
+	for _, line := range []string{"~ namespace documents", "    ~ viewer: ", "    ! ACCESS_NARROWED: ", "+ namespace teams", "    + member: ", "- namespace organizations", "1 namespaces unchanged"} {
+		if !strings.Contains(diff, line) {
+			t.Errorf("Expected the plan to contain %q, got\n%s", line, diff)
+		}
+	}
+	if strings.Contains(diff, "namespace groups") {
+		t.Errorf("Expected groups, which has tuples, not to be pruned, got\n%s", diff)
+	}
+
+	// Breaking changes block the whole plan unless they are allowed
+	opts.DryRun = false
+	if _, err := service.Reconcile(context.Background(), declared, opts); err == nil || !strings.Contains(err.Error(), "breaking changes") {
+		t.Fatalf("Expected breaking changes to fail, got %v", err)
+	}
+	if len(store.writes) != 0 || len(store.deletions) != 0 {
+		t.Fatalf("Expected a blocked plan to change nothing, got %d writes and %d deletions", len(store.writes), len(store.deletions))
+	}
+
+	opts.AllowBreakingChanges = true
+	if _, err := service.Reconcile(context.Background(), declared, opts); err != nil {
+		t.Fatalf("Reconcile returned error: %v", err)
+	}
+	if len(store.writes) != 2 || len(store.deletions) != 1 || store.deletions[0] != "organizations" {
+		t.Fatalf("Expected 2 writes and organizations deleted, got %v and %v", store.writes, store.deletions)
+	}
+	update, create := store.writes[0], store.writes[1]
+	if update.Config.Name != "documents" || !update.AllowUpdate || update.Config.Relations[2].RewriteRules != `{"_this":{}}` || update.Author != "bootstrap" {
+		t.Errorf("Unexpected update %+v", update)
+	}
+	if create.Config.Name != "teams" || create.AllowUpdate {
+		t.Errorf("Unexpected create %+v", create)
+	}
+}
+
+func TestReconcileInvalidDeclaration(t *testing.T) {
+	store := &namespaceStore{memoryReader: newMemoryReader(), readTs: 10}
+	service := NewConfigurationService(store)
+
+	_, err := service.Reconcile(context.Background(), []*api.NamespaceConfig{{
+		Name:      "teams",
+		Relations: []*api.RelationConfig{{Name: "lead", RewriteRules: `{"computed_userset": {"relation": "member"}}`}},
+	}}, ReconcileOptions{})
+	if err == nil || !strings.Contains(err.Error(), "namespaces[0].relations[0].rewrite_rules") {
+		t.Errorf("Expected the undefined relation to be reported, got %v", err)
+	}
+	if len(store.writes) != 0 {
+		t.Errorf("Expected nothing written, got %v", store.writes)
+	}
+}
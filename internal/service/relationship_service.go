@@ -0,0 +1,499 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/DangVTNhan/goacl/api"
+	"github.com/DangVTNhan/goacl/internal/database"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+const (
+	// maxWriteTuples caps the number of tuples accepted in a single write
+	maxWriteTuples = 1000
+
+	// watchBatchSize is the number of changes read from the change log at a time
+	watchBatchSize = 100
+
+	// watchBlock is how long a watch waits for new changes before checking again
+	watchBlock = 5 * time.Second
+)
+
+// TupleStore reads relation tuples and starts transactions that change them
+// Writes are checked against the namespace configurations it reads
+type TupleStore interface {
+	NamespaceReader
+	NewTupleTxn() *database.TupleTxn
+	ReadTuples(ctx context.Context, filter database.TupleFilter, after string, limit int, readTs uint64) (*database.TuplePage, error)
+	ImportTuples(ctx context.Context, tuples []database.RelationTuple) (*database.ImportResult, error)
+	ListNamespaceConfigsAt(ctx context.Context, readTs uint64) ([]database.NamespaceConfig, error)
+}
+
+// ChangeLog reads the ordered log of changes made to relation tuples
+type ChangeLog interface {
+	ChangeLogHead(ctx context.Context) (string, error)
+	ChangeLogPositionAt(ctx context.Context, commitTs uint64) (string, error)
+	ReadChanges(ctx context.Context, after string, count int64, block time.Duration) ([]database.Change, error)
+}
+
+// RelationshipService manages the stored relation tuples
+type RelationshipService struct {
+	store   TupleStore
+	changes ChangeLog
+}
+
+// NewRelationshipService creates a new relationship service
+func NewRelationshipService(store TupleStore, changes ChangeLog) *RelationshipService {
+	return &RelationshipService{
+		store:   store,
+		changes: changes,
+	}
+}
+
+// WriteRelation checks the preconditions and writes the tuples in a single
+// transaction, so either every tuple is written or none is
+func (s *RelationshipService) WriteRelation(ctx context.Context, req *api.WriteRelationRequest) (*api.WriteRelationResponse, error) {
+	tuples, preconditions, err := parseWriteRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	validator := newSchemaValidator(s.store)
+	for i, tuple := range tuples {
+		if err := validator.validate(ctx, fmt.Sprintf("tuples[%d]", i), tuple); err != nil {
+			return nil, err
+		}
+	}
+	if err := validator.err(); err != nil {
+		return nil, err
+	}
+
+	txn := s.store.NewTupleTxn()
+	defer txn.Discard(ctx)
+
+	written, err := writeTuples(ctx, txn, tuples, preconditions)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	commitTs, err := txn.Commit(ctx)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &api.WriteRelationResponse{
+		ConsistencyToken: database.EncodeConsistencyToken(commitTs),
+		WrittenAt:        timestamppb.Now(),
+		TuplesWritten:    written,
+	}, nil
+}
+
+// DeleteRelation checks the preconditions and deletes the tuples matching
+// the filter in a single transaction
+// Unless allow_multiple is set, a filter matching more than one tuple is
+// refused and nothing is deleted
+func (s *RelationshipService) DeleteRelation(ctx context.Context, req *api.DeleteRelationRequest) (*api.DeleteRelationResponse, error) {
+	filter, preconditions, err := parseDeleteRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	txn := s.store.NewTupleTxn()
+	defer txn.Discard(ctx)
+
+	deleted, err := deleteTuples(ctx, txn, filter, preconditions, req.GetAllowMultiple())
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	commitTs, err := txn.Commit(ctx)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &api.DeleteRelationResponse{
+		ConsistencyToken: database.EncodeConsistencyToken(commitTs),
+		DeletedAt:        timestamppb.Now(),
+		TuplesDeleted:    deleted,
+	}, nil
+}
+
+// BatchWrite applies a mix of writes and deletes in a single transaction
+// Each operation's preconditions are checked after the operations before it
+// are applied, and if any operation fails nothing is applied: the response
+// then has no consistency token, and its results identify the failing operation
+func (s *RelationshipService) BatchWrite(ctx context.Context, req *api.BatchWriteRequest) (*api.BatchWriteResponse, error) {
+	operations, err := parseBatchWriteRequest(req)
+	if err != nil {
+		return nil, err
+	}
+
+	validator := newSchemaValidator(s.store)
+	for i, op := range operations {
+		for j, tuple := range op.tuples {
+			if err := validator.validate(ctx, fmt.Sprintf("operations[%d].write.tuples[%d]", i, j), tuple); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := validator.err(); err != nil {
+		return nil, err
+	}
+
+	txn := s.store.NewTupleTxn()
+	defer txn.Discard(ctx)
+
+	resp := &api.BatchWriteResponse{Results: make([]*api.WriteOperationResult, len(operations))}
+	for i, op := range operations {
+		var affected int32
+		if op.delete {
+			affected, err = deleteTuples(ctx, txn, op.filter, op.preconditions, op.allowMultiple)
+		} else {
+			affected, err = writeTuples(ctx, txn, op.tuples, op.preconditions)
+		}
+
+		if isOperationError(err) {
+			return rejectedBatch(len(operations), i, err), nil
+		}
+		if err != nil {
+			return nil, toStatusError(err)
+		}
+
+		resp.Results[i] = &api.WriteOperationResult{Success: true, TuplesAffected: affected}
+	}
+
+	commitTs, err := txn.Commit(ctx)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	resp.ConsistencyToken = database.EncodeConsistencyToken(commitTs)
+	resp.WrittenAt = timestamppb.Now()
+	return resp, nil
+}
+
+// rejectedBatch builds the response of a batch in which the operation at
+// index failed, leaving every operation unapplied
+func rejectedBatch(size, failed int, err error) *api.BatchWriteResponse {
+	resp := &api.BatchWriteResponse{Results: make([]*api.WriteOperationResult, size)}
+	for i := range resp.Results {
+		resp.Results[i] = &api.WriteOperationResult{
+			Error: fmt.Sprintf("not applied: operations[%d] failed", failed),
+		}
+	}
+	resp.Results[failed].Error = err.Error()
+	return resp
+}
+
+// writeTuples checks the preconditions and stages the writes of a write operation
+func writeTuples(ctx context.Context, txn *database.TupleTxn, tuples []database.RelationTuple, preconditions []database.Precondition) (int32, error) {
+	if err := checkPreconditions(ctx, txn, preconditions); err != nil {
+		return 0, err
+	}
+
+	var written int32
+	for _, tuple := range tuples {
+		changed, err := txn.Write(ctx, tuple)
+		if err != nil {
+			return 0, err
+		}
+		if changed {
+			written++
+		}
+	}
+	return written, nil
+}
+
+// deleteTuples checks the preconditions and stages the deletes of a delete operation
+func deleteTuples(ctx context.Context, txn *database.TupleTxn, filter database.TupleFilter, preconditions []database.Precondition, allowMultiple bool) (int32, error) {
+	if err := checkPreconditions(ctx, txn, preconditions); err != nil {
+		return 0, err
+	}
+
+	tuples, err := txn.Find(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+	if len(tuples) > 1 && !allowMultiple {
+		return 0, &multipleMatchError{count: len(tuples)}
+	}
+
+	var deleted int32
+	for _, tuple := range tuples {
+		changed, err := txn.Delete(ctx, tuple)
+		if err != nil {
+			return 0, err
+		}
+		if changed {
+			deleted++
+		}
+	}
+	return deleted, nil
+}
+
+// checkPreconditions verifies the preconditions of an operation in order
+func checkPreconditions(ctx context.Context, txn *database.TupleTxn, preconditions []database.Precondition) error {
+	for _, precondition := range preconditions {
+		if err := txn.Check(ctx, precondition); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ReadRelations returns the tuples matching the filter, one page at a time
+// Every page after the first is read from the snapshot of the first, so
+// concurrent writes neither skip nor duplicate tuples across pages
+func (s *RelationshipService) ReadRelations(ctx context.Context, req *api.ReadRelationsRequest) (*api.ReadRelationsResponse, error) {
+	filter, err := tupleFilterFromProto(req.GetFilter())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "filter: %v", err)
+	}
+	if req.GetPageSize() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "page_size must not be negative")
+	}
+
+	token, err := decodePageToken(req.GetPageToken())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	readTs := token.ReadTs
+	if readTs == 0 && req.GetConsistencyToken() != "" {
+		readTs, err = database.DecodeConsistencyToken(req.GetConsistencyToken())
+		if err != nil {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+	}
+
+	page, err := s.store.ReadTuples(ctx, filter, token.After, pageSize(req.GetPageSize()), readTs)
+	if errors.Is(err, database.ErrInvalidCursor) {
+		return nil, status.Error(codes.InvalidArgument, "invalid page token")
+	}
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	resp := &api.ReadRelationsResponse{
+		Tuples:           make([]*api.RelationTuple, 0, len(page.Tuples)),
+		ConsistencyToken: database.EncodeConsistencyToken(page.ReadTs),
+	}
+	now := time.Now()
+	for _, tuple := range page.Tuples {
+		if tuple.Expired(now) {
+			continue
+		}
+		resp.Tuples = append(resp.Tuples, tupleToProto(tuple))
+	}
+	if page.After != "" {
+		resp.NextPageToken = encodePageToken(pageToken{After: page.After, ReadTs: page.ReadTs})
+	}
+
+	return resp, nil
+}
+
+// WatchRelations streams the changes to tuples matching the filter
+// Without a consistency token only changes made after the watch starts are
+// streamed; with one, every change committed after it is streamed first
+// Passing the token of the last change received resumes a watch without
+// losing or repeating changes
+func (s *RelationshipService) WatchRelations(req *api.WatchRelationsRequest, stream grpc.ServerStreamingServer[api.WatchRelationsResponse]) error {
+	filter, err := tupleFilterFromProto(req.GetFilter())
+	if err != nil {
+		return status.Errorf(codes.InvalidArgument, "filter: %v", err)
+	}
+
+	ctx := stream.Context()
+	position, afterTs, err := s.watchStart(ctx, req.GetConsistencyToken())
+	if err != nil {
+		return err
+	}
+
+	for {
+		changes, err := s.changes.ReadChanges(ctx, position, watchBatchSize, watchBlock)
+		if ctx.Err() != nil {
+			return toStatusError(ctx.Err())
+		}
+		if errors.Is(err, database.ErrChangesExpired) {
+			return status.Error(codes.OutOfRange, "changes after the consistency token are no longer retained; read the current tuples and watch from the returned token")
+		}
+		if err != nil {
+			return toStatusError(err)
+		}
+
+		for _, change := range changes {
+			position = change.Position
+			if change.CommitTs <= afterTs || !filter.Matches(change.Tuple) {
+				continue
+			}
+
+			if err := stream.Send(changeToProto(change)); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// watchStart returns the change log position a watch starts reading after,
+// and the commit timestamp changes must be newer than to be streamed
+func (s *RelationshipService) watchStart(ctx context.Context, token string) (string, uint64, error) {
+	if token == "" {
+		position, err := s.changes.ChangeLogHead(ctx)
+		if err != nil {
+			return "", 0, toStatusError(err)
+		}
+		return position, 0, nil
+	}
+
+	ts, position, err := database.DecodeChangeToken(token)
+	if err != nil {
+		return "", 0, status.Error(codes.InvalidArgument, err.Error())
+	}
+	if position != "" {
+		return position, 0, nil
+	}
+
+	// Changes relayed after the position may have committed at or before the
+	// token, so those are skipped as they are read
+	position, err = s.changes.ChangeLogPositionAt(ctx, ts)
+	if err != nil {
+		return "", 0, toStatusError(err)
+	}
+	return position, ts, nil
+}
+
+// parseWriteRequest validates a write request and converts its tuples and
+// preconditions to their database form
+func parseWriteRequest(req *api.WriteRelationRequest) ([]database.RelationTuple, []database.Precondition, error) {
+	if len(req.GetTuples()) == 0 {
+		return nil, nil, status.Error(codes.InvalidArgument, "tuples must not be empty")
+	}
+	if len(req.GetTuples()) > maxWriteTuples {
+		return nil, nil, status.Errorf(codes.InvalidArgument, "at most %d tuples may be written at once, got %d", maxWriteTuples, len(req.GetTuples()))
+	}
+	if err := validateConsistencyToken(req.GetConsistencyToken()); err != nil {
+		return nil, nil, err
+	}
+
+	tuples := make([]database.RelationTuple, len(req.GetTuples()))
+	for i, tuple := range req.GetTuples() {
+		converted, err := tupleFromProto(tuple)
+		if err != nil {
+			return nil, nil, status.Errorf(codes.InvalidArgument, "tuples[%d]: %v", i, err)
+		}
+		tuples[i] = converted
+	}
+
+	preconditions, err := parsePreconditions(req.GetPreconditions())
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return tuples, preconditions, nil
+}
+
+// parseDeleteRequest validates a delete request and converts its filter and
+// preconditions to their database form
+func parseDeleteRequest(req *api.DeleteRelationRequest) (database.TupleFilter, []database.Precondition, error) {
+	filter, err := tupleFilterFromProto(req.GetFilter())
+	if err != nil {
+		return filter, nil, status.Errorf(codes.InvalidArgument, "filter: %v", err)
+	}
+	if filter.IsEmpty() {
+		return filter, nil, status.Error(codes.InvalidArgument, "filter must set at least one field")
+	}
+	if err := validateConsistencyToken(req.GetConsistencyToken()); err != nil {
+		return filter, nil, err
+	}
+
+	preconditions, err := parsePreconditions(req.GetPreconditions())
+	if err != nil {
+		return filter, nil, err
+	}
+
+	return filter, preconditions, nil
+}
+
+// batchOperation is a validated operation of a batch write
+type batchOperation struct {
+	delete        bool
+	tuples        []database.RelationTuple
+	filter        database.TupleFilter
+	allowMultiple bool
+	preconditions []database.Precondition
+}
+
+// parseBatchWriteRequest validates a batch write request and converts its
+// operations to their database form
+func parseBatchWriteRequest(req *api.BatchWriteRequest) ([]batchOperation, error) {
+	if len(req.GetOperations()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "operations must not be empty")
+	}
+	if len(req.GetOperations()) > maxWriteTuples {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d operations may be applied at once, got %d", maxWriteTuples, len(req.GetOperations()))
+	}
+	if err := validateConsistencyToken(req.GetConsistencyToken()); err != nil {
+		return nil, err
+	}
+
+	operations := make([]batchOperation, len(req.GetOperations()))
+	var total int
+	for i, operation := range req.GetOperations() {
+		var (
+			op  batchOperation
+			err error
+		)
+		switch {
+		case operation.GetWrite() != nil:
+			op.tuples, op.preconditions, err = parseWriteRequest(operation.GetWrite())
+			total += len(op.tuples)
+		case operation.GetDelete() != nil:
+			op.delete = true
+			op.allowMultiple = operation.GetDelete().GetAllowMultiple()
+			op.filter, op.preconditions, err = parseDeleteRequest(operation.GetDelete())
+		default:
+			err = status.Error(codes.InvalidArgument, "one of write or delete is required")
+		}
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "operations[%d]: %s", i, status.Convert(err).Message())
+		}
+		operations[i] = op
+	}
+
+	if total > maxWriteTuples {
+		return nil, status.Errorf(codes.InvalidArgument, "at most %d tuples may be written at once, got %d", maxWriteTuples, total)
+	}
+
+	return operations, nil
+}
+
+// parsePreconditions validates preconditions and converts them to their database form
+func parsePreconditions(preconditions []*api.Precondition) ([]database.Precondition, error) {
+	result := make([]database.Precondition, len(preconditions))
+	for i, precondition := range preconditions {
+		converted, err := preconditionFromProto(precondition)
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "preconditions[%d]: %v", i, err)
+		}
+		result[i] = converted
+	}
+	return result, nil
+}
+
+// validateConsistencyToken rejects consistency tokens that cannot be decoded
+// Writes always apply to the latest data, so a valid token needs no further handling
+func validateConsistencyToken(token string) error {
+	if token == "" {
+		return nil
+	}
+	if _, err := database.DecodeConsistencyToken(token); err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	return nil
+}
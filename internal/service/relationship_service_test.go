@@ -0,0 +1,392 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DangVTNhan/goacl/api"
+	"github.com/DangVTNhan/goacl/internal/database"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+func TestParseWriteRequest(t *testing.T) {
+	valid := &api.RelationTuple{Namespace: "documents", ObjectId: "doc1", Relation: "viewer", UserId: "alice"}
+
+	tests := []struct {
+		name string
+		req  *api.WriteRelationRequest
+		ok   bool
+	}{
+		{"valid", &api.WriteRelationRequest{Tuples: []*api.RelationTuple{valid}}, true},
+		{"no tuples", &api.WriteRelationRequest{}, false},
+		{"missing subject", &api.WriteRelationRequest{Tuples: []*api.RelationTuple{{Namespace: "documents", ObjectId: "doc1", Relation: "viewer"}}}, false},
+		{"both subjects", &api.WriteRelationRequest{Tuples: []*api.RelationTuple{{Namespace: "documents", ObjectId: "doc1", Relation: "viewer", UserId: "alice", Userset: "groups:eng#member"}}}, false},
+		{"invalid userset", &api.WriteRelationRequest{Tuples: []*api.RelationTuple{{Namespace: "documents", ObjectId: "doc1", Relation: "viewer", Userset: "eng"}}}, false},
+		{"invalid condition", &api.WriteRelationRequest{Tuples: []*api.RelationTuple{{Namespace: "documents", ObjectId: "doc1", Relation: "viewer", UserId: "alice", Condition: "request.ip in"}}}, false},
+		{"invalid token", &api.WriteRelationRequest{Tuples: []*api.RelationTuple{valid}, ConsistencyToken: "bogus"}, false},
+		{"unspecified precondition", &api.WriteRelationRequest{
+			Tuples:        []*api.RelationTuple{valid},
+			Preconditions: []*api.Precondition{{Tuple: valid}},
+		}, false},
+		{"precondition", &api.WriteRelationRequest{
+			Tuples:        []*api.RelationTuple{valid},
+			Preconditions: []*api.Precondition{{Type: api.PreconditionType_PRECONDITION_TYPE_MUST_NOT_EXIST, Tuple: valid}},
+		}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := parseWriteRequest(tt.req)
+			if tt.ok && err != nil {
+				t.Fatalf("Expected request to be valid, got %v", err)
+			}
+			if !tt.ok && status.Code(err) != codes.InvalidArgument {
+				t.Fatalf("Expected InvalidArgument, got %v", err)
+			}
+		})
+	}
+}
+
+func TestTupleFromProtoExpiry(t *testing.T) {
+	tuple, err := tupleFromProto(&api.RelationTuple{
+		Namespace: "documents",
+		ObjectId:  "doc1",
+		Relation:  "viewer",
+		UserId:    "alice",
+		ExpiresAt: &timestamppb.Timestamp{Seconds: 1798675200},
+	})
+	if err != nil {
+		t.Fatalf("tupleFromProto returned error: %v", err)
+	}
+	if tuple.ExpiresAt != "2026-12-31T00:00:00Z" {
+		t.Errorf("Expected expiry 2026-12-31T00:00:00Z, got %s", tuple.ExpiresAt)
+	}
+}
+
+func TestPreconditionStatusError(t *testing.T) {
+	err := toStatusError(&database.PreconditionError{Precondition: database.Precondition{
+		Tuple:     database.RelationTuple{Namespace: "documents", ObjectID: "doc1", Relation: "owner", UserID: "alice"},
+		MustExist: true,
+	}})
+
+	st := status.Convert(err)
+	if st.Code() != codes.FailedPrecondition {
+		t.Fatalf("Expected FailedPrecondition, got %v", st.Code())
+	}
+
+	var failure *errdetails.PreconditionFailure
+	for _, detail := range st.Details() {
+		if f, ok := detail.(*errdetails.PreconditionFailure); ok {
+			failure = f
+		}
+	}
+	if failure == nil || len(failure.GetViolations()) != 1 {
+		t.Fatalf("Expected one precondition violation, got %v", st.Details())
+	}
+	if v := failure.GetViolations()[0]; v.GetType() != "MUST_EXIST" || v.GetSubject() != "documents:doc1#owner@alice" {
+		t.Errorf("Unexpected violation %v", v)
+	}
+}
+
+func TestParseDeleteRequest(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *api.DeleteRelationRequest
+		ok   bool
+	}{
+		{"object filter", &api.DeleteRelationRequest{Filter: &api.RelationFilter{Namespace: "documents", ObjectId: "doc1"}}, true},
+		{"userset filter", &api.DeleteRelationRequest{Filter: &api.RelationFilter{Userset: "groups:eng#member"}}, true},
+		{"no filter", &api.DeleteRelationRequest{}, false},
+		{"empty filter", &api.DeleteRelationRequest{Filter: &api.RelationFilter{}}, false},
+		{"both subjects", &api.DeleteRelationRequest{Filter: &api.RelationFilter{UserId: "alice", Userset: "groups:eng#member"}}, false},
+		{"invalid userset", &api.DeleteRelationRequest{Filter: &api.RelationFilter{Userset: "eng"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, err := parseDeleteRequest(tt.req)
+			if tt.ok && err != nil {
+				t.Fatalf("Expected request to be valid, got %v", err)
+			}
+			if !tt.ok && status.Code(err) != codes.InvalidArgument {
+				t.Fatalf("Expected InvalidArgument, got %v", err)
+			}
+		})
+	}
+}
+
+// pagedStore serves tuples from memory in the pages a TupleStore would
+type pagedStore struct {
+	tuples     []database.RelationTuple
+	namespaces []database.NamespaceConfig
+	readTs     uint64
+
+	// requestedTs records the read timestamp of each page requested
+	requestedTs []uint64
+}
+
+func (s *pagedStore) GetNamespaceConfig(_ context.Context, name string) (*database.NamespaceConfig, error) {
+	return nil, fmt.Errorf("namespace %s %w", name, database.ErrNotFound)
+}
+
+func (s *pagedStore) ListNamespaceConfigsAt(_ context.Context, readTs uint64) ([]database.NamespaceConfig, error) {
+	if readTs != s.readTs {
+		return nil, fmt.Errorf("namespaces read at %d instead of %d", readTs, s.readTs)
+	}
+	return s.namespaces, nil
+}
+
+func (s *pagedStore) NewTupleTxn() *database.TupleTxn {
+	return nil
+}
+
+func (s *pagedStore) ImportTuples(context.Context, []database.RelationTuple) (*database.ImportResult, error) {
+	return nil, errors.New("not supported")
+}
+
+func (s *pagedStore) ReadTuples(_ context.Context, filter database.TupleFilter, after string, limit int, readTs uint64) (*database.TuplePage, error) {
+	s.requestedTs = append(s.requestedTs, readTs)
+	if readTs == 0 {
+		readTs = s.readTs
+	}
+
+	page := &database.TuplePage{ReadTs: readTs}
+	for _, tuple := range s.tuples {
+		if tuple.UID <= after || !filter.Matches(tuple) {
+			continue
+		}
+		if len(page.Tuples) == limit {
+			page.After = page.Tuples[limit-1].UID
+			break
+		}
+		page.Tuples = append(page.Tuples, tuple)
+	}
+	return page, nil
+}
+
+func TestReadRelationsPagination(t *testing.T) {
+	store := &pagedStore{readTs: 42}
+	for i, user := range []string{"alice", "bob", "carol", "dave", "erin"} {
+		store.tuples = append(store.tuples, database.RelationTuple{
+			UID:       fmt.Sprintf("0x%d", i+1),
+			Namespace: "documents",
+			ObjectID:  "doc1",
+			Relation:  "viewer",
+			UserID:    user,
+		})
+	}
+	store.tuples[2].ExpiresAt = "2000-01-01T00:00:00Z"
+	svc := NewRelationshipService(store, nil)
+
+	var (
+		users []string
+		token string
+		pages int
+	)
+	for {
+		resp, err := svc.ReadRelations(context.Background(), &api.ReadRelationsRequest{
+			Filter:    &api.RelationFilter{Namespace: "documents"},
+			PageSize:  2,
+			PageToken: token,
+		})
+		if err != nil {
+			t.Fatalf("ReadRelations returned error: %v", err)
+		}
+		pages++
+		for _, tuple := range resp.GetTuples() {
+			users = append(users, tuple.GetUserId())
+		}
+		if resp.GetConsistencyToken() != database.EncodeConsistencyToken(42) {
+			t.Errorf("Expected every page to report the snapshot of the first")
+		}
+
+		token = resp.GetNextPageToken()
+		if token == "" {
+			break
+		}
+	}
+
+	if pages != 3 {
+		t.Errorf("Expected 3 pages, got %d", pages)
+	}
+	if got := strings.Join(users, ","); got != "alice,bob,dave,erin" {
+		t.Errorf("Expected live tuples in order, got %s", got)
+	}
+	if fmt.Sprint(store.requestedTs) != "[0 42 42]" {
+		t.Errorf("Expected later pages to be pinned to the first read timestamp, got %v", store.requestedTs)
+	}
+}
+
+func TestReadRelationsInvalidPageToken(t *testing.T) {
+	svc := NewRelationshipService(&pagedStore{readTs: 1}, nil)
+	_, err := svc.ReadRelations(context.Background(), &api.ReadRelationsRequest{PageToken: "not a token"})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("Expected InvalidArgument, got %v", err)
+	}
+}
+
+// memoryChangeLog serves a fixed list of changes and cancels the watch once
+// they have all been read
+type memoryChangeLog struct {
+	changes []database.Change
+	expired bool
+	cancel  context.CancelFunc
+}
+
+func (l *memoryChangeLog) ChangeLogHead(context.Context) (string, error) {
+	return l.changes[len(l.changes)-1].Position, nil
+}
+
+func (l *memoryChangeLog) ChangeLogPositionAt(_ context.Context, commitTs uint64) (string, error) {
+	position := "0-0"
+	for _, change := range l.changes {
+		if change.CommitTs > commitTs {
+			break
+		}
+		position = change.Position
+	}
+	return position, nil
+}
+
+func (l *memoryChangeLog) ReadChanges(ctx context.Context, after string, count int64, _ time.Duration) ([]database.Change, error) {
+	if l.expired {
+		return nil, database.ErrChangesExpired
+	}
+
+	var changes []database.Change
+	for _, change := range l.changes {
+		if change.Position > after && int64(len(changes)) < count {
+			changes = append(changes, change)
+		}
+	}
+	if len(changes) == 0 {
+		l.cancel()
+		return nil, ctx.Err()
+	}
+	return changes, nil
+}
+
+// watchStream collects the responses sent to a watch
+type watchStream struct {
+	grpc.ServerStream
+	ctx  context.Context
+	sent []*api.WatchRelationsResponse
+}
+
+func (s *watchStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *watchStream) Send(resp *api.WatchRelationsResponse) error {
+	s.sent = append(s.sent, resp)
+	return nil
+}
+
+func TestWatchRelations(t *testing.T) {
+	change := func(position string, ts uint64, changeType database.ChangeType, object string) database.Change {
+		return database.Change{
+			Position: position,
+			Type:     changeType,
+			Tuple:    database.RelationTuple{Namespace: "documents", ObjectID: object, Relation: "viewer", UserID: "alice"},
+			CommitTs: ts,
+		}
+	}
+	changes := []database.Change{
+		change("1-0", 10, database.ChangeCreated, "doc1"),
+		change("2-0", 11, database.ChangeCreated, "doc2"),
+		change("3-0", 12, database.ChangeDeleted, "doc1"),
+		change("4-0", 13, database.ChangeUpdated, "doc2"),
+	}
+
+	watch := func(t *testing.T, req *api.WatchRelationsRequest) []*api.WatchRelationsResponse {
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+
+		svc := NewRelationshipService(nil, &memoryChangeLog{changes: changes, cancel: cancel})
+		stream := &watchStream{ctx: ctx}
+		if err := svc.WatchRelations(req, stream); status.Code(err) != codes.Canceled {
+			t.Fatalf("Expected the watch to end when cancelled, got %v", err)
+		}
+		return stream.sent
+	}
+
+	t.Run("from timestamp", func(t *testing.T) {
+		sent := watch(t, &api.WatchRelationsRequest{
+			Filter:           &api.RelationFilter{ObjectId: "doc1"},
+			ConsistencyToken: database.EncodeConsistencyToken(10),
+		})
+		if len(sent) != 1 || sent[0].GetChangeType() != api.ChangeType_CHANGE_TYPE_DELETED {
+			t.Fatalf("Expected only the deletion of doc1, got %v", sent)
+		}
+	})
+
+	t.Run("resume", func(t *testing.T) {
+		first := watch(t, &api.WatchRelationsRequest{ConsistencyToken: database.EncodeConsistencyToken(11)})
+		if len(first) != 2 {
+			t.Fatalf("Expected 2 changes after timestamp 11, got %d", len(first))
+		}
+
+		resumed := watch(t, &api.WatchRelationsRequest{ConsistencyToken: first[0].GetConsistencyToken()})
+		if len(resumed) != 1 || resumed[0].GetChangeType() != api.ChangeType_CHANGE_TYPE_UPDATED {
+			t.Fatalf("Expected to resume with the update of doc2, got %v", resumed)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		svc := NewRelationshipService(nil, &memoryChangeLog{changes: changes, expired: true})
+		err := svc.WatchRelations(&api.WatchRelationsRequest{ConsistencyToken: database.EncodeChangeToken(10, "1-0")}, &watchStream{ctx: context.Background()})
+		if status.Code(err) != codes.OutOfRange {
+			t.Fatalf("Expected OutOfRange, got %v", err)
+		}
+	})
+}
+
+func TestParseBatchWriteRequest(t *testing.T) {
+	write := &api.WriteOperation{Operation: &api.WriteOperation_Write{Write: &api.WriteRelationRequest{
+		Tuples: []*api.RelationTuple{{Namespace: "documents", ObjectId: "doc1", Relation: "parent", Userset: "folders:new#viewer"}},
+	}}}
+	del := &api.WriteOperation{Operation: &api.WriteOperation_Delete{Delete: &api.DeleteRelationRequest{
+		Filter: &api.RelationFilter{Namespace: "documents", ObjectId: "doc1", Relation: "parent"},
+	}}}
+
+	operations, err := parseBatchWriteRequest(&api.BatchWriteRequest{Operations: []*api.WriteOperation{del, write}})
+	if err != nil {
+		t.Fatalf("parseBatchWriteRequest returned error: %v", err)
+	}
+	if len(operations) != 2 || !operations[0].delete || operations[1].delete {
+		t.Fatalf("Expected a delete followed by a write, got %+v", operations)
+	}
+
+	_, err = parseBatchWriteRequest(&api.BatchWriteRequest{Operations: []*api.WriteOperation{write, {}}})
+	if status.Code(err) != codes.InvalidArgument || !strings.HasPrefix(status.Convert(err).Message(), "operations[1]:") {
+		t.Fatalf("Expected InvalidArgument naming operations[1], got %v", err)
+	}
+
+	if _, err := parseBatchWriteRequest(&api.BatchWriteRequest{}); status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("Expected InvalidArgument for an empty batch, got %v", err)
+	}
+}
+
+func TestRejectedBatch(t *testing.T) {
+	resp := rejectedBatch(3, 1, &multipleMatchError{count: 2})
+
+	if resp.GetConsistencyToken() != "" {
+		t.Error("Expected a rejected batch to have no consistency token")
+	}
+	for i, result := range resp.GetResults() {
+		if result.GetSuccess() {
+			t.Errorf("Expected operations[%d] to be reported as not applied", i)
+		}
+	}
+	if got := resp.GetResults()[1].GetError(); !strings.Contains(got, "allow_multiple") {
+		t.Errorf("Expected the failing operation to carry its error, got %q", got)
+	}
+}
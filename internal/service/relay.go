@@ -0,0 +1,53 @@
+package service
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// ChangeRelayer moves recorded tuple changes to the change stream
+type ChangeRelayer interface {
+	RelayChanges(ctx context.Context) (int, error)
+}
+
+// ChangeRelay periodically relays the tuple changes that writers committed
+// but did not relay themselves, such as after a crash or a Redis outage
+type ChangeRelay struct {
+	store    ChangeRelayer
+	interval time.Duration
+}
+
+// NewChangeRelay creates a relay running at the given interval
+func NewChangeRelay(store ChangeRelayer, interval time.Duration) *ChangeRelay {
+	return &ChangeRelay{store: store, interval: interval}
+}
+
+// Run relays changes until the context is cancelled
+// A non-positive interval disables the relay
+func (r *ChangeRelay) Run(ctx context.Context) {
+	if r.interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			relayed, err := r.store.RelayChanges(ctx)
+			if err != nil {
+				if ctx.Err() == nil {
+					log.Printf("Warning: failed to relay tuple changes: %v", err)
+				}
+				continue
+			}
+			if relayed > 0 {
+				log.Printf("Relayed %d pending tuple changes", relayed)
+			}
+		}
+	}
+}
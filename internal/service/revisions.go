@@ -0,0 +1,229 @@
+package service
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/DangVTNhan/goacl/api"
+	"github.com/DangVTNhan/goacl/internal/database"
+	"github.com/DangVTNhan/goacl/internal/rewrite"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ListNamespaceRevisions returns the revisions of a namespace configuration,
+// newest first, one page at a time
+func (s *ConfigurationService) ListNamespaceRevisions(ctx context.Context, req *api.ListNamespaceRevisionsRequest) (*api.ListNamespaceRevisionsResponse, error) {
+	if req.GetNamespace() == "" {
+		return nil, status.Error(codes.InvalidArgument, "namespace is required")
+	}
+	if req.GetPageSize() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "page_size must not be negative")
+	}
+
+	token, err := decodePageToken(req.GetPageToken())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	var before int64
+	if token.After != "" {
+		before, err = strconv.ParseInt(token.After, 10, 64)
+		if err != nil || before <= 0 {
+			return nil, status.Error(codes.InvalidArgument, "invalid page token")
+		}
+	}
+
+	page, err := s.store.ReadNamespaceRevisions(ctx, req.GetNamespace(), before, pageSize(req.GetPageSize()))
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	resp := &api.ListNamespaceRevisionsResponse{
+		Revisions: make([]*api.NamespaceRevision, len(page.Revisions)),
+	}
+	for i, revision := range page.Revisions {
+		resp.Revisions[i] = revisionToProto(revision)
+	}
+	if page.Before != 0 {
+		resp.NextPageToken = encodePageToken(pageToken{After: strconv.FormatInt(page.Before, 10)})
+	}
+
+	return resp, nil
+}
+
+// ReadNamespaceRevision returns a namespace configuration as a revision
+// wrote it
+func (s *ConfigurationService) ReadNamespaceRevision(ctx context.Context, req *api.ReadNamespaceRevisionRequest) (*api.ReadNamespaceRevisionResponse, error) {
+	if req.GetNamespace() == "" {
+		return nil, status.Error(codes.InvalidArgument, "namespace is required")
+	}
+	if req.GetVersion() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "version must be positive")
+	}
+
+	revision, err := s.store.ReadNamespaceRevision(ctx, req.GetNamespace(), req.GetVersion())
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &api.ReadNamespaceRevisionResponse{Revision: revisionToProto(*revision)}, nil
+}
+
+// DiffNamespaceRevisions compares two revisions of a namespace configuration
+// relation by relation, against the current one when no newer revision is
+// named
+func (s *ConfigurationService) DiffNamespaceRevisions(ctx context.Context, req *api.DiffNamespaceRevisionsRequest) (*api.DiffNamespaceRevisionsResponse, error) {
+	if req.GetNamespace() == "" {
+		return nil, status.Error(codes.InvalidArgument, "namespace is required")
+	}
+	if req.GetFromVersion() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "from_version must be positive")
+	}
+	if req.GetToVersion() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "to_version must not be negative")
+	}
+
+	from, err := s.store.ReadNamespaceRevision(ctx, req.GetNamespace(), req.GetFromVersion())
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	var to database.NamespaceConfig
+	if req.GetToVersion() == 0 {
+		current, err := s.store.GetNamespaceConfig(ctx, req.GetNamespace())
+		if err != nil {
+			return nil, toStatusError(err)
+		}
+		to = *current
+	} else {
+		revision, err := s.store.ReadNamespaceRevision(ctx, req.GetNamespace(), req.GetToVersion())
+		if err != nil {
+			return nil, toStatusError(err)
+		}
+		to = revision.Config()
+	}
+
+	return &api.DiffNamespaceRevisionsResponse{
+		FromVersion: from.Version,
+		ToVersion:   to.Version,
+		Changes:     diffRelations(from.Relations, to.Relations),
+	}, nil
+}
+
+// RollbackNamespace restores the configuration of an earlier revision
+// History is never rewritten: the restored configuration is written as a new
+// revision, which can itself be rolled back
+// Rollbacks are checked for breaking changes like other updates: tuples
+// written since the revision may rely on relations it did not have
+func (s *ConfigurationService) RollbackNamespace(ctx context.Context, req *api.RollbackNamespaceRequest) (*api.RollbackNamespaceResponse, error) {
+	if req.GetNamespace() == "" {
+		return nil, status.Error(codes.InvalidArgument, "namespace is required")
+	}
+	if req.GetVersion() <= 0 {
+		return nil, status.Error(codes.InvalidArgument, "version must be positive")
+	}
+	if req.GetExpectedVersion() < 0 {
+		return nil, status.Error(codes.InvalidArgument, "expected_version must not be negative")
+	}
+
+	revision, err := s.store.ReadNamespaceRevision(ctx, req.GetNamespace(), req.GetVersion())
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	expected := req.GetExpectedVersion()
+	if expected == 0 {
+		current, err := s.store.GetNamespaceConfig(ctx, req.GetNamespace())
+		if err != nil {
+			return nil, toStatusError(err)
+		}
+		expected = current.Version
+	}
+	if revision.Version == expected {
+		return nil, status.Errorf(codes.FailedPrecondition, "namespace %s is already at version %d", req.GetNamespace(), expected)
+	}
+
+	write := database.NamespaceWrite{
+		Config:          revision.Config(),
+		AllowUpdate:     true,
+		ExpectedVersion: expected,
+		Author:          req.GetAuthor(),
+	}
+	if !req.GetAllowBreakingChanges() {
+		write.Check = breakingCheck("config", namespaceToProto(revision.Config()))
+	}
+
+	written, commitTs, err := s.store.WriteNamespaceConfig(ctx, write)
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	return &api.RollbackNamespaceResponse{
+		ConsistencyToken: database.EncodeConsistencyToken(commitTs),
+		Config:           namespaceToProto(*written),
+	}, nil
+}
+
+// revisionToProto converts a stored namespace revision to its API form
+func revisionToProto(revision database.NamespaceRevision) *api.NamespaceRevision {
+	return &api.NamespaceRevision{
+		Namespace: revision.Namespace,
+		Version:   revision.Version,
+		Author:    revision.Author,
+		CreatedAt: timestampFromString(revision.CreatedAt),
+		Config:    namespaceToProto(revision.Config()),
+	}
+}
+
+// diffRelations lists the relations added, removed or modified between two
+// configurations, in the order of the older one followed by added relations
+// Rewrite rules are compared by meaning, so reformatted JSON is no change
+func diffRelations(from, to []database.RelationConfig) []*api.RelationDiff {
+	newer := make(map[string]database.RelationConfig, len(to))
+	for _, rel := range to {
+		newer[rel.Name] = rel
+	}
+	older := make(map[string]bool, len(from))
+
+	var changes []*api.RelationDiff
+	for _, rel := range from {
+		older[rel.Name] = true
+		changed, ok := newer[rel.Name]
+		switch {
+		case !ok:
+			changes = append(changes, &api.RelationDiff{
+				Relation: rel.Name,
+				Type:     api.RelationDiff_CHANGE_TYPE_REMOVED,
+				From:     relationToProto(rel),
+			})
+		case changed.AllowWildcard != rel.AllowWildcard || normalizedRules(changed.RewriteRules) != normalizedRules(rel.RewriteRules):
+			changes = append(changes, &api.RelationDiff{
+				Relation: rel.Name,
+				Type:     api.RelationDiff_CHANGE_TYPE_MODIFIED,
+				From:     relationToProto(rel),
+				To:       relationToProto(changed),
+			})
+		}
+	}
+	for _, rel := range to {
+		if !older[rel.Name] {
+			changes = append(changes, &api.RelationDiff{
+				Relation: rel.Name,
+				Type:     api.RelationDiff_CHANGE_TYPE_ADDED,
+				To:       relationToProto(rel),
+			})
+		}
+	}
+
+	return changes
+}
+
+// normalizedRules returns the canonical form of rewrite rules, or the rules
+// themselves when they cannot be parsed
+func normalizedRules(rules string) string {
+	rule, err := rewrite.Parse(rules)
+	if err != nil {
+		return rules
+	}
+	return rule.String()
+}
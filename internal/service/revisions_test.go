@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/DangVTNhan/goacl/api"
+	"github.com/DangVTNhan/goacl/internal/database"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// teamRevisions returns three revisions of a teams namespace, newest first
+func teamRevisions() []database.NamespaceRevision {
+	member := database.RelationConfig{Name: "member", RewriteRules: `{"union": {"child": [{"_this": {}}]}}`}
+	lead := database.RelationConfig{Name: "lead", RewriteRules: `{"union": {"child": [{"_this": {}}]}}`}
+	broken := database.RelationConfig{Name: "lead", RewriteRules: `{"union": {"child": [{"computed_userset": {"relation": "member"}}]}}`}
+	admin := database.RelationConfig{Name: "admin", RewriteRules: `{"union":{"child":[{"_this":{}}]}}`}
+
+	return []database.NamespaceRevision{
+		{Namespace: "teams", Version: 3, Author: "bob", Relations: []database.RelationConfig{member, broken, admin}},
+		{Namespace: "teams", Version: 2, Author: "alice", Relations: []database.RelationConfig{member, lead}},
+		{Namespace: "teams", Version: 1, Author: "alice", Relations: []database.RelationConfig{member}},
+	}
+}
+
+func TestListNamespaceRevisions(t *testing.T) {
+	store := &namespaceStore{memoryReader: newMemoryReader(), revisions: teamRevisions()}
+	service := NewConfigurationService(store)
+
+	var versions []int64
+	req := &api.ListNamespaceRevisionsRequest{Namespace: "teams", PageSize: 2}
+	for {
+		resp, err := service.ListNamespaceRevisions(context.Background(), req)
+		if err != nil {
+			t.Fatalf("ListNamespaceRevisions returned error: %v", err)
+		}
+		for _, revision := range resp.GetRevisions() {
+			versions = append(versions, revision.GetVersion())
+		}
+		if resp.GetNextPageToken() == "" {
+			break
+		}
+		req.PageToken = resp.GetNextPageToken()
+	}
+
+	if fmt.Sprint(versions) != "[3 2 1]" {
+		t.Errorf("Expected revisions [3 2 1], got %v", versions)
+	}
+}
+
+func TestDiffNamespaceRevisions(t *testing.T) {
+	store := &namespaceStore{memoryReader: newMemoryReader(), revisions: teamRevisions()}
+	service := NewConfigurationService(store)
+
+	resp, err := service.DiffNamespaceRevisions(context.Background(), &api.DiffNamespaceRevisionsRequest{
+		Namespace:   "teams",
+		FromVersion: 1,
+		ToVersion:   3,
+	})
+	if err != nil {
+		t.Fatalf("DiffNamespaceRevisions returned error: %v", err)
+	}
+
+	var changes []string
+	for _, change := range resp.GetChanges() {
+		changes = append(changes, change.GetRelation()+" "+change.GetType().String())
+	}
+	expected := []string{"lead CHANGE_TYPE_ADDED", "admin CHANGE_TYPE_ADDED"}
+	if fmt.Sprint(changes) != fmt.Sprint(expected) {
+		t.Errorf("Expected changes %v, got %v", expected, changes)
+	}
+
+	resp, err = service.DiffNamespaceRevisions(context.Background(), &api.DiffNamespaceRevisionsRequest{
+		Namespace:   "teams",
+		FromVersion: 2,
+		ToVersion:   3,
+	})
+	if err != nil {
+		t.Fatalf("DiffNamespaceRevisions returned error: %v", err)
+	}
+
+	// Only the lead rules changed meaning; admin is new
+	changes = nil
+	for _, change := range resp.GetChanges() {
+		changes = append(changes, change.GetRelation()+" "+change.GetType().String())
+	}
+	expected = []string{"lead CHANGE_TYPE_MODIFIED", "admin CHANGE_TYPE_ADDED"}
+	if fmt.Sprint(changes) != fmt.Sprint(expected) {
+		t.Errorf("Expected changes %v, got %v", expected, changes)
+	}
+}
+
+func TestRollbackNamespace(t *testing.T) {
+	store := &namespaceStore{memoryReader: newMemoryReader(), revisions: teamRevisions(), readTs: 20}
+	service := NewConfigurationService(store)
+
+	resp, err := service.RollbackNamespace(context.Background(), &api.RollbackNamespaceRequest{
+		Namespace:       "teams",
+		Version:         2,
+		ExpectedVersion: 3,
+		Author:          "carol",
+	})
+	if err != nil {
+		t.Fatalf("RollbackNamespace returned error: %v", err)
+	}
+	if resp.GetConfig().GetVersion() != 4 || len(resp.GetConfig().GetRelations()) != 2 {
+		t.Errorf("Expected the relations of version 2 written as version 4, got %v", resp.GetConfig())
+	}
+	if write := store.writes[0]; !write.AllowUpdate || write.ExpectedVersion != 3 || write.Author != "carol" {
+		t.Errorf("Expected an update of version 3 by carol, got %+v", write)
+	}
+
+	_, err = service.RollbackNamespace(context.Background(), &api.RollbackNamespaceRequest{Namespace: "teams", Version: 3, ExpectedVersion: 3})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Expected FailedPrecondition rolling back to the current version, got %v", err)
+	}
+
+	_, err = service.RollbackNamespace(context.Background(), &api.RollbackNamespaceRequest{Namespace: "teams", Version: 7})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("Expected NotFound for a missing revision, got %v", err)
+	}
+}
+
+func TestRollbackNamespaceBreakingChanges(t *testing.T) {
+	store := &namespaceStore{memoryReader: newMemoryReader(), revisions: teamRevisions(), readTs: 20}
+	current := teamRevisions()[0].Config()
+	store.namespaces["teams"] = &current
+	store.add("teams", "core", "admin", "alice")
+	service := NewConfigurationService(store)
+
+	// Version 2 has no admin relation, which now has a tuple
+	_, err := service.RollbackNamespace(context.Background(), &api.RollbackNamespaceRequest{Namespace: "teams", Version: 2})
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("Expected FailedPrecondition for a breaking rollback, got %v", err)
+	}
+	if len(store.writes) != 0 {
+		t.Errorf("Expected the breaking rollback not to be written, got %+v", store.writes)
+	}
+
+	_, err = service.RollbackNamespace(context.Background(), &api.RollbackNamespaceRequest{Namespace: "teams", Version: 2, AllowBreakingChanges: true})
+	if err != nil {
+		t.Fatalf("RollbackNamespace returned error: %v", err)
+	}
+	if len(store.writes) != 1 || store.writes[0].ExpectedVersion != 3 {
+		t.Errorf("Expected an update of version 3, got %+v", store.writes)
+	}
+}
@@ -0,0 +1,130 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/DangVTNhan/goacl/api"
+	"github.com/DangVTNhan/goacl/internal/rewrite"
+	"google.golang.org/protobuf/proto"
+)
+
+// rewriteToProto converts a rewrite rule to its typed API form
+func rewriteToProto(r *rewrite.Rule) *api.Rewrite {
+	if r == nil {
+		return nil
+	}
+
+	switch {
+	case r.This != nil:
+		return &api.Rewrite{Rewrite: &api.Rewrite_This{This: &api.RewriteThis{}}}
+	case r.ComputedUserset != nil:
+		return &api.Rewrite{Rewrite: &api.Rewrite_ComputedUserset{
+			ComputedUserset: &api.RewriteComputedUserset{Relation: r.ComputedUserset.Relation},
+		}}
+	case r.TupleToUserset != nil:
+		return &api.Rewrite{Rewrite: &api.Rewrite_TupleToUserset{TupleToUserset: &api.RewriteTupleToUserset{
+			Tupleset:        &api.RewriteTupleset{Relation: r.TupleToUserset.Tupleset.Relation},
+			ComputedUserset: &api.RewriteComputedUserset{Relation: r.TupleToUserset.ComputedUserset.Relation},
+		}}}
+	case r.Union != nil:
+		return &api.Rewrite{Rewrite: &api.Rewrite_Union{Union: setOperationToProto(r.Union)}}
+	case r.Intersection != nil:
+		return &api.Rewrite{Rewrite: &api.Rewrite_Intersection{Intersection: setOperationToProto(r.Intersection)}}
+	case r.Exclusion != nil:
+		return &api.Rewrite{Rewrite: &api.Rewrite_Exclusion{Exclusion: &api.RewriteExclusion{
+			Base:    rewriteToProto(r.Exclusion.Base),
+			Exclude: rewriteToProto(r.Exclusion.Exclude),
+		}}}
+	}
+	return &api.Rewrite{}
+}
+
+func setOperationToProto(op *rewrite.SetOperation) *api.RewriteSetOperation {
+	result := &api.RewriteSetOperation{Child: make([]*api.Rewrite, len(op.Child))}
+	for i, child := range op.Child {
+		result.Child[i] = rewriteToProto(child)
+	}
+	return result
+}
+
+// rewriteFromProto converts a typed rewrite tree to a rewrite rule
+// Malformed trees convert to malformed rules, which fail validation at the
+// same path the tree has
+func rewriteFromProto(r *api.Rewrite) *rewrite.Rule {
+	if r == nil {
+		return nil
+	}
+
+	switch node := r.GetRewrite().(type) {
+	case *api.Rewrite_This:
+		return &rewrite.Rule{This: &rewrite.This{}}
+	case *api.Rewrite_ComputedUserset:
+		return &rewrite.Rule{ComputedUserset: &rewrite.ComputedUserset{Relation: node.ComputedUserset.GetRelation()}}
+	case *api.Rewrite_TupleToUserset:
+		return &rewrite.Rule{TupleToUserset: &rewrite.TupleToUserset{
+			Tupleset:        rewrite.Tupleset{Relation: node.TupleToUserset.GetTupleset().GetRelation()},
+			ComputedUserset: rewrite.ComputedUserset{Relation: node.TupleToUserset.GetComputedUserset().GetRelation()},
+		}}
+	case *api.Rewrite_Union:
+		return &rewrite.Rule{Union: setOperationFromProto(node.Union)}
+	case *api.Rewrite_Intersection:
+		return &rewrite.Rule{Intersection: setOperationFromProto(node.Intersection)}
+	case *api.Rewrite_Exclusion:
+		return &rewrite.Rule{Exclusion: &rewrite.Exclusion{
+			Base:    rewriteFromProto(node.Exclusion.GetBase()),
+			Exclude: rewriteFromProto(node.Exclusion.GetExclude()),
+		}}
+	}
+	return &rewrite.Rule{}
+}
+
+func setOperationFromProto(op *api.RewriteSetOperation) *rewrite.SetOperation {
+	result := &rewrite.SetOperation{Child: make([]*rewrite.Rule, len(op.GetChild()))}
+	for i, child := range op.GetChild() {
+		result.Child[i] = rewriteFromProto(child)
+	}
+	return result
+}
+
+// typedRewrites returns the configuration with every typed rewrite tree
+// converted to JSON rewrite rules, and the indexes of the relations that
+// were typed
+// A relation may set only one of the two forms
+func typedRewrites(field string, config *api.NamespaceConfig) (*api.NamespaceConfig, map[int]bool, *api.ValidationError) {
+	typed := make(map[int]bool)
+	for i, rel := range config.GetRelations() {
+		if rel.GetRewrite() == nil {
+			continue
+		}
+		if rel.GetRewriteRules() != "" {
+			return nil, nil, &api.ValidationError{
+				Field:   fmt.Sprintf("%s.relations[%d]", field, i),
+				Message: "only one of rewrite_rules and rewrite may be set",
+				Code:    "CONFLICTING_FIELDS",
+			}
+		}
+		typed[i] = true
+	}
+	if len(typed) == 0 {
+		return config, typed, nil
+	}
+
+	config = proto.Clone(config).(*api.NamespaceConfig)
+	for i := range typed {
+		rel := config.Relations[i]
+		rel.RewriteRules = rewriteFromProto(rel.Rewrite).String()
+		rel.Rewrite = nil
+	}
+	return config, typed, nil
+}
+
+// typedField renames the rewrite_rules field of a relation that was given
+// as a typed tree to rewrite
+func typedField(field string, typed map[int]bool, problemField string) string {
+	for i := range typed {
+		if problemField == fmt.Sprintf("%s.relations[%d].rewrite_rules", field, i) {
+			return fmt.Sprintf("%s.relations[%d].rewrite", field, i)
+		}
+	}
+	return problemField
+}
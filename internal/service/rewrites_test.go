@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DangVTNhan/goacl/api"
+	"github.com/DangVTNhan/goacl/internal/rewrite"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestRewriteRoundTrip(t *testing.T) {
+	rules := []string{
+		`{"exclusion": {"base": {"intersection": {"child": [{"_this": {}}, {"computed_userset": {"relation": "member"}}]}}, "exclude": {"computed_userset": {"relation": "blocked"}}}}`,
+	}
+	for _, ns := range initialNamespaces() {
+		for _, rel := range ns.GetRelations() {
+			rules = append(rules, rel.GetRewriteRules())
+		}
+	}
+
+	for _, rules := range rules {
+		rule, err := rewrite.Parse(rules)
+		if err != nil {
+			t.Fatalf("Failed to parse %s: %v", rules, err)
+		}
+
+		typed := rewriteToProto(rule)
+		if got := rewriteFromProto(typed).String(); got != rule.String() {
+			t.Errorf("Expected %s to survive conversion, got %s", rule, got)
+		}
+
+		// The typed tree survives the wire as well
+		data, err := proto.Marshal(typed)
+		if err != nil {
+			t.Fatalf("Failed to marshal rewrite: %v", err)
+		}
+		decoded := &api.Rewrite{}
+		if err := proto.Unmarshal(data, decoded); err != nil {
+			t.Fatalf("Failed to unmarshal rewrite: %v", err)
+		}
+		if !proto.Equal(typed, decoded) {
+			t.Errorf("Expected %v to survive marshaling, got %v", typed, decoded)
+		}
+	}
+}
+
+func TestWriteNamespaceTypedRewrite(t *testing.T) {
+	store := &namespaceStore{memoryReader: newMemoryReader(), readTs: 10}
+	service := NewConfigurationService(store)
+
+	member := &api.Rewrite{Rewrite: &api.Rewrite_ComputedUserset{ComputedUserset: &api.RewriteComputedUserset{Relation: "member"}}}
+	lead := &api.Rewrite{Rewrite: &api.Rewrite_Union{Union: &api.RewriteSetOperation{
+		Child: []*api.Rewrite{{Rewrite: &api.Rewrite_This{This: &api.RewriteThis{}}}, member},
+	}}}
+
+	resp, err := service.WriteNamespace(context.Background(), &api.WriteNamespaceRequest{
+		Config: &api.NamespaceConfig{
+			Name: "teams",
+			Relations: []*api.RelationConfig{
+				{Name: "member", RewriteRules: `{"_this": {}}`},
+				{Name: "lead", Rewrite: lead},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("WriteNamespace returned error: %v", err)
+	}
+	if got := store.writes[0].Config.Relations[1].RewriteRules; got != `{"union":{"child":[{"_this":{}},{"computed_userset":{"relation":"member"}}]}}` {
+		t.Errorf("Expected the typed rewrite to be stored as JSON, got %s", got)
+	}
+	if !proto.Equal(resp.GetConfig().GetRelations()[1].GetRewrite(), lead) {
+		t.Errorf("Expected the response to carry the typed rewrite, got %v", resp.GetConfig().GetRelations()[1])
+	}
+	if resp.GetConfig().GetRelations()[0].GetRewrite().GetThis() == nil {
+		t.Errorf("Expected JSON rewrite rules to be returned as a typed rewrite too, got %v", resp.GetConfig().GetRelations()[0])
+	}
+
+	_, err = service.WriteNamespace(context.Background(), &api.WriteNamespaceRequest{
+		Config: &api.NamespaceConfig{
+			Name:      "teams",
+			Relations: []*api.RelationConfig{{Name: "lead", RewriteRules: `{"_this": {}}`, Rewrite: lead}},
+		},
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("Expected InvalidArgument for both forms, got %v", err)
+	}
+
+	// Problems in a typed rewrite are reported against it
+	validation, err := service.ValidateNamespace(context.Background(), &api.ValidateNamespaceRequest{
+		Config: &api.NamespaceConfig{
+			Name: "teams",
+			Relations: []*api.RelationConfig{{Name: "lead", Rewrite: &api.Rewrite{Rewrite: &api.Rewrite_Union{Union: &api.RewriteSetOperation{
+				Child: []*api.Rewrite{member},
+			}}}}},
+		},
+	})
+	if err != nil {
+		t.Fatalf("ValidateNamespace returned error: %v", err)
+	}
+	if got := validation.GetErrors(); len(got) != 1 || got[0].GetField() != "config.relations[0].rewrite" || got[0].GetPath() != "$.union.child[0].computed_userset.relation" {
+		t.Errorf("Expected the undefined relation in config.relations[0].rewrite, got %v", got)
+	}
+}
@@ -0,0 +1,156 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/DangVTNhan/goacl/api"
+	"github.com/DangVTNhan/goacl/internal/condition"
+	"github.com/DangVTNhan/goacl/internal/database"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// tupleFromProto validates a relation tuple received in a request and
+// converts it to its stored form
+func tupleFromProto(tuple *api.RelationTuple) (database.RelationTuple, error) {
+	var result database.RelationTuple
+
+	switch {
+	case tuple == nil:
+		return result, errors.New("tuple is required")
+	case tuple.GetNamespace() == "":
+		return result, errors.New("namespace is required")
+	case tuple.GetObjectId() == "":
+		return result, errors.New("object_id is required")
+	case tuple.GetRelation() == "":
+		return result, errors.New("relation is required")
+	case tuple.GetUserId() == "" && tuple.GetUserset() == "":
+		return result, errors.New("one of user_id or userset is required")
+	case tuple.GetUserId() != "" && tuple.GetUserset() != "":
+		return result, errors.New("only one of user_id or userset may be set")
+	}
+
+	if tuple.GetUserset() != "" {
+		if _, err := parseUserset(tuple.GetUserset()); err != nil {
+			return result, err
+		}
+	}
+
+	if tuple.GetCondition() != "" {
+		if _, err := condition.Parse(tuple.GetCondition()); err != nil {
+			return result, fmt.Errorf("invalid condition: %w", err)
+		}
+	}
+
+	result = database.RelationTuple{
+		Namespace:  tuple.GetNamespace(),
+		ObjectID:   tuple.GetObjectId(),
+		Relation:   tuple.GetRelation(),
+		UserID:     tuple.GetUserId(),
+		Userset:    tuple.GetUserset(),
+		Conditions: tuple.GetCondition(),
+	}
+
+	if tuple.GetExpiresAt() != nil {
+		if err := tuple.GetExpiresAt().CheckValid(); err != nil {
+			return result, fmt.Errorf("invalid expires_at: %w", err)
+		}
+		result.ExpiresAt = tuple.GetExpiresAt().AsTime().UTC().Format(time.RFC3339Nano)
+	}
+
+	return result, nil
+}
+
+// preconditionFromProto validates a write precondition and converts it to
+// its database form
+func preconditionFromProto(precondition *api.Precondition) (database.Precondition, error) {
+	var result database.Precondition
+
+	switch precondition.GetType() {
+	case api.PreconditionType_PRECONDITION_TYPE_MUST_EXIST:
+		result.MustExist = true
+	case api.PreconditionType_PRECONDITION_TYPE_MUST_NOT_EXIST:
+	default:
+		return result, errors.New("type must be MUST_EXIST or MUST_NOT_EXIST")
+	}
+
+	tuple, err := tupleFromProto(precondition.GetTuple())
+	if err != nil {
+		return result, err
+	}
+	result.Tuple = tuple
+
+	return result, nil
+}
+
+// tupleFilterFromProto validates a relation filter and converts it to its
+// database form
+func tupleFilterFromProto(filter *api.RelationFilter) (database.TupleFilter, error) {
+	result := database.TupleFilter{
+		Namespace: filter.GetNamespace(),
+		ObjectID:  filter.GetObjectId(),
+		Relation:  filter.GetRelation(),
+		UserID:    filter.GetUserId(),
+		Userset:   filter.GetUserset(),
+	}
+
+	if result.UserID != "" && result.Userset != "" {
+		return result, errors.New("only one of user_id or userset may be set")
+	}
+	if result.Userset != "" {
+		if _, err := parseUserset(result.Userset); err != nil {
+			return result, err
+		}
+	}
+
+	return result, nil
+}
+
+// tupleToProto converts a stored relation tuple to its API form
+func tupleToProto(tuple database.RelationTuple) *api.RelationTuple {
+	return &api.RelationTuple{
+		Namespace: tuple.Namespace,
+		ObjectId:  tuple.ObjectID,
+		Relation:  tuple.Relation,
+		UserId:    tuple.UserID,
+		Userset:   tuple.Userset,
+		Condition: tuple.Conditions,
+		CreatedAt: timestampFromString(tuple.CreatedAt),
+		UpdatedAt: timestampFromString(tuple.UpdatedAt),
+		ExpiresAt: timestampFromString(tuple.ExpiresAt),
+	}
+}
+
+// changeToProto converts a recorded change to its watch response
+func changeToProto(change database.Change) *api.WatchRelationsResponse {
+	changeType := api.ChangeType_CHANGE_TYPE_UNSPECIFIED
+	switch change.Type {
+	case database.ChangeCreated:
+		changeType = api.ChangeType_CHANGE_TYPE_CREATED
+	case database.ChangeUpdated:
+		changeType = api.ChangeType_CHANGE_TYPE_UPDATED
+	case database.ChangeDeleted:
+		changeType = api.ChangeType_CHANGE_TYPE_DELETED
+	}
+
+	return &api.WatchRelationsResponse{
+		ChangeType:       changeType,
+		Tuple:            tupleToProto(change.Tuple),
+		ConsistencyToken: database.EncodeChangeToken(change.CommitTs, change.Position),
+		ChangedAt:        timestamppb.New(change.ChangedAt),
+	}
+}
+
+// timestampFromString converts an optional RFC 3339 time to a timestamp,
+// returning nil when it is empty or cannot be parsed
+func timestampFromString(value string) *timestamppb.Timestamp {
+	if value == "" {
+		return nil
+	}
+	t, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return nil
+	}
+	return timestamppb.New(t)
+}
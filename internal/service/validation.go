@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/DangVTNhan/goacl/internal/database"
+	"github.com/DangVTNhan/goacl/internal/rewrite"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// NamespaceReader reads namespace configurations
+type NamespaceReader interface {
+	GetNamespaceConfig(ctx context.Context, name string) (*database.NamespaceConfig, error)
+}
+
+// schemaValidator checks tuples against the namespace configurations,
+// reading each namespace at most once
+type schemaValidator struct {
+	reader     NamespaceReader
+	namespaces map[string]*namespaceSchema
+
+	violations []*errdetails.BadRequest_FieldViolation
+}
+
+// namespaceSchema is what a namespace configuration allows tuples to hold
+// A nil schema marks a namespace that does not exist
+type namespaceSchema struct {
+	relations map[string]relationSchema
+}
+
+// relationSchema is what a relation allows tuples to hold
+type relationSchema struct {
+	direct        bool
+	allowWildcard bool
+}
+
+func newSchemaValidator(reader NamespaceReader) *schemaValidator {
+	return &schemaValidator{
+		reader:     reader,
+		namespaces: make(map[string]*namespaceSchema),
+	}
+}
+
+// validate records a violation for every way the tuple does not fit the
+// namespace configuration, naming fields relative to field
+func (v *schemaValidator) validate(ctx context.Context, field string, tuple database.RelationTuple) error {
+	ns, err := v.namespace(ctx, tuple.Namespace)
+	if err != nil {
+		return err
+	}
+	if ns == nil {
+		v.violate(field+".namespace", "namespace %s does not exist", tuple.Namespace)
+		return nil
+	}
+
+	relation, ok := ns.relations[tuple.Relation]
+	switch {
+	case !ok:
+		v.violate(field+".relation", "relation %s is not defined in namespace %s", tuple.Relation, tuple.Namespace)
+	case !relation.direct:
+		v.violate(field+".relation", "relation %s#%s is computed and cannot hold direct tuples", tuple.Namespace, tuple.Relation)
+	case tuple.Userset == "" && isWildcard(tuple.UserID) && !relation.allowWildcard:
+		v.violate(field+".user_id", "relation %s#%s does not allow wildcard subjects", tuple.Namespace, tuple.Relation)
+	}
+
+	if tuple.Userset == "" {
+		return nil
+	}
+
+	ref, err := parseUserset(tuple.Userset)
+	if err != nil {
+		v.violate(field+".userset", "%v", err)
+		return nil
+	}
+
+	subject, err := v.namespace(ctx, ref.Namespace)
+	if err != nil {
+		return err
+	}
+	switch {
+	case subject == nil:
+		v.violate(field+".userset", "namespace %s does not exist", ref.Namespace)
+	case ref.Relation != "":
+		if _, ok := subject.relations[ref.Relation]; !ok {
+			v.violate(field+".userset", "relation %s is not defined in namespace %s", ref.Relation, ref.Namespace)
+		}
+	}
+
+	return nil
+}
+
+// err returns the recorded violations as an InvalidArgument status error,
+// or nil when there are none
+func (v *schemaValidator) err() error {
+	return badRequest(v.violations)
+}
+
+// reset forgets the recorded violations
+func (v *schemaValidator) reset() {
+	v.violations = nil
+}
+
+func (v *schemaValidator) violate(field, format string, args ...interface{}) {
+	v.violations = append(v.violations, fieldViolation(field, format, args...))
+}
+
+// namespace returns the schema of a namespace, or nil when it does not exist
+func (v *schemaValidator) namespace(ctx context.Context, name string) (*namespaceSchema, error) {
+	if ns, ok := v.namespaces[name]; ok {
+		return ns, nil
+	}
+
+	config, err := v.reader.GetNamespaceConfig(ctx, name)
+	if errors.Is(err, database.ErrNotFound) {
+		v.namespaces[name] = nil
+		return nil, nil
+	}
+	if err != nil {
+		return nil, toStatusError(err)
+	}
+
+	ns := &namespaceSchema{relations: make(map[string]relationSchema, len(config.Relations))}
+	for _, rel := range config.Relations {
+		// A relation whose rules cannot be parsed fails every check, so it
+		// is treated as unable to hold tuples
+		rule, err := rewrite.Parse(rel.RewriteRules)
+		ns.relations[rel.Name] = relationSchema{
+			direct:        err == nil && rule.Direct(),
+			allowWildcard: rel.AllowWildcard,
+		}
+	}
+
+	v.namespaces[name] = ns
+	return ns, nil
+}
+
+// fieldViolation describes an invalid request field
+func fieldViolation(field, format string, args ...interface{}) *errdetails.BadRequest_FieldViolation {
+	return &errdetails.BadRequest_FieldViolation{
+		Field:       field,
+		Description: fmt.Sprintf(format, args...),
+	}
+}
+
+// badRequest returns the violations as an InvalidArgument status error, or
+// nil when there are none
+func badRequest(violations []*errdetails.BadRequest_FieldViolation) error {
+	if len(violations) == 0 {
+		return nil
+	}
+
+	message := violations[0].GetField() + ": " + violations[0].GetDescription()
+	if len(violations) > 1 {
+		message += fmt.Sprintf(" (and %d more)", len(violations)-1)
+	}
+
+	st := status.New(codes.InvalidArgument, message)
+	if detailed, detailErr := st.WithDetails(&errdetails.BadRequest{FieldViolations: violations}); detailErr == nil {
+		st = detailed
+	}
+	return st.Err()
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"testing"
+
+	"github.com/DangVTNhan/goacl/internal/database"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSchemaValidator(t *testing.T) {
+	reader := newMemoryReader()
+	reader.allowWildcard("documents", "viewer")
+	reader.namespaces["documents"].Relations = append(reader.namespaces["documents"].Relations, database.RelationConfig{
+		Name:         "can_view",
+		RewriteRules: `{"computed_userset": {"relation": "viewer"}}`,
+	})
+
+	tests := []struct {
+		name  string
+		tuple database.RelationTuple
+		field string
+	}{
+		{"valid user", database.RelationTuple{Namespace: "documents", ObjectID: "doc1", Relation: "viewer", UserID: "alice"}, ""},
+		{"valid userset", database.RelationTuple{Namespace: "documents", ObjectID: "doc1", Relation: "viewer", Userset: "groups:eng#member"}, ""},
+		{"valid tupleset", database.RelationTuple{Namespace: "folders", ObjectID: "f1", Relation: "parent", Userset: "folders:root"}, ""},
+		{"valid wildcard", database.RelationTuple{Namespace: "documents", ObjectID: "doc1", Relation: "viewer", UserID: "*"}, ""},
+		{"unknown namespace", database.RelationTuple{Namespace: "document", ObjectID: "doc1", Relation: "viewer", UserID: "alice"}, "tuples[0].namespace"},
+		{"unknown relation", database.RelationTuple{Namespace: "documents", ObjectID: "doc1", Relation: "viwer", UserID: "alice"}, "tuples[0].relation"},
+		{"computed relation", database.RelationTuple{Namespace: "documents", ObjectID: "doc1", Relation: "can_view", UserID: "alice"}, "tuples[0].relation"},
+		{"wildcard not allowed", database.RelationTuple{Namespace: "documents", ObjectID: "doc1", Relation: "owner", UserID: "*"}, "tuples[0].user_id"},
+		{"unknown userset namespace", database.RelationTuple{Namespace: "documents", ObjectID: "doc1", Relation: "viewer", Userset: "group:eng#member"}, "tuples[0].userset"},
+		{"unknown userset relation", database.RelationTuple{Namespace: "documents", ObjectID: "doc1", Relation: "viewer", Userset: "groups:eng#members"}, "tuples[0].userset"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := newSchemaValidator(reader)
+			if err := validator.validate(context.Background(), "tuples[0]", tt.tuple); err != nil {
+				t.Fatalf("validate returned error: %v", err)
+			}
+
+			err := validator.err()
+			if tt.field == "" {
+				if err != nil {
+					t.Fatalf("Expected tuple to be valid, got %v", err)
+				}
+				return
+			}
+
+			st := status.Convert(err)
+			if st.Code() != codes.InvalidArgument {
+				t.Fatalf("Expected InvalidArgument, got %v", err)
+			}
+			var violations []*errdetails.BadRequest_FieldViolation
+			for _, detail := range st.Details() {
+				if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+					violations = badRequest.GetFieldViolations()
+				}
+			}
+			if len(violations) != 1 || violations[0].GetField() != tt.field {
+				t.Errorf("Expected a violation of %s, got %v", tt.field, violations)
+			}
+		})
+	}
+}